@@ -1,20 +1,62 @@
 package main
 
 import (
-	"log"
+	"fmt"
 	"os"
+	"path/filepath"
+	"runtime/debug"
 
 	"elyria.io/mog/internal/mog"
+	"elyria.io/mog/internal/tcellscreen"
 )
 
+// version is the version of mog, which can be set when building with
+// -ldflags "-X main.version=...". Without it the version of the module is
+// used.
+var version string
+
 func main() {
-	if len(os.Args) == 1 {
-		mog.NewProgram().Start()
-	} else {
-		p, err := mog.NewProgramFromFile(os.Args[1])
-		if err != nil {
-			log.Fatalf("%+v", err)
+	args, err := mog.ParseArgs(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mog: %v\n%s", err, mog.Usage)
+		os.Exit(2)
+	}
+	// Like vi, mog is a pager when it is run as view.
+	if filepath.Base(os.Args[0]) == "view" {
+		args.Pager = true
+	}
+
+	var p *mog.Program
+	switch {
+	case args.Help:
+		fmt.Print(mog.Usage)
+	case args.Version:
+		fmt.Printf("mog %s\n", mogVersion())
+	case args.Headless:
+		os.Exit(mog.RunHeadless(args, os.Stdin, os.Stderr))
+	case args.Recover && len(args.Files) == 0:
+		err = mog.ListSwapFiles(os.Stdout, ".")
+	default:
+		var s mog.Screen
+		if s, err = tcellscreen.New(); err == nil {
+			p, err = mog.NewProgramWithArgs(s, args, os.Stdin)
 		}
-		p.Start()
 	}
+	if err == nil && p != nil {
+		err = p.Start()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "mog: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func mogVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version
+	}
+	return "(devel)"
 }
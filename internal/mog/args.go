@@ -0,0 +1,327 @@
+package mog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Args are the command line arguments of mog.
+type Args struct {
+	Files []string
+	// Commands are the ex commands run after the first file was loaded,
+	// given with +{command} or -c {command}. +{number} and +/{pattern}
+	// become the commands {number} and /{pattern}, and + alone $.
+	Commands []string
+	// Stdin is set when the buffer is read from the standard input,
+	// given as -.
+	Stdin    bool
+	ReadOnly bool
+	Recover  bool
+	// Config is the file to read instead of the config file, given with
+	// -u. NONE reads no config file at all.
+	Config string
+	// Pager makes the editor a read-only pager, which Follow makes keep
+	// the end of the text in view as more of it arrives.
+	Pager  bool
+	Follow bool
+	// Script is a file with keys that are typed after the commands were
+	// run, given with -s. Headless runs it without a terminal.
+	Script   string
+	Headless bool
+	// Clean starts the editor without any config.
+	Clean   bool
+	Version bool
+	Help    bool
+}
+
+// Usage describes the command line arguments of mog.
+const Usage = `usage: mog [arguments] [file ...]
+       mog [arguments] -
+
+Arguments:
+   --            Only file names after this
+   -             Read the text from stdin
+   +             Start at the end of the file
+   +{number}     Start at line {number}
+   +/{pattern}   Start at the first match of {pattern}
+   +{command}    Run {command} after loading the first file
+   -c {command}  Run {command} after loading the first file
+   -s {file}     Type the keys in {file} after the commands were run
+   --headless    Run the script without a terminal and write no swap files
+   -R            Read-only mode
+   -r            Recover the file from its swap file, or list swap files
+   -o[{n}]       Open the files in windows split horizontally (not supported yet)
+   -O[{n}]       Open the files in windows split vertically (not supported yet)
+   -d            Show the differences between the files (not supported yet)
+   -u {file}     Use {file} as the config file, NONE for none
+   --pager       Page through the text like less
+   --follow      Page and keep the end in view as more text arrives
+   --clean       Use no config at all
+   --version     Print the version and exit
+   -h, --help    Print this help and exit
+`
+
+// ParseArgs parses the command line arguments of mog, without the name of
+// the program.
+func ParseArgs(args []string) (*Args, error) {
+	a := &Args{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			a.Files = append(a.Files, args[i+1:]...)
+			return a, nil
+		case arg == "-":
+			a.Stdin = true
+		case arg == "+":
+			a.Commands = append(a.Commands, "$")
+		case strings.HasPrefix(arg, "+"):
+			a.Commands = append(a.Commands, arg[1:])
+		case arg == "-c" || arg == "-u" || arg == "-s":
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("argument missing after %s", arg)
+			}
+			i++
+			switch arg {
+			case "-c":
+				a.Commands = append(a.Commands, args[i])
+			case "-u":
+				a.Config = args[i]
+			default:
+				a.Script = args[i]
+			}
+		case arg == "-R":
+			a.ReadOnly = true
+		case arg == "-r":
+			a.Recover = true
+		case isSplitArg(arg) || arg == "-d":
+			// There are neither windows nor a diff mode yet.
+			return nil, fmt.Errorf("%s is not supported yet", arg)
+		case arg == "--pager":
+			a.Pager = true
+		case arg == "--follow":
+			a.Follow = true
+		case arg == "--headless":
+			a.Headless = true
+		case arg == "--clean":
+			a.Clean = true
+		case arg == "--version":
+			a.Version = true
+		case arg == "-h" || arg == "--help":
+			a.Help = true
+		case strings.HasPrefix(arg, "-"):
+			return nil, fmt.Errorf("unknown option %s", arg)
+		default:
+			a.Files = append(a.Files, arg)
+		}
+	}
+	if a.Stdin && len(a.Files) > 0 {
+		return nil, errors.New("- cannot be combined with file names")
+	}
+	return a, nil
+}
+
+// isSplitArg reports whether arg is -o or -O, optionally followed by the
+// number of windows to open.
+func isSplitArg(arg string) bool {
+	if !strings.HasPrefix(arg, "-o") && !strings.HasPrefix(arg, "-O") {
+		return false
+	}
+	return strings.Trim(arg[2:], "0123456789") == ""
+}
+
+// NewProgramWithArgs creates a new program as asked for by the command line
+// arguments, shown on s. The text is read from stdin if the arguments say
+// so.
+func NewProgramWithArgs(s Screen, a *Args, stdin io.Reader) (*Program, error) {
+	f, err := EmptyFrame(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.start(a, stdin); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &Program{frame: f}, nil
+}
+
+// start reads the config, loads the first file, runs the commands given on
+// the command line and types the keys of the script. Errors in the config
+// and the commands are shown as messages, like those of commands that were
+// typed.
+func (f *SimpleFrame) start(a *Args, stdin io.Reader) error {
+	if !a.Clean && a.Config != "NONE" {
+		f.readConfig(a.Config)
+	}
+	f.argList = a.Files
+	switch {
+	case a.Stdin && f.headless:
+		bs, err := io.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		f.loadBuffer(bs)
+	case a.Stdin:
+		f.readStdin(stdin)
+	case len(a.Files) > 0:
+		if err := f.loadFile(a.Files[0]); err != nil {
+			return err
+		}
+		f.startWatching()
+		if a.Recover {
+			if err := f.recoverFile(a.Files[0]); err != nil {
+				return err
+			}
+		}
+	default:
+		// The new buffer is written the way the configuration file says.
+		f.loadBuffer(nil)
+	}
+	f.readonly = f.readonly || a.ReadOnly
+	f.fireBufferAutocmds(BufEnter)
+	if a.Pager || a.Follow {
+		f.startPager(a.Follow)
+	}
+	for _, c := range a.Commands {
+		if err := f.Execute(c); err != nil {
+			f.showError(err)
+		}
+	}
+	f.fireBufferAutocmds(VimEnter)
+	f.autocmdTick = f.changedTick
+	f.autocmdCursor = f.cursorPos()
+	if a.Script != "" {
+		script, err := os.ReadFile(a.Script)
+		if err != nil {
+			return err
+		}
+		for _, ev := range parseKeys(string(script)) {
+			if f.HandleEvent(ev) {
+				break
+			}
+		}
+		f.flushKeys()
+	}
+	return nil
+}
+
+// exSource implements :source, which runs the ex commands in a file, one per
+// line. Empty lines and lines starting with '"' or '#' are skipped. Running
+// the file stops at the first error.
+func (f *SimpleFrame) exSource(args string) error {
+	if args == "" {
+		return editorErrorf(471, "Argument required")
+	}
+	return f.sourceFile(args, nil)
+}
+
+// sourceFile runs the ex commands in the file at path like :source. Errors
+// are prefixed with the file name and the line number. If report is nil,
+// running the file stops at the first one, otherwise every error is passed
+// to report.
+func (f *SimpleFrame) sourceFile(path string, report func(error)) error {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return editorErrorf(484, "Can't open file %s", path)
+	}
+	for i, line := range strings.Split(string(bs), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "\"") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := f.Execute(line); err != nil {
+			err = fmt.Errorf("%s:%d: %w", path, i+1, err)
+			if report == nil {
+				return err
+			}
+			report(err)
+		}
+	}
+	return nil
+}
+
+// readConfig runs the config file at path, or the one found by configFile
+// if path is empty. Every error in it is shown.
+func (f *SimpleFrame) readConfig(path string) {
+	if path == "" {
+		path = configFile()
+		if path == "" {
+			return
+		}
+	}
+	if err := f.sourceFile(path, f.showError); err != nil {
+		f.showError(err)
+	}
+}
+
+// configFile returns the path of the config file, which is mogrc in the
+// configuration directory or else .mogrc in the home directory. It returns
+// "" if there is neither.
+func configFile() string {
+	var paths []string
+	if dir, err := configDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "mogrc"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".mogrc"))
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// exNext implements :next, which edits the next file of the argument list.
+func (f *SimpleFrame) exNext(args string) error {
+	return f.editArg(f.argIndex+1, args)
+}
+
+// exPrevious implements :previous, which edits the previous file of the
+// argument list.
+func (f *SimpleFrame) exPrevious(args string) error {
+	return f.editArg(f.argIndex-1, args)
+}
+
+// editArg edits file i of the argument list. Like :edit, it refuses to throw
+// away changes unless args is "!".
+func (f *SimpleFrame) editArg(i int, args string) error {
+	if args != "" && args != "!" {
+		return errTrailingCharacters(args)
+	}
+	switch {
+	case i < 0:
+		return editorErrorf(164, "Cannot go before first file")
+	case i >= len(f.argList):
+		return editorErrorf(165, "Cannot go beyond last file")
+	case f.modified && args != "!":
+		return errNoWriteSinceLastChange
+	}
+	if err := f.editFile(f.argList[i], ""); err != nil {
+		return err
+	}
+	f.argIndex = i
+	return nil
+}
+
+// exArgs implements :args, which shows the argument list with the file
+// being edited in brackets.
+func (f *SimpleFrame) exArgs(args string) error {
+	if args != "" {
+		return errTrailingCharacters(args)
+	}
+	names := make([]string, len(f.argList))
+	for i, name := range f.argList {
+		if i == f.argIndex {
+			name = "[" + name + "]"
+		}
+		names[i] = name
+	}
+	f.showMessage(strings.Join(names, " "))
+	return nil
+}
@@ -0,0 +1,179 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseArgs(t *testing.T) {
+	a, err := ParseArgs([]string{"-R", "+12", "+/foo", "-c", "set so=2", "+", "a.txt", "-u", "NONE", "--", "-b.txt"})
+	assert.Nil(t, err)
+	assert.EqualValues(t, &Args{
+		Files:    []string{"a.txt", "-b.txt"},
+		Commands: []string{"12", "/foo", "set so=2", "$"},
+		ReadOnly: true,
+		Config:   "NONE",
+	}, a)
+
+	a, err = ParseArgs([]string{"-", "--clean", "--version"})
+	assert.Nil(t, err)
+	assert.EqualValues(t, &Args{Stdin: true, Clean: true, Version: true}, a)
+
+	_, err = ParseArgs([]string{"-x"})
+	assert.EqualError(t, err, "unknown option -x")
+	for _, arg := range []string{"-o", "-O", "-o2", "-d"} {
+		_, err = ParseArgs([]string{arg, "a.txt", "b.txt"})
+		assert.EqualError(t, err, arg+" is not supported yet")
+	}
+	_, err = ParseArgs([]string{"-Ox"})
+	assert.EqualError(t, err, "unknown option -Ox")
+	_, err = ParseArgs([]string{"-c"})
+	assert.EqualError(t, err, "argument missing after -c")
+	_, err = ParseArgs([]string{"-", "a.txt"})
+	assert.NotNil(t, err)
+}
+
+func writeTestFiles(t *testing.T, contents ...string) []string {
+	dir := t.TempDir()
+	var paths []string
+	for i, c := range contents {
+		path := filepath.Join(dir, string(rune('a'+i))+".txt")
+		assert.Nil(t, os.WriteFile(path, []byte(c), 0o644))
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestSimpleFrame_start(t *testing.T) {
+	paths := writeTestFiles(t, "one\n  two\nthree foo\nfour", "other")
+	f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+
+	err := f.start(&Args{Files: paths, Commands: []string{"2"}, ReadOnly: true}, nil)
+	assert.Nil(t, err)
+	defer f.closeFile()
+	assert.EqualValues(t, []string{"one", "  two", "three foo", "four"}, f.buffer.lines())
+	assert.EqualValues(t, bufferPos{2, 1}, f.cursorPos())
+	assert.True(t, f.readonly)
+
+	assert.Nil(t, f.Execute("/fo"))
+	assert.EqualValues(t, bufferPos{6, 2}, f.cursorPos())
+	assert.Nil(t, f.Execute("$"))
+	assert.EqualValues(t, bufferPos{0, 3}, f.cursorPos())
+}
+
+func TestSimpleFrame_start_Stdin(t *testing.T) {
+	f, _ := newTestFrame(t, []string{""}, 80, 10)
+
+	err := f.start(&Args{Stdin: true, Commands: []string{"frob"}}, strings.NewReader("a\r\nb\r\n"))
+	assert.Nil(t, err)
+	assert.EqualValues(t, "E492: Not an editor command: frob", f.bottomLine())
+	handleEventsUntil(t, f, func() bool { return f.input == nil })
+	assert.EqualValues(t, []string{"a", "b", ""}, f.buffer.lines())
+	assert.EqualValues(t, fileFormatDos, f.fileFormat)
+	assert.False(t, f.modified)
+	assert.Nil(t, f.Execute("q"))
+}
+
+func TestSimpleFrame_exNext(t *testing.T) {
+	paths := writeTestFiles(t, "a", "b")
+	f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+	assert.Nil(t, f.start(&Args{Files: paths}, nil))
+	defer f.closeFile()
+
+	assert.EqualError(t, f.Execute("prev"), "E164: Cannot go before first file")
+	f.InsertText("x")
+	assert.EqualError(t, f.Execute("n"), "E37: No write since last change (add ! to override)")
+	assert.Nil(t, f.Execute("n!"))
+	assert.EqualValues(t, []string{"b"}, f.buffer.lines())
+	assert.EqualError(t, f.Execute("next"), "E165: Cannot go beyond last file")
+
+	assert.Nil(t, f.Execute("args"))
+	assert.EqualValues(t, paths[0]+" ["+paths[1]+"]", f.bottomLine())
+	assert.Nil(t, f.Execute("prev"))
+	assert.EqualValues(t, []string{"a"}, f.buffer.lines())
+}
+
+func TestSimpleFrame_exSource(t *testing.T) {
+	paths := writeTestFiles(t, "\" comment\nset so=3\n# comment\nset frob\nset so=5\n")
+	f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+
+	err := f.Execute("source " + paths[0])
+	assert.EqualError(t, err, paths[0]+":4: E518: Unknown option: frob")
+	assert.EqualValues(t, 3, f.scrollOff)
+}
+
+func TestSimpleFrame_start_Config(t *testing.T) {
+	config := t.TempDir()
+	setEnv(t, "XDG_CONFIG_HOME", config)
+	setEnv(t, "HOME", t.TempDir())
+	assert.Nil(t, os.Mkdir(filepath.Join(config, "mog"), 0o755))
+	path := filepath.Join(config, "mog", "mogrc")
+	assert.Nil(t, os.WriteFile(path, []byte("set so=3\nset frob\n\nset mouse ff=amiga\nset noar\n"), 0o644))
+
+	f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+	assert.Nil(t, f.start(&Args{}, nil))
+	// Every line is run, whether the ones before it failed or not.
+	assert.EqualValues(t, 3, f.scrollOff)
+	assert.True(t, f.mouse)
+	assert.False(t, f.autoread)
+	var errs []string
+	for _, m := range f.messages {
+		if m.isError {
+			errs = append(errs, m.text)
+		}
+	}
+	assert.EqualValues(t, []string{
+		path + ":2: E518: Unknown option: frob",
+		path + ":4: E474: Invalid argument: fileformat=amiga",
+	}, errs)
+
+	f, _ = newTestFrame(t, numberedLines(1), 80, 10)
+	assert.Nil(t, f.start(&Args{Clean: true}, nil))
+	assert.EqualValues(t, 0, f.scrollOff)
+	assert.Nil(t, f.start(&Args{Config: "NONE"}, nil))
+	assert.EqualValues(t, 0, f.scrollOff)
+}
+
+func TestSimpleFrame_start_ConfigFileOptions(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "mogrc")
+	assert.Nil(t, os.WriteFile(config, []byte("set ff=mac\nsetglobal ff=dos fenc=latin1\n"), 0o644))
+	empty := filepath.Join(dir, "empty.txt")
+	assert.Nil(t, os.WriteFile(empty, nil, 0o644))
+
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+	assert.Nil(t, f.start(&Args{Config: config}, nil))
+	// The new buffer takes the global values and has nothing to write.
+	assert.EqualValues(t, fileFormatDos, f.fileFormat)
+	assert.EqualValues(t, encodingLatin1, f.fileEncoding)
+	assert.False(t, f.modified)
+
+	assert.Nil(t, f.loadFile(empty))
+	assert.EqualValues(t, fileFormatDos, f.fileFormat)
+	assert.EqualValues(t, encodingLatin1, f.fileEncoding)
+	assert.False(t, f.modified)
+	assert.Nil(t, f.Execute("set ff=unix"))
+	assert.True(t, f.modified)
+}
+
+func TestSimpleFrame_searchText(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+	f.buffer = newLineBuffer([]string{"ab ab", "x", "ab"})
+	f.cursor.MoveTo(0, 0)
+
+	typeKeys(f, "/b\r")
+	assert.EqualValues(t, bufferPos{1, 0}, f.cursorPos())
+	typeKeys(f, "n")
+	assert.EqualValues(t, bufferPos{4, 0}, f.cursorPos())
+	typeKeys(f, "nn")
+	assert.EqualValues(t, bufferPos{1, 0}, f.cursorPos())
+	assert.EqualValues(t, "search hit BOTTOM, continuing at TOP", f.bottomLine())
+
+	assert.EqualError(t, f.Execute("/z"), "E486: Pattern not found: z")
+	assert.EqualError(t, f.Execute("/("), "E383: Invalid search string: (")
+}
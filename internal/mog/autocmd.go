@@ -0,0 +1,240 @@
+package mog
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// AutocmdEvent is something that happens in the editor that commands can be
+// run for automatically.
+type AutocmdEvent string
+
+const (
+	// BufEnter fires when a buffer becomes the one being edited.
+	BufEnter AutocmdEvent = "BufEnter"
+	// BufReadPost fires after a file was read into the buffer.
+	BufReadPost AutocmdEvent = "BufReadPost"
+	// BufWritePre and BufWritePost fire before and after the buffer is
+	// written to a file.
+	BufWritePre  AutocmdEvent = "BufWritePre"
+	BufWritePost AutocmdEvent = "BufWritePost"
+	// CursorMoved and CursorMovedI fire after the cursor was moved, in
+	// insert mode for the latter.
+	CursorMoved  AutocmdEvent = "CursorMoved"
+	CursorMovedI AutocmdEvent = "CursorMovedI"
+	// FileType fires when the filetype option is set. Its pattern is
+	// matched against the file type rather than the file name.
+	FileType AutocmdEvent = "FileType"
+	// InsertEnter and InsertLeave fire when insert mode starts and ends.
+	InsertEnter AutocmdEvent = "InsertEnter"
+	InsertLeave AutocmdEvent = "InsertLeave"
+	// TextChanged and TextChangedI fire after the text of the buffer was
+	// changed, in insert mode for the latter.
+	TextChanged  AutocmdEvent = "TextChanged"
+	TextChangedI AutocmdEvent = "TextChangedI"
+	// VimEnter fires when the editor has started, after the commands given
+	// on the command line were run.
+	VimEnter AutocmdEvent = "VimEnter"
+	// VimResized fires after the screen changed its size.
+	VimResized AutocmdEvent = "VimResized"
+)
+
+var autocmdEvents = []AutocmdEvent{
+	BufEnter, BufReadPost, BufWritePre, BufWritePost, CursorMoved, CursorMovedI, FileType,
+	InsertEnter, InsertLeave, TextChanged, TextChangedI, VimEnter, VimResized,
+}
+
+// findAutocmdEvent returns the event with the given name, ignoring case.
+func findAutocmdEvent(name string) (AutocmdEvent, error) {
+	for _, e := range autocmdEvents {
+		if strings.EqualFold(name, string(e)) {
+			return e, nil
+		}
+	}
+	return "", editorErrorf(216, "No such event: %s", name)
+}
+
+// autocmd runs fn when event fires for a file whose name matches pattern.
+type autocmd struct {
+	event   AutocmdEvent
+	pattern string
+	// command is the ex command given with :autocmd, which fn runs. It
+	// is "" for autocmds added with Subscribe.
+	command string
+	fn      func(f *SimpleFrame, match string) error
+}
+
+// Subscribe calls fn whenever event fires for a file whose name matches
+// pattern, with the name as match, or for FileType with the file type. A
+// pattern is a comma separated list of globs, which are matched against
+// the base name of the file unless they contain a '/'. The returned
+// function ends the subscription.
+func (f *SimpleFrame) Subscribe(event AutocmdEvent, pattern string, fn func(f *SimpleFrame, match string) error) func() {
+	ac := &autocmd{event: event, pattern: pattern, fn: fn}
+	f.autocmds = append(f.autocmds, ac)
+	return func() { f.removeAutocmds(func(a *autocmd) bool { return a == ac }) }
+}
+
+func (f *SimpleFrame) removeAutocmds(remove func(a *autocmd) bool) {
+	kept := f.autocmds[:0]
+	for _, a := range f.autocmds {
+		if !remove(a) {
+			kept = append(kept, a)
+		}
+	}
+	f.autocmds = kept
+}
+
+// maxAutocmdDepth is how deeply autocmds may fire events that run others
+// before they are taken to fire each other forever.
+const maxAutocmdDepth = 10
+
+// fireAutocmds runs the autocmds of event whose pattern matches match. Their
+// errors are shown. Autocmds may fire events themselves, up to
+// maxAutocmdDepth deep.
+func (f *SimpleFrame) fireAutocmds(event AutocmdEvent, match string) {
+	if f.autocmdDepth >= maxAutocmdDepth {
+		f.showError(editorErrorf(218, "autocommand nesting too deep"))
+		return
+	}
+	f.autocmdDepth++
+	defer func() { f.autocmdDepth-- }()
+	// An autocmd may add or remove others.
+	for _, a := range append([]*autocmd(nil), f.autocmds...) {
+		if a.event != event || !matchAutocmdPattern(a.pattern, match, event == FileType) {
+			continue
+		}
+		if err := a.fn(f, match); err != nil {
+			f.showError(fmt.Errorf("%s autocommands for %q: %w", event, a.pattern, err))
+		}
+	}
+}
+
+// matchAutocmdPattern reports whether name matches one of the comma
+// separated globs of pattern. Unless literal is set, name is a file name.
+func matchAutocmdPattern(pattern, name string, literal bool) bool {
+	for _, p := range strings.Split(pattern, ",") {
+		target := name
+		if !literal && !strings.Contains(p, "/") {
+			target = filepath.Base(name)
+		}
+		if ok, _ := filepath.Match(p, target); ok || p == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// fireBufferAutocmds runs the autocmds of event for the file being edited.
+func (f *SimpleFrame) fireBufferAutocmds(event AutocmdEvent) {
+	f.fireAutocmds(event, f.filePath)
+}
+
+// fireChangeAutocmds runs the autocmds of CursorMoved and TextChanged, or
+// those for insert mode, if the cursor moved or the text changed since it
+// was last called.
+func (f *SimpleFrame) fireChangeAutocmds() {
+	insert := f.mode == ModeInsert
+	if f.changedTick != f.autocmdTick {
+		f.autocmdTick = f.changedTick
+		if insert {
+			f.fireBufferAutocmds(TextChangedI)
+		} else {
+			f.fireBufferAutocmds(TextChanged)
+		}
+	}
+	if pos := f.cursorPos(); pos != f.autocmdCursor {
+		f.autocmdCursor = pos
+		if insert {
+			f.fireBufferAutocmds(CursorMovedI)
+		} else {
+			f.fireBufferAutocmds(CursorMoved)
+		}
+	}
+}
+
+// exAutocmd implements :autocmd.
+//
+//	:autocmd {event} {pattern} {command}   run command when event fires
+//	:autocmd [{event} [{pattern}]]         list the autocmds
+//	:autocmd! [{event} [{pattern}]]        remove the autocmds
+//	:autocmd! {event} {pattern} {command}  replace them by a new one
+//
+// Events are a comma separated list of names, or * for all of them when
+// listing or removing.
+func (f *SimpleFrame) exAutocmd(args string) error {
+	remove := strings.HasPrefix(args, "!")
+	if remove {
+		args = strings.TrimSpace(args[1:])
+	}
+	names, rest := cutWord(args)
+	pattern, command := cutWord(rest)
+	var events []AutocmdEvent
+	if names != "" && names != "*" {
+		for _, name := range strings.Split(names, ",") {
+			e, err := findAutocmdEvent(name)
+			if err != nil {
+				return err
+			}
+			events = append(events, e)
+		}
+	}
+	selected := func(a *autocmd) bool {
+		if a.command == "" || pattern != "" && a.pattern != pattern {
+			return false
+		}
+		for _, e := range events {
+			if a.event == e {
+				return true
+			}
+		}
+		return len(events) == 0
+	}
+	if remove {
+		f.removeAutocmds(selected)
+	}
+	if command == "" {
+		if !remove {
+			f.listAutocmds(selected)
+		}
+		return nil
+	}
+	if len(events) == 0 {
+		return editorErrorf(216, "No such event: %s", names)
+	}
+	for _, e := range events {
+		f.autocmds = append(f.autocmds, &autocmd{
+			event:   e,
+			pattern: pattern,
+			command: command,
+			fn:      func(f *SimpleFrame, _ string) error { return f.Execute(command) },
+		})
+	}
+	return nil
+}
+
+// cutWord returns the first word of s and what follows it, without the
+// white space around them.
+func cutWord(s string) (word, rest string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// listAutocmds shows the autocmds added with :autocmd that are selected.
+func (f *SimpleFrame) listAutocmds(selected func(a *autocmd) bool) {
+	var lines []message
+	for _, a := range f.autocmds {
+		if selected(a) {
+			lines = append(lines, message{text: fmt.Sprintf("%-14s%-12s%s", a.event, a.pattern, a.command)})
+		}
+	}
+	if len(lines) == 0 {
+		f.showMessage("No autocommands found")
+		return
+	}
+	f.listing = lines
+}
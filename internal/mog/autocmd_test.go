@@ -0,0 +1,125 @@
+package mog
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleFrame_exAutocmd(t *testing.T) {
+	paths := writeTestFiles(t, "one", "two")
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+
+	assert.Nil(t, f.Execute("autocmd BufReadPost *.txt set ro"))
+	assert.Nil(t, f.Execute("au BufWritePre,BufWritePost a.* set tm=5"))
+	assert.Nil(t, f.loadFile(paths[0]))
+	assert.True(t, f.readonly)
+
+	assert.Nil(t, f.Execute("write! "+paths[1]))
+	assert.EqualValues(t, defaultTimeoutLen, f.timeoutLen)
+	assert.Nil(t, f.Execute("write!"))
+	assert.EqualValues(t, 5, f.timeoutLen)
+
+	assert.Nil(t, f.Execute("autocmd BufWritePre"))
+	assert.EqualValues(t, []message{{text: "BufWritePre   a.*         set tm=5"}}, f.listing)
+
+	assert.Nil(t, f.Execute("autocmd! * a.*"))
+	assert.Nil(t, f.Execute("autocmd"))
+	assert.EqualValues(t, []message{{text: "BufReadPost   *.txt       set ro"}}, f.listing)
+	assert.Nil(t, f.Execute("autocmd!"))
+	assert.Nil(t, f.Execute("autocmd"))
+	assert.EqualValues(t, "No autocommands found", f.message.text)
+
+	assert.EqualValues(t, "E216: No such event: BufRead", f.Execute("autocmd BufRead * set ro").Error())
+}
+
+func TestSimpleFrame_exAutocmd_Nested(t *testing.T) {
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+
+	assert.Nil(t, f.Execute("autocmd  BufEnter\t*   set  ft=foo"))
+	assert.Nil(t, f.Execute("autocmd FileType foo set tm=9"))
+	assert.Nil(t, f.Execute("autocmd BufEnter"))
+	assert.EqualValues(t, []message{{text: "BufEnter      *           set  ft=foo"}}, f.listing)
+	f.fireBufferAutocmds(BufEnter)
+	assert.EqualValues(t, "foo", f.fileType)
+	assert.EqualValues(t, 9, f.timeoutLen)
+
+	assert.Nil(t, f.Execute("autocmd FileType bar set ft=bar"))
+	assert.Nil(t, f.Execute("set ft=bar"))
+	assert.Contains(t, f.message.text, "E218: autocommand nesting too deep")
+	assert.EqualValues(t, 0, f.autocmdDepth)
+}
+
+func TestSimpleFrame_Subscribe(t *testing.T) {
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+	f.loadBuffer([]byte("abc\ndef"))
+
+	var fired []AutocmdEvent
+	record := func(event AutocmdEvent) func() {
+		return f.Subscribe(event, "*", func(*SimpleFrame, string) error {
+			fired = append(fired, event)
+			return nil
+		})
+	}
+	for _, e := range []AutocmdEvent{CursorMoved, CursorMovedI, InsertEnter, InsertLeave, TextChanged, TextChangedI} {
+		record(e)
+	}
+	for _, r := range "jixx" {
+		f.HandleEvent(NewEventKey(KeyRune, r, ModNone))
+	}
+	f.HandleEvent(NewEventKey(KeyEscape, 0, ModNone))
+	f.HandleEvent(NewEventKey(KeyRune, 'u', ModNone))
+	assert.EqualValues(t, []AutocmdEvent{
+		CursorMoved, InsertEnter, TextChangedI, CursorMovedI, TextChangedI, CursorMovedI,
+		InsertLeave, TextChanged, CursorMoved,
+	}, fired)
+
+	var types []string
+	stop := f.Subscribe(FileType, "go,c", func(_ *SimpleFrame, match string) error {
+		types = append(types, match)
+		return errors.New("failed")
+	})
+	assert.Nil(t, f.Execute("set ft=go"))
+	assert.Nil(t, f.Execute("set ft=python"))
+	stop()
+	assert.Nil(t, f.Execute("set ft=c"))
+	assert.EqualValues(t, []string{"go"}, types)
+	assert.EqualValues(t, `FileType autocommands for "go,c": failed`, f.message.text)
+}
+
+func TestSimpleFrame_start_Autocmds(t *testing.T) {
+	paths := writeTestFiles(t, "one")
+	config := paths[0] + ".rc"
+	assert.Nil(t, os.WriteFile(config, []byte("autocmd BufEnter a.txt set ro\nautocmd VimEnter * set tm=7\n"), 0o644))
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+
+	assert.Nil(t, f.start(&Args{Files: paths, Config: config}, nil))
+	assert.True(t, f.readonly)
+	assert.EqualValues(t, 7, f.timeoutLen)
+}
+
+func TestMatchAutocmdPattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		literal       bool
+		want          bool
+	}{
+		{"*", "", false, true},
+		{"*.go", "/src/main.go", false, true},
+		{"*.c,*.go", "/src/main.go", false, true},
+		{"*.c", "/src/main.go", false, false},
+		{"/src/*", "/src/main.go", false, true},
+		{"/lib/*", "/src/main.go", false, false},
+		{"go", "go", true, true},
+		{"g*", "go/x", true, false},
+	}
+	for _, tt := range tests {
+		assert.EqualValues(t, tt.want, matchAutocmdPattern(tt.pattern, tt.name, tt.literal), "%q %q", tt.pattern, tt.name)
+	}
+}
@@ -0,0 +1,230 @@
+package mog
+
+import "sort"
+
+// mergedPieceSize is the largest number of lines pieces in memory are
+// merged into, so that reading the lines of a large file piece by piece
+// does not copy the ones read before over and over.
+const mergedPieceSize = 4096
+
+// lineBuffer holds the lines of a buffer as a list of pieces. A piece is
+// either lines in memory or a run of lines of a large file that have not
+// been read yet, so that nothing is kept per line of a large file until it
+// is read. The lines of other files are a single piece.
+type lineBuffer struct {
+	pieces []linePiece
+	// starts holds the first line of every piece followed by the number
+	// of lines.
+	starts []int
+}
+
+// linePiece is either the lines in lines or, if lines is nil, the n lines of
+// a large file from line line on. Pieces are never empty.
+type linePiece struct {
+	lines   []string
+	line, n int
+}
+
+func (p linePiece) len() int {
+	if p.lines != nil {
+		return len(p.lines)
+	}
+	return p.n
+}
+
+// slice returns the lines from from up to to of the piece.
+func (p linePiece) slice(from, to int) linePiece {
+	if p.lines != nil {
+		return linePiece{lines: p.lines[from:to:to]}
+	}
+	return linePiece{line: p.line + from, n: to - from}
+}
+
+// newLineBuffer returns a buffer holding lines, which it keeps.
+func newLineBuffer(lines []string) lineBuffer {
+	var b lineBuffer
+	b.setPieces([]linePiece{{lines: lines}})
+	return b
+}
+
+func (b *lineBuffer) len() int {
+	if len(b.starts) == 0 {
+		return 0
+	}
+	return b.starts[len(b.starts)-1]
+}
+
+// find returns the index of the piece holding line y.
+func (b *lineBuffer) find(y int) int {
+	if len(b.pieces) == 1 {
+		return 0
+	}
+	return sort.Search(len(b.pieces), func(i int) bool { return b.starts[i+1] > y })
+}
+
+// line returns line y, which is empty if it was not read yet.
+func (b *lineBuffer) line(y int) string {
+	i := b.find(y)
+	if p := b.pieces[i]; p.lines != nil {
+		return p.lines[y-b.starts[i]]
+	}
+	return ""
+}
+
+// fileLine returns the line of the large file that line y still has to be
+// read from, or -1 if it is in memory.
+func (b *lineBuffer) fileLine(y int) int {
+	i := b.find(y)
+	if p := b.pieces[i]; p.lines == nil {
+		return p.line + y - b.starts[i]
+	}
+	return -1
+}
+
+func (b *lineBuffer) setLine(y int, line string) {
+	i := b.find(y)
+	if p := b.pieces[i]; p.lines != nil {
+		p.lines[y-b.starts[i]] = line
+		return
+	}
+	b.replace(y, 1, []string{line})
+}
+
+// slice returns a copy of the lines from from up to to.
+func (b *lineBuffer) slice(from, to int) []string {
+	lines := make([]string, 0, to-from)
+	for y := from; y < to; {
+		i := b.find(y)
+		end := minInt(b.starts[i+1], to)
+		if p := b.pieces[i]; p.lines != nil {
+			lines = append(lines, p.lines[y-b.starts[i]:end-b.starts[i]]...)
+		} else {
+			lines = append(lines, make([]string, end-y)...)
+		}
+		y = end
+	}
+	return lines
+}
+
+// lines returns every line. Unless the buffer is a single piece, they are
+// copied.
+func (b *lineBuffer) lines() []string {
+	if len(b.pieces) == 1 && b.pieces[0].lines != nil {
+		return b.pieces[0].lines
+	}
+	return b.slice(0, b.len())
+}
+
+// replace replaces the n lines from line y on with lines.
+func (b *lineBuffer) replace(y, n int, lines []string) {
+	if i := b.find(y); i < len(b.pieces) && b.pieces[i].lines != nil && y+n <= b.starts[i+1] {
+		// The lines are all in one piece in memory, which is edited in
+		// place.
+		p, off := b.pieces[i].lines, y-b.starts[i]
+		if len(lines) == n {
+			copy(p[off:], lines)
+			return
+		}
+		buf := make([]string, 0, len(p)-n+len(lines))
+		buf = append(buf, p[:off]...)
+		buf = append(buf, lines...)
+		buf = append(buf, p[off+n:]...)
+		pieces := append([]linePiece(nil), b.pieces...)
+		pieces[i].lines = buf
+		b.setPieces(pieces)
+		return
+	}
+	lines = append([]string(nil), lines...)
+	var pieces []linePiece
+	added := false
+	for i, p := range b.pieces {
+		start, end := b.starts[i], b.starts[i+1]
+		if start < y {
+			pieces = append(pieces, p.slice(0, minInt(end, y)-start))
+		}
+		if end >= y && !added {
+			pieces = append(pieces, linePiece{lines: lines})
+			added = true
+		}
+		if end > y+n {
+			pieces = append(pieces, p.slice(maxInt(start, y+n)-start, end-start))
+		}
+	}
+	if !added {
+		pieces = append(pieces, linePiece{lines: lines})
+	}
+	b.setPieces(pieces)
+}
+
+// appendLines adds lines to the end of the buffer.
+func (b *lineBuffer) appendLines(lines []string) {
+	if last := len(b.pieces) - 1; last >= 0 && b.pieces[last].lines != nil {
+		b.pieces[last].lines = append(b.pieces[last].lines, lines...)
+		b.starts[last+1] += len(lines)
+		return
+	}
+	b.replace(b.len(), 0, lines)
+}
+
+// appendUnread adds the n lines of a large file from line line on, which
+// are read later, to the end of the buffer.
+func (b *lineBuffer) appendUnread(line, n int) {
+	b.setPieces(append(b.pieces, linePiece{line: line, n: n}))
+}
+
+// load reads the lines from from up to to that are not in memory yet with
+// read, which is passed the line of the buffer and the line of the large
+// file. It stops at the first error.
+func (b *lineBuffer) load(from, to int, read func(y, line int) (string, error)) error {
+	for y := from; y < to; {
+		i := b.find(y)
+		end := minInt(b.starts[i+1], to)
+		p := b.pieces[i]
+		if p.lines != nil {
+			y = end
+			continue
+		}
+		lines := make([]string, 0, end-y)
+		var err error
+		for ; y < end; y++ {
+			var line string
+			if line, err = read(y, p.line+y-b.starts[i]); err != nil {
+				break
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			b.replace(y-len(lines), len(lines), lines)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setPieces replaces the pieces, dropping empty ones and merging the ones
+// that are next to each other in memory or in the large file.
+func (b *lineBuffer) setPieces(pieces []linePiece) {
+	b.pieces = b.pieces[:0]
+	for _, p := range pieces {
+		if p.len() == 0 {
+			continue
+		}
+		last := len(b.pieces) - 1
+		switch {
+		case last < 0:
+		case p.lines != nil && b.pieces[last].lines != nil && len(b.pieces[last].lines)+len(p.lines) <= mergedPieceSize:
+			b.pieces[last].lines = append(b.pieces[last].lines[:len(b.pieces[last].lines):len(b.pieces[last].lines)], p.lines...)
+			continue
+		case p.lines == nil && b.pieces[last].lines == nil && b.pieces[last].line+b.pieces[last].n == p.line:
+			b.pieces[last].n += p.n
+			continue
+		}
+		b.pieces = append(b.pieces, p)
+	}
+	b.starts = append(b.starts[:0], 0)
+	for _, p := range b.pieces {
+		b.starts = append(b.starts, b.starts[len(b.starts)-1]+p.len())
+	}
+}
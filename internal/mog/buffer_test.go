@@ -0,0 +1,60 @@
+package mog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineBuffer_replace(t *testing.T) {
+	b := newLineBuffer([]string{"a", "b", "c"})
+	b.replace(1, 1, []string{"x", "y"})
+	assert.EqualValues(t, []string{"a", "x", "y", "c"}, b.lines())
+	b.replace(0, 4, nil)
+	assert.EqualValues(t, 0, b.len())
+	b.replace(0, 0, []string{"z"})
+	assert.EqualValues(t, []string{"z"}, b.lines())
+}
+
+func TestLineBuffer_Unread(t *testing.T) {
+	var b lineBuffer
+	b.appendUnread(0, 1)
+	b.appendUnread(1, 9)
+	assert.EqualValues(t, []linePiece{{line: 0, n: 10}}, b.pieces, "runs of the file are merged")
+	assert.EqualValues(t, 10, b.len())
+	assert.EqualValues(t, "", b.line(4))
+	assert.EqualValues(t, 4, b.fileLine(4))
+
+	b.replace(2, 3, []string{"x"})
+	assert.EqualValues(t, 8, b.len())
+	assert.EqualValues(t, "x", b.line(2))
+	assert.EqualValues(t, -1, b.fileLine(2))
+	assert.EqualValues(t, 5, b.fileLine(3))
+	assert.EqualValues(t, []linePiece{{line: 0, n: 2}, {lines: []string{"x"}}, {line: 5, n: 5}}, b.pieces)
+
+	b.setLine(1, "y")
+	assert.EqualValues(t, []linePiece{{line: 0, n: 1}, {lines: []string{"y", "x"}}, {line: 5, n: 5}}, b.pieces)
+	b.appendLines([]string{"z"})
+	assert.EqualValues(t, []string{"", "y", "x", "", "", "", "", "", "z"}, b.lines())
+}
+
+func TestLineBuffer_load(t *testing.T) {
+	var b lineBuffer
+	b.appendUnread(0, 10)
+	b.replace(3, 1, []string{"x"})
+	read := func(y, line int) (string, error) {
+		if line == 8 {
+			return "", errors.New("unreadable")
+		}
+		return string(rune('a' + line)), nil
+	}
+
+	assert.Nil(t, b.load(1, 6, read))
+	assert.EqualValues(t, []string{"", "b", "c", "x", "e", "f", "", "", "", ""}, b.lines())
+	assert.EqualValues(t, []linePiece{{line: 0, n: 1}, {lines: []string{"b", "c", "x", "e", "f"}}, {line: 6, n: 4}}, b.pieces)
+
+	assert.NotNil(t, b.load(0, 10, read))
+	assert.EqualValues(t, []string{"a", "b", "c", "x", "e", "f", "g", "h", "", ""}, b.lines())
+	assert.EqualValues(t, 8, b.fileLine(8))
+}
@@ -0,0 +1,323 @@
+package mog
+
+import (
+	"math"
+	"strconv"
+)
+
+// Color is a color text can be drawn in: the default color of the screen,
+// an index into the palette of the screen or an RGB value.
+type Color uint64
+
+const (
+	// ColorDefault is the default color of the screen. It is the zero
+	// value.
+	ColorDefault Color = 0
+	// ColorValid is set in every color other than ColorDefault. The lower
+	// bits hold the palette index.
+	ColorValid Color = 1 << 32
+	// ColorIsRGB is set in colors given as RGB values, which the lower
+	// three bytes hold.
+	ColorIsRGB Color = 1 << 33
+)
+
+// The first 16 colors of the palette, as most terminals name them.
+const (
+	ColorBlack = ColorValid + iota
+	ColorMaroon
+	ColorGreen
+	ColorOlive
+	ColorNavy
+	ColorPurple
+	ColorTeal
+	ColorSilver
+	ColorGray
+	ColorRed
+	ColorLime
+	ColorYellow
+	ColorBlue
+	ColorFuchsia
+	ColorAqua
+	ColorWhite
+)
+
+// PaletteColor returns the color at index in the palette.
+func PaletteColor(index int) Color {
+	return Color(index) | ColorValid
+}
+
+// NewHexColor returns the color with the RGB value v, written 0xrrggbb.
+func NewHexColor(v int32) Color {
+	return Color(v&0xffffff) | ColorIsRGB | ColorValid
+}
+
+// NewRGBColor returns the color with the given red, green and blue values,
+// each from 0 to 255.
+func NewRGBColor(r, g, b int32) Color {
+	return NewHexColor((r&0xff)<<16 | (g&0xff)<<8 | b&0xff)
+}
+
+// Valid reports whether c is not ColorDefault.
+func (c Color) Valid() bool {
+	return c&ColorValid != 0
+}
+
+// IsRGB reports whether c is given as an RGB value rather than a palette
+// index.
+func (c Color) IsRGB() bool {
+	return c&(ColorValid|ColorIsRGB) == ColorValid|ColorIsRGB
+}
+
+// Hex returns the RGB value of c, written 0xrrggbb. Palette colors have the
+// values of the xterm palette. It returns -1 for ColorDefault.
+func (c Color) Hex() int32 {
+	switch {
+	case !c.Valid():
+		return -1
+	case c.IsRGB():
+		return int32(c & 0xffffff)
+	}
+	return paletteHex(int(c - ColorValid))
+}
+
+// RGB returns the red, green and blue values of c, or -1 for each for
+// ColorDefault.
+func (c Color) RGB() (r, g, b int32) {
+	v := c.Hex()
+	if v < 0 {
+		return -1, -1, -1
+	}
+	return v >> 16 & 0xff, v >> 8 & 0xff, v & 0xff
+}
+
+var basicColors = [16]int32{
+	0x000000, 0x800000, 0x008000, 0x808000, 0x000080, 0x800080, 0x008080, 0xc0c0c0,
+	0x808080, 0xff0000, 0x00ff00, 0xffff00, 0x0000ff, 0xff00ff, 0x00ffff, 0xffffff,
+}
+
+// paletteHex returns the RGB value of the color at index in the 256 color
+// palette of xterm: the 16 basic colors, a 6x6x6 color cube and 24 shades
+// of gray.
+func paletteHex(index int) int32 {
+	switch {
+	case index < 0 || index > 255:
+		return -1
+	case index < 16:
+		return basicColors[index]
+	case index < 232:
+		level := func(i int) int32 {
+			if i == 0 {
+				return 0
+			}
+			return int32(55 + 40*i)
+		}
+		i := index - 16
+		return level(i/36)<<16 | level(i/6%6)<<8 | level(i%6)
+	}
+	v := int32(8 + 10*(index-232))
+	return v<<16 | v<<8 | v
+}
+
+// GetColor returns the color with the given W3C name or written as
+// "#rrggbb", or ColorDefault if there is none.
+func GetColor(name string) Color {
+	if c, ok := colorNames[name]; ok {
+		return c
+	}
+	if len(name) == 7 && name[0] == '#' {
+		if v, err := strconv.ParseInt(name[1:], 16, 32); err == nil {
+			return NewHexColor(int32(v))
+		}
+	}
+	return ColorDefault
+}
+
+// FindColor returns the color of palette that looks most like c.
+func FindColor(c Color, palette []Color) Color {
+	match := ColorDefault
+	dist := math.Inf(1)
+	l, a, b := toLab(c)
+	for _, p := range palette {
+		pl, pa, pb := toLab(p)
+		d := (l-pl)*(l-pl) + (a-pa)*(a-pa) + (b-pb)*(b-pb)
+		if match == ColorDefault || d < dist {
+			match, dist = p, d
+		}
+	}
+	return match
+}
+
+// toLab returns c in the CIE L*a*b* color space, in which the distance of
+// colors is roughly how different they look.
+func toLab(c Color) (l, a, b float64) {
+	r, g, bl := c.RGB()
+	linear := func(v int32) float64 {
+		f := float64(v) / 255
+		if f <= 0.04045 {
+			return f / 12.92
+		}
+		return math.Pow((f+0.055)/1.055, 2.4)
+	}
+	lr, lg, lb := linear(r), linear(g), linear(bl)
+	x := (0.4124564*lr + 0.3575761*lg + 0.1804375*lb) / 0.95047
+	y := 0.2126729*lr + 0.7151522*lg + 0.0721750*lb
+	z := (0.0193339*lr + 0.1191920*lg + 0.9503041*lb) / 1.08883
+	f := func(t float64) float64 {
+		if t > 216.0/24389 {
+			return math.Cbrt(t)
+		}
+		return (24389.0/27*t + 16) / 116
+	}
+	fx, fy, fz := f(x), f(y), f(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+// colorNames are the colors that have a name in W3C.
+var colorNames = map[string]Color{
+	"aliceblue":            NewHexColor(0xf0f8ff),
+	"antiquewhite":         NewHexColor(0xfaebd7),
+	"aqua":                 ColorAqua,
+	"aquamarine":           NewHexColor(0x7fffd4),
+	"azure":                NewHexColor(0xf0ffff),
+	"beige":                NewHexColor(0xf5f5dc),
+	"bisque":               NewHexColor(0xffe4c4),
+	"black":                ColorBlack,
+	"blanchedalmond":       NewHexColor(0xffebcd),
+	"blue":                 ColorBlue,
+	"blueviolet":           NewHexColor(0x8a2be2),
+	"brown":                NewHexColor(0xa52a2a),
+	"burlywood":            NewHexColor(0xdeb887),
+	"cadetblue":            NewHexColor(0x5f9ea0),
+	"chartreuse":           NewHexColor(0x7fff00),
+	"chocolate":            NewHexColor(0xd2691e),
+	"coral":                NewHexColor(0xff7f50),
+	"cornflowerblue":       NewHexColor(0x6495ed),
+	"cornsilk":             NewHexColor(0xfff8dc),
+	"crimson":              NewHexColor(0xdc143c),
+	"darkblue":             NewHexColor(0x00008b),
+	"darkcyan":             NewHexColor(0x008b8b),
+	"darkgoldenrod":        NewHexColor(0xb8860b),
+	"darkgray":             NewHexColor(0xa9a9a9),
+	"darkgreen":            NewHexColor(0x006400),
+	"darkgrey":             NewHexColor(0xa9a9a9),
+	"darkkhaki":            NewHexColor(0xbdb76b),
+	"darkmagenta":          NewHexColor(0x8b008b),
+	"darkolivegreen":       NewHexColor(0x556b2f),
+	"darkorange":           NewHexColor(0xff8c00),
+	"darkorchid":           NewHexColor(0x9932cc),
+	"darkred":              NewHexColor(0x8b0000),
+	"darksalmon":           NewHexColor(0xe9967a),
+	"darkseagreen":         NewHexColor(0x8fbc8f),
+	"darkslateblue":        NewHexColor(0x483d8b),
+	"darkslategray":        NewHexColor(0x2f4f4f),
+	"darkslategrey":        NewHexColor(0x2f4f4f),
+	"darkturquoise":        NewHexColor(0x00ced1),
+	"darkviolet":           NewHexColor(0x9400d3),
+	"deeppink":             NewHexColor(0xff1493),
+	"deepskyblue":          NewHexColor(0x00bfff),
+	"dimgray":              NewHexColor(0x696969),
+	"dimgrey":              NewHexColor(0x696969),
+	"dodgerblue":           NewHexColor(0x1e90ff),
+	"firebrick":            NewHexColor(0xb22222),
+	"floralwhite":          NewHexColor(0xfffaf0),
+	"forestgreen":          NewHexColor(0x228b22),
+	"fuchsia":              ColorFuchsia,
+	"gainsboro":            NewHexColor(0xdcdcdc),
+	"ghostwhite":           NewHexColor(0xf8f8ff),
+	"gold":                 NewHexColor(0xffd700),
+	"goldenrod":            NewHexColor(0xdaa520),
+	"gray":                 ColorGray,
+	"green":                ColorGreen,
+	"greenyellow":          NewHexColor(0xadff2f),
+	"grey":                 ColorGray,
+	"honeydew":             NewHexColor(0xf0fff0),
+	"hotpink":              NewHexColor(0xff69b4),
+	"indianred":            NewHexColor(0xcd5c5c),
+	"indigo":               NewHexColor(0x4b0082),
+	"ivory":                NewHexColor(0xfffff0),
+	"khaki":                NewHexColor(0xf0e68c),
+	"lavender":             NewHexColor(0xe6e6fa),
+	"lavenderblush":        NewHexColor(0xfff0f5),
+	"lawngreen":            NewHexColor(0x7cfc00),
+	"lemonchiffon":         NewHexColor(0xfffacd),
+	"lightblue":            NewHexColor(0xadd8e6),
+	"lightcoral":           NewHexColor(0xf08080),
+	"lightcyan":            NewHexColor(0xe0ffff),
+	"lightgoldenrodyellow": NewHexColor(0xfafad2),
+	"lightgray":            NewHexColor(0xd3d3d3),
+	"lightgreen":           NewHexColor(0x90ee90),
+	"lightgrey":            NewHexColor(0xd3d3d3),
+	"lightpink":            NewHexColor(0xffb6c1),
+	"lightsalmon":          NewHexColor(0xffa07a),
+	"lightseagreen":        NewHexColor(0x20b2aa),
+	"lightskyblue":         NewHexColor(0x87cefa),
+	"lightslategray":       NewHexColor(0x778899),
+	"lightslategrey":       NewHexColor(0x778899),
+	"lightsteelblue":       NewHexColor(0xb0c4de),
+	"lightyellow":          NewHexColor(0xffffe0),
+	"lime":                 ColorLime,
+	"limegreen":            NewHexColor(0x32cd32),
+	"linen":                NewHexColor(0xfaf0e6),
+	"maroon":               ColorMaroon,
+	"mediumaquamarine":     NewHexColor(0x66cdaa),
+	"mediumblue":           NewHexColor(0x0000cd),
+	"mediumorchid":         NewHexColor(0xba55d3),
+	"mediumpurple":         NewHexColor(0x9370db),
+	"mediumseagreen":       NewHexColor(0x3cb371),
+	"mediumslateblue":      NewHexColor(0x7b68ee),
+	"mediumspringgreen":    NewHexColor(0x00fa9a),
+	"mediumturquoise":      NewHexColor(0x48d1cc),
+	"mediumvioletred":      NewHexColor(0xc71585),
+	"midnightblue":         NewHexColor(0x191970),
+	"mintcream":            NewHexColor(0xf5fffa),
+	"mistyrose":            NewHexColor(0xffe4e1),
+	"moccasin":             NewHexColor(0xffe4b5),
+	"navajowhite":          NewHexColor(0xffdead),
+	"navy":                 ColorNavy,
+	"oldlace":              NewHexColor(0xfdf5e6),
+	"olive":                ColorOlive,
+	"olivedrab":            NewHexColor(0x6b8e23),
+	"orange":               NewHexColor(0xffa500),
+	"orangered":            NewHexColor(0xff4500),
+	"orchid":               NewHexColor(0xda70d6),
+	"palegoldenrod":        NewHexColor(0xeee8aa),
+	"palegreen":            NewHexColor(0x98fb98),
+	"paleturquoise":        NewHexColor(0xafeeee),
+	"palevioletred":        NewHexColor(0xdb7093),
+	"papayawhip":           NewHexColor(0xffefd5),
+	"peachpuff":            NewHexColor(0xffdab9),
+	"peru":                 NewHexColor(0xcd853f),
+	"pink":                 NewHexColor(0xffc0cb),
+	"plum":                 NewHexColor(0xdda0dd),
+	"powderblue":           NewHexColor(0xb0e0e6),
+	"purple":               ColorPurple,
+	"rebeccapurple":        NewHexColor(0x663399),
+	"red":                  ColorRed,
+	"rosybrown":            NewHexColor(0xbc8f8f),
+	"royalblue":            NewHexColor(0x4169e1),
+	"saddlebrown":          NewHexColor(0x8b4513),
+	"salmon":               NewHexColor(0xfa8072),
+	"sandybrown":           NewHexColor(0xf4a460),
+	"seagreen":             NewHexColor(0x2e8b57),
+	"seashell":             NewHexColor(0xfff5ee),
+	"sienna":               NewHexColor(0xa0522d),
+	"silver":               ColorSilver,
+	"skyblue":              NewHexColor(0x87ceeb),
+	"slateblue":            NewHexColor(0x6a5acd),
+	"slategray":            NewHexColor(0x708090),
+	"slategrey":            NewHexColor(0x708090),
+	"snow":                 NewHexColor(0xfffafa),
+	"springgreen":          NewHexColor(0x00ff7f),
+	"steelblue":            NewHexColor(0x4682b4),
+	"tan":                  NewHexColor(0xd2b48c),
+	"teal":                 ColorTeal,
+	"thistle":              NewHexColor(0xd8bfd8),
+	"tomato":               NewHexColor(0xff6347),
+	"turquoise":            NewHexColor(0x40e0d0),
+	"violet":               NewHexColor(0xee82ee),
+	"wheat":                NewHexColor(0xf5deb3),
+	"white":                ColorWhite,
+	"whitesmoke":           NewHexColor(0xf5f5f5),
+	"yellow":               ColorYellow,
+	"yellowgreen":          NewHexColor(0x9acd32),
+}
@@ -0,0 +1,332 @@
+package mog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Highlight groups used by the editor itself rather than by highlighters.
+const (
+	GroupStatusLine HighlightGroup = "StatusLine"
+	GroupLineNr     HighlightGroup = "LineNr"
+	GroupVisual     HighlightGroup = "Visual"
+	GroupSearch     HighlightGroup = "Search"
+	GroupCursorLine HighlightGroup = "CursorLine"
+	GroupNonText    HighlightGroup = "NonText"
+	GroupErrorMsg   HighlightGroup = "ErrorMsg"
+)
+
+// groupAttrs describes how text in a highlight group looks. Colors that are
+// ColorDefault are taken from the Normal group, colors that are colorNone
+// are the default color of the terminal.
+type groupAttrs struct {
+	fg, bg Color
+	attrs  AttrMask
+}
+
+// colorNone is the color of groups drawn in the default color of the
+// terminal, even if the Normal group has another color. It is no valid
+// color, so that it never is one of the colors of a screen.
+const colorNone Color = ColorIsRGB
+
+func defaultGroupAttrs() map[HighlightGroup]groupAttrs {
+	return map[HighlightGroup]groupAttrs{
+		GroupNormal:     {},
+		GroupComment:    {fg: ColorTeal},
+		GroupString:     {fg: ColorMaroon},
+		GroupKeyword:    {fg: ColorOlive, attrs: AttrBold},
+		GroupNumber:     {fg: ColorPurple},
+		GroupBuiltin:    {fg: ColorGreen},
+		GroupStatusLine: {attrs: AttrReverse | AttrBold},
+		GroupLineNr:     {fg: ColorOlive},
+		GroupVisual:     {attrs: AttrReverse},
+		GroupSearch:     {fg: ColorBlack, bg: ColorOlive},
+		GroupCursorLine: {attrs: AttrUnderline},
+		GroupNonText:    {fg: ColorNavy, attrs: AttrBold},
+		GroupErrorMsg:   {fg: ColorWhite, bg: ColorRed},
+	}
+}
+
+// highlightGroups maps highlight groups to the styles they are drawn with.
+type highlightGroups struct {
+	scheme string
+	attrs  map[HighlightGroup]groupAttrs
+	// colors is the number of colors of the screen styles are resolved for.
+	colors int
+	styles map[HighlightGroup]Style
+}
+
+func newHighlightGroups() *highlightGroups {
+	return &highlightGroups{scheme: "default", attrs: defaultGroupAttrs()}
+}
+
+// style returns the style of group g, with its colors reduced to what a
+// screen with the given number of colors can show.
+func (h *highlightGroups) style(g HighlightGroup, colors int) Style {
+	if colors != h.colors || h.styles == nil {
+		h.colors = colors
+		h.styles = make(map[HighlightGroup]Style)
+	}
+	if s, ok := h.styles[g]; ok {
+		return s
+	}
+	normal := h.attrs[GroupNormal]
+	a := h.attrs[g]
+	fg, bg := a.fg, a.bg
+	if fg == ColorDefault {
+		fg = normal.fg
+	}
+	if bg == ColorDefault {
+		bg = normal.bg
+	}
+	if fg == colorNone {
+		fg = ColorDefault
+	}
+	if bg == colorNone {
+		bg = ColorDefault
+	}
+	s := StyleDefault.
+		Foreground(fitColor(fg, colors)).
+		Background(fitColor(bg, colors)).
+		Attributes(a.attrs)
+	h.styles[g] = s
+	return s
+}
+
+// set changes how group g looks.
+func (h *highlightGroups) set(g HighlightGroup, a groupAttrs) {
+	h.attrs[g] = a
+	h.styles = nil
+}
+
+// reset restores every group to its default look.
+func (h *highlightGroups) reset() {
+	h.attrs = defaultGroupAttrs()
+	h.styles = nil
+}
+
+// fitColor returns the color closest to c that a screen with the given number
+// of colors can show. Screens supporting true color can show any color.
+func fitColor(c Color, colors int) Color {
+	if c == ColorDefault || colors >= 1<<24 {
+		return c
+	}
+	if !c.IsRGB() && int(c-ColorValid) < colors {
+		return c
+	}
+	if colors <= 0 {
+		return ColorDefault
+	}
+	palette := make([]Color, colors)
+	for i := range palette {
+		palette[i] = PaletteColor(i)
+	}
+	return FindColor(c, palette)
+}
+
+// style returns the style text in group g is drawn with on the screen of the
+// frame.
+func (f *SimpleFrame) style(g HighlightGroup) Style {
+	if f.groups == nil {
+		f.groups = newHighlightGroups()
+	}
+	return f.groups.style(g, f.screen.Colors())
+}
+
+// cursorLineStyle returns the style of group g on the line with the cursor,
+// which takes the colors CursorLine has and adds its attributes.
+func (f *SimpleFrame) cursorLineStyle(g HighlightGroup) Style {
+	s := f.style(g)
+	fg, bg, attrs := f.style(GroupCursorLine).Decompose()
+	a := f.groups.attrs[GroupCursorLine]
+	if a.fg != ColorDefault {
+		s = s.Foreground(fg)
+	}
+	if a.bg != ColorDefault {
+		s = s.Background(bg)
+	}
+	_, _, own := s.Decompose()
+	return s.Attributes(own | attrs)
+}
+
+// exHighlight implements :highlight.
+//
+//	:highlight                 list every group
+//	:highlight {group}         show how a group looks
+//	:highlight {group} {attr}  change how a group looks, with attributes
+//	                           fg={color}, bg={color} and
+//	                           attr={bold,italic,...}
+//	:highlight clear           restore the default look of every group
+//
+// Colors are given as names, '#rrggbb' or palette indexes, with 'none' being
+// the terminal's default color and 'normal' the color of the Normal group.
+func (f *SimpleFrame) exHighlight(args string) error {
+	if f.groups == nil {
+		f.groups = newHighlightGroups()
+	}
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		var groups []string
+		for g := range f.groups.attrs {
+			groups = append(groups, string(g))
+		}
+		sort.Strings(groups)
+		f.showMessage(strings.Join(groups, " "))
+		return nil
+	}
+	if len(fields) == 1 && fields[0] == "clear" {
+		f.groups.reset()
+		f.damage.markAll()
+		return nil
+	}
+	g := HighlightGroup(fields[0])
+	a := f.groups.attrs[g]
+	if len(fields) == 1 {
+		f.showMessage(formatGroupAttrs(g, a))
+		return nil
+	}
+	for _, field := range fields[1:] {
+		if err := parseGroupAttr(&a, field); err != nil {
+			return err
+		}
+	}
+	f.groups.set(g, a)
+	f.damage.markAll()
+	return nil
+}
+
+func parseGroupAttr(a *groupAttrs, field string) error {
+	kv := strings.SplitN(field, "=", 2)
+	if len(kv) != 2 {
+		return editorErrorf(416, "Missing equal sign: %s", field)
+	}
+	switch kv[0] {
+	case "fg", "bg":
+		c, err := parseColor(kv[1])
+		if err != nil {
+			return err
+		}
+		if kv[0] == "fg" {
+			a.fg = c
+		} else {
+			a.bg = c
+		}
+	case "attr":
+		a.attrs = AttrNone
+		for _, name := range strings.Split(kv[1], ",") {
+			attr, ok := attrNames[name]
+			if !ok {
+				return editorErrorf(418, "Illegal value: %s", name)
+			}
+			a.attrs |= attr
+		}
+	default:
+		return editorErrorf(423, "Illegal argument: %s", field)
+	}
+	return nil
+}
+
+var attrNames = map[string]AttrMask{
+	"none":          AttrNone,
+	"bold":          AttrBold,
+	"blink":         AttrBlink,
+	"reverse":       AttrReverse,
+	"underline":     AttrUnderline,
+	"dim":           AttrDim,
+	"italic":        AttrItalic,
+	"strikethrough": AttrStrikeThrough,
+}
+
+// parseColor parses a color name, '#rrggbb' or a palette index, none for
+// the default color of the terminal or normal for the color of the Normal
+// group.
+func parseColor(s string) (Color, error) {
+	switch strings.ToLower(s) {
+	case "none":
+		return colorNone, nil
+	case "normal":
+		return ColorDefault, nil
+	}
+	if i, err := strconv.Atoi(s); err == nil && i >= 0 && i < 256 {
+		return PaletteColor(i), nil
+	}
+	if c := GetColor(strings.ToLower(s)); c != ColorDefault {
+		return c, nil
+	}
+	return ColorDefault, editorErrorf(254, "Cannot allocate color %s", s)
+}
+
+func formatGroupAttrs(g HighlightGroup, a groupAttrs) string {
+	var attrs []string
+	for name, attr := range attrNames {
+		if attr != AttrNone && a.attrs&attr != 0 {
+			attrs = append(attrs, name)
+		}
+	}
+	sort.Strings(attrs)
+	if len(attrs) == 0 {
+		attrs = []string{"none"}
+	}
+	return fmt.Sprintf("%s fg=%s bg=%s attr=%s", g, formatColor(a.fg), formatColor(a.bg), strings.Join(attrs, ","))
+}
+
+func formatColor(c Color) string {
+	switch {
+	case c == ColorDefault:
+		return "normal"
+	case c == colorNone:
+		return "none"
+	case c.IsRGB():
+		return fmt.Sprintf("#%06x", c.Hex())
+	default:
+		return strconv.Itoa(int(c - ColorValid))
+	}
+}
+
+// exColorScheme implements :colorscheme. Without arguments it shows the name
+// of the current color scheme. Otherwise it loads the named scheme from the
+// colors directory of the configuration directory, a file of ex commands,
+// usually :highlight, named after the scheme with the extension .mog. Every
+// group starts from its default look, which the scheme named default
+// restores without loading anything.
+func (f *SimpleFrame) exColorScheme(args string) error {
+	if f.groups == nil {
+		f.groups = newHighlightGroups()
+	}
+	if args == "" {
+		f.showMessage(f.groups.scheme)
+		return nil
+	}
+	if args == "default" {
+		f.groups.reset()
+		f.groups.scheme = args
+		f.damage.markAll()
+		return nil
+	}
+	if strings.ContainsAny(args, `/\`) || strings.Contains(args, "..") {
+		// The name could lead out of the colors directory.
+		return editorErrorf(474, "Invalid argument: %s", args)
+	}
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "colors", args+".mog")
+	if _, err := os.Stat(path); err != nil {
+		return editorErrorf(185, "Cannot find color scheme '%s'", args)
+	}
+	// Groups the scheme leaves alone look like they do by default rather
+	// than like in the scheme used before.
+	f.groups.reset()
+	f.damage.markAll()
+	if err := f.sourceFile(path, nil); err != nil {
+		return err
+	}
+	f.groups.scheme = args
+	f.damage.markAll()
+	return nil
+}
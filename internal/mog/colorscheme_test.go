@@ -0,0 +1,185 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_fitColor(t *testing.T) {
+	tests := []struct {
+		name     string
+		color    Color
+		colors   int
+		expected Color
+	}{
+		{"true color is kept", NewHexColor(0x123456), 1 << 24, NewHexColor(0x123456)},
+		{"default color is kept", ColorDefault, 8, ColorDefault},
+		{"palette color that fits is kept", PaletteColor(200), 256, PaletteColor(200)},
+		{"true color falls back to 256 colors", NewHexColor(0xff0000), 256, PaletteColor(9)},
+		{"true color falls back to 16 colors", NewHexColor(0x0000e0), 16, ColorBlue},
+		{"palette color falls back to 8 colors", ColorWhite, 8, ColorSilver},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualValues(t, tt.expected, fitColor(tt.color, tt.colors))
+		})
+	}
+}
+
+func TestHighlightGroups_style_InheritsNormalColors(t *testing.T) {
+	h := newHighlightGroups()
+	h.set(GroupNormal, groupAttrs{fg: ColorWhite, bg: ColorBlack})
+	h.set(GroupComment, groupAttrs{fg: ColorGreen, attrs: AttrItalic})
+
+	assert.EqualValues(t,
+		StyleDefault.Foreground(ColorGreen).Background(ColorBlack).Italic(true),
+		h.style(GroupComment, 256))
+}
+
+func TestSimpleFrame_exHighlight(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(1), 10, 10)
+
+	err := f.Execute("hi Comment fg=#ff0000 bg=none attr=bold,underline")
+	assert.Nil(t, err)
+	assert.EqualValues(t, groupAttrs{
+		fg:    NewHexColor(0xff0000),
+		bg:    colorNone,
+		attrs: AttrBold | AttrUnderline,
+	}, f.groups.attrs[GroupComment])
+	assert.True(t, f.damage.full)
+
+	err = f.Execute("highlight Comment")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "Comment fg=#ff0000 bg=none attr=bold,underline", f.message.text)
+
+	// none is the terminal's default even if Normal has a color, normal
+	// follows Normal.
+	assert.Nil(t, f.Execute("hi Normal fg=white bg=black"))
+	_, bg, _ := f.style(GroupComment).Decompose()
+	assert.EqualValues(t, ColorDefault, bg)
+	assert.Nil(t, f.Execute("hi Comment bg=normal"))
+	_, bg, _ = f.style(GroupComment).Decompose()
+	assert.EqualValues(t, ColorBlack, bg)
+
+	err = f.Execute("hi Comment fg=nosuchcolor")
+	assert.EqualError(t, err, "E254: Cannot allocate color nosuchcolor")
+
+	err = f.Execute("hi clear")
+	assert.Nil(t, err)
+	assert.EqualValues(t, defaultGroupAttrs()[GroupComment], f.groups.attrs[GroupComment])
+}
+
+func TestSimpleFrame_exColorScheme(t *testing.T) {
+	dir := t.TempDir()
+	setEnv(t, "XDG_CONFIG_HOME", dir)
+	colors := filepath.Join(dir, "mog", "colors")
+	assert.Nil(t, os.MkdirAll(colors, 0o755))
+	scheme := "\" A dark scheme\nhighlight clear\nhighlight Normal fg=white bg=black\n\nhighlight Keyword fg=#ffaa00\n"
+	assert.Nil(t, os.WriteFile(filepath.Join(colors, "dark.mog"), []byte(scheme), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(colors, "broken.mog"), []byte("hi Normal fg=white\nhi Keyword x\n"), 0o644))
+
+	f, _ := newTestFrame(t, numberedLines(1), 10, 10)
+	err := f.Execute("colorscheme dark")
+	assert.Nil(t, err)
+	assert.EqualValues(t, NewHexColor(0xffaa00), f.groups.attrs[GroupKeyword].fg)
+	assert.EqualValues(t, ColorWhite, f.groups.attrs[GroupNormal].fg)
+
+	err = f.Execute("colo")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "dark", f.message.text)
+
+	err = f.Execute("colorscheme broken")
+	assert.EqualError(t, err, filepath.Join(colors, "broken.mog")+":2: E416: Missing equal sign: x")
+
+	err = f.Execute("colorscheme nosuchscheme")
+	assert.EqualError(t, err, "E185: Cannot find color scheme 'nosuchscheme'")
+	for _, name := range []string{"../dark", "a/b", "..", `a\b`} {
+		assert.EqualError(t, f.Execute("colorscheme "+name), "E474: Invalid argument: "+name)
+	}
+}
+
+func TestSimpleFrame_exColorScheme_StartsFromDefaults(t *testing.T) {
+	dir := t.TempDir()
+	setEnv(t, "XDG_CONFIG_HOME", dir)
+	colors := filepath.Join(dir, "mog", "colors")
+	assert.Nil(t, os.MkdirAll(colors, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(colors, "dark.mog"), []byte("hi Keyword fg=#ffaa00\n"), 0o644))
+	assert.Nil(t, os.WriteFile(filepath.Join(colors, "light.mog"), []byte("hi Normal fg=black\n"), 0o644))
+
+	f, _ := newTestFrame(t, numberedLines(1), 10, 10)
+	assert.Nil(t, f.Execute("colorscheme dark"))
+	assert.Nil(t, f.Execute("colorscheme light"))
+	assert.EqualValues(t, defaultGroupAttrs()[GroupKeyword], f.groups.attrs[GroupKeyword])
+	assert.EqualValues(t, ColorBlack, f.groups.attrs[GroupNormal].fg)
+}
+
+func TestSimpleFrame_Show_CursorLine(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"//", "cd"}, 4, 4)
+	assert.Nil(t, f.Execute("hi Comment fg=red"))
+	assert.Nil(t, f.Execute("set syntax=go cul"))
+	f.Show()
+
+	cells, _, _ := ss.GetContents()
+	underlined := func(i int) bool {
+		_, _, attrs := cells[i].Style.Decompose()
+		return attrs&AttrUnderline != 0
+	}
+	assert.EqualValues(t, []bool{true, true, true, true}, []bool{underlined(0), underlined(1), underlined(2), underlined(3)})
+	assert.False(t, underlined(4))
+	fg, _, _ := cells[0].Style.Decompose()
+	assert.EqualValues(t, ColorRed, fg, "the colors of the group are kept")
+
+	f.MoveCursor(dirDown)
+	f.Show()
+	cells, _, _ = ss.GetContents()
+	assert.False(t, underlined(0))
+	assert.True(t, underlined(4))
+}
+
+func TestSimpleFrame_Show_HlSearch(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"abab", "b"}, 5, 4)
+	assert.Nil(t, f.Execute("/b"))
+	f.Show()
+	cells, _, _ := ss.GetContents()
+	assert.EqualValues(t, f.style(GroupNormal), cells[1].Style, "hlsearch is off")
+
+	assert.Nil(t, f.Execute("set hls"))
+	f.Show()
+	cells, _, _ = ss.GetContents()
+	var styles []Style
+	for _, i := range []int{0, 1, 2, 3, 5} {
+		styles = append(styles, cells[i].Style)
+	}
+	normal, search := f.style(GroupNormal), f.style(GroupSearch)
+	assert.EqualValues(t, []Style{normal, search, normal, search, search}, styles)
+}
+
+func TestSimpleFrame_Execute_UnknownCommand(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(1), 10, 10)
+	assert.EqualError(t, f.Execute("c"), "E492: Not an editor command: c")
+	assert.EqualError(t, f.Execute("frobnicate"), "E492: Not an editor command: frobnicate")
+	assert.EqualError(t, f.Execute("1,2"), "E492: Not an editor command: 1,2")
+	assert.Nil(t, f.Execute(""))
+}
+
+func TestSimpleFrame_handleEventKey_CommandLine(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(1), 20, 10)
+	for _, r := range ":hi Foo x" {
+		f.handleEventKey(*NewEventKey(KeyRune, r, ModNone))
+	}
+	assert.EqualValues(t, ModeCommand, f.mode)
+	assert.EqualValues(t, ":hi Foo x", f.bottomLine())
+
+	f.handleEventKey(*NewEventKey(KeyEnter, 0, ModNone))
+	assert.EqualValues(t, ModeNormal, f.mode)
+	assert.EqualValues(t, "E416: Missing equal sign: x", f.bottomLine())
+
+	for _, r := range ":q" {
+		f.handleEventKey(*NewEventKey(KeyRune, r, ModNone))
+	}
+	closed := f.handleEventKey(*NewEventKey(KeyEnter, 0, ModNone))
+	assert.True(t, closed)
+}
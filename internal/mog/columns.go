@@ -0,0 +1,96 @@
+package mog
+
+import "unicode/utf8"
+
+// Positions in a line are byte offsets, while the screen is made up of
+// columns. Every character takes up one column, except for tabs, which take
+// up the columns up to the next multiple of tabstop, and bytes that are not
+// part of valid UTF-8, which are shown as <xx>.
+
+// invalidByteWidth is the number of columns an invalid byte takes up.
+const invalidByteWidth = 4
+
+// charAt returns the character at the start of s together with its size in
+// bytes and the number of columns it takes up when shown in column col. r
+// is utf8.RuneError for an invalid byte.
+func charAt(s string, col, tabStop int) (r rune, size, width int) {
+	r, size = utf8.DecodeRuneInString(s)
+	switch {
+	case r == utf8.RuneError && size == 1:
+		return r, 1, invalidByteWidth
+	case r == '\t' && tabStop > 0:
+		return r, 1, tabStop - col%tabStop
+	}
+	return r, size, 1
+}
+
+// lineWidth returns the number of columns line takes up.
+func lineWidth(line string, tabStop int) int {
+	width := 0
+	for i := 0; i < len(line); {
+		_, size, w := charAt(line[i:], width, tabStop)
+		width += w
+		i += size
+	}
+	return width
+}
+
+// columnOf returns the column of the character at byte offset x of line.
+// Offsets beyond the end of the line take up a column each.
+func columnOf(line string, x, tabStop int) int {
+	col := 0
+	for i := 0; i < len(line); {
+		_, size, w := charAt(line[i:], col, tabStop)
+		if i+size > x {
+			return col
+		}
+		col += w
+		i += size
+	}
+	return col + x - len(line)
+}
+
+// byteAtColumn returns the byte offset of the character of line shown in
+// column col.
+func byteAtColumn(line string, col, tabStop int) int {
+	c := 0
+	for i := 0; i < len(line); {
+		_, size, w := charAt(line[i:], c, tabStop)
+		if c+w > col {
+			return i
+		}
+		c += w
+		i += size
+	}
+	return len(line) + col - c
+}
+
+// lastCharStart returns the byte offset of the last character of line, or
+// -1 if it is empty.
+func lastCharStart(line string) int {
+	if line == "" {
+		return -1
+	}
+	_, size := utf8.DecodeLastRuneInString(line)
+	return len(line) - size
+}
+
+// nextCharStart returns the byte offset of the character following the one
+// at byte offset x of line.
+func nextCharStart(line string, x int) int {
+	if x >= len(line) {
+		return x + 1
+	}
+	_, size := utf8.DecodeRuneInString(line[x:])
+	return x + size
+}
+
+// prevCharStart returns the byte offset of the character preceding the one
+// at byte offset x of line.
+func prevCharStart(line string, x int) int {
+	if x > len(line) {
+		return x - 1
+	}
+	_, size := utf8.DecodeLastRuneInString(line[:x])
+	return x - maxInt(size, 1)
+}
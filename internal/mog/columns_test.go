@@ -0,0 +1,97 @@
+package mog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_columnOf_byteAtColumn(t *testing.T) {
+	line := "aé\xffb"
+	tests := []struct {
+		x, col int
+	}{
+		{0, 0},
+		{1, 1},
+		{3, 2},
+		{4, 6},
+		{5, 7},
+		{7, 9},
+	}
+	for _, tt := range tests {
+		assert.EqualValues(t, tt.col, columnOf(line, tt.x, defaultTabStop), "column of byte %d", tt.x)
+		assert.EqualValues(t, tt.x, byteAtColumn(line, tt.col, defaultTabStop), "byte at column %d", tt.col)
+	}
+	assert.EqualValues(t, 1, columnOf(line, 2, defaultTabStop), "the second byte of é")
+	assert.EqualValues(t, 3, byteAtColumn(line, 4, defaultTabStop), "inside <ff>")
+	assert.EqualValues(t, 7, lineWidth(line, defaultTabStop))
+}
+
+func Test_columnOf_byteAtColumn_Tabs(t *testing.T) {
+	line := "\tab\tc\t"
+	tests := []struct {
+		x, col int
+	}{
+		{0, 0},
+		{1, 4},
+		{2, 5},
+		{3, 6},
+		{4, 8},
+		{5, 9},
+		{6, 12},
+	}
+	for _, tt := range tests {
+		assert.EqualValues(t, tt.col, columnOf(line, tt.x, 4), "column of byte %d", tt.x)
+		assert.EqualValues(t, tt.x, byteAtColumn(line, tt.col, 4), "byte at column %d", tt.col)
+	}
+	assert.EqualValues(t, 0, byteAtColumn(line, 3, 4), "inside the first tab")
+	assert.EqualValues(t, 12, lineWidth(line, 4))
+	assert.EqualValues(t, 6, lineWidth(line, 0), "no tabstop")
+}
+
+func TestSimpleFrame_MoveCursor_StepsOverCharacters(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(1), 20, 5)
+	f.buffer = newLineBuffer([]string{"aé\xffb"})
+
+	var xs []int
+	for i := 0; i < 4; i++ {
+		f.MoveCursor(dirRight)
+		xs = append(xs, f.cursor.XPos())
+	}
+	assert.EqualValues(t, []int{1, 3, 4, 4}, xs)
+	f.MoveCursor(dirLeft)
+	f.MoveCursor(dirLeft)
+	assert.EqualValues(t, 1, f.cursor.XPos())
+}
+
+func TestSimpleFrame_Show_InvalidBytes(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"a\xffé", "b"}, 5, 4)
+	f.cursor.MoveTo(2, 0)
+	f.Show()
+
+	assert.EqualValues(t, []string{"a<ff>", "é    ", "b    ", " -- N"}, screenContents(ss))
+	cells, _, _ := ss.GetContents()
+	assert.EqualValues(t, f.style(GroupNonText), cells[1].Style)
+	x, y := f.cursorScreenPos()
+	assert.EqualValues(t, []int{0, 1}, []int{x, y})
+
+	f.handleEventKey(*NewEventKey(KeyRune, 'i', ModNone))
+	f.handleEventKey(*NewEventKey(KeyRune, 'ü', ModNone))
+	assert.EqualValues(t, []string{"a\xffüé", "b"}, f.buffer.lines())
+	assert.EqualValues(t, 4, f.cursor.XPos())
+}
+
+func TestSimpleFrame_Show_Tabs(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"\ta\tb", "c\td"}, 6, 5)
+	assert.Nil(t, f.Execute("set ts=4"))
+	f.cursor.MoveTo(3, 0)
+	f.Show()
+
+	assert.EqualValues(t, []string{"    a ", "  b   ", "c   d ", "~     ", " -- No"}, screenContents(ss))
+	x, y := f.cursorScreenPos()
+	assert.EqualValues(t, []int{2, 1}, []int{x, y})
+
+	assert.Nil(t, f.Execute("set ts=2"))
+	f.Show()
+	assert.EqualValues(t, []string{"  a b ", "c d   ", "~     ", "~     ", " -- No"}, screenContents(ss))
+}
@@ -0,0 +1,67 @@
+package mog
+
+import "strings"
+
+// defaultCommentString is the default of commentstring.
+const defaultCommentString = "/* %s */"
+
+// checkCommentString reports whether v says where the commented line goes.
+func checkCommentString(v *optionValue) bool {
+	return strings.Count(v.s, "%s") == 1
+}
+
+// toggleComment comments out the lines from y to last with commentstring,
+// or, if all of those that are not blank are commented out already, removes
+// their comments. Blank lines are left alone. Comments are put after the
+// indentation of a line.
+func (f *SimpleFrame) toggleComment(y, last int) {
+	parts := strings.SplitN(f.commentString, "%s", 2)
+	if len(parts) != 2 {
+		return
+	}
+	start, end := parts[0], parts[1]
+	f.ensureLoaded(y, last+1)
+	lines := f.buffer.slice(y, last+1)
+	uncomment := true
+	for _, line := range lines {
+		if body := strings.TrimSpace(line); body != "" && !isCommented(body, start, end) {
+			uncomment = false
+		}
+	}
+	for i, line := range lines {
+		body := strings.TrimLeft(line, " \t")
+		if strings.TrimSpace(body) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(body)]
+		if uncomment {
+			lines[i] = indent + uncommentLine(strings.TrimRight(body, " \t"), start, end)
+		} else {
+			lines[i] = indent + start + body + end
+		}
+	}
+	f.undo.begin(f.cursorPos())
+	f.replaceLines(y, len(lines), lines)
+	f.undo.end()
+}
+
+// isCommented reports whether body, a line without surrounding white
+// space, is commented out with start and end.
+func isCommented(body, start, end string) bool {
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	return len(body) >= len(start)+len(end) && strings.HasPrefix(body, start) && strings.HasSuffix(body, end)
+}
+
+// uncommentLine removes the comment start and end from body, together with
+// the white space they are written with in commentstring, if it is there.
+func uncommentLine(body, start, end string) string {
+	if strings.HasPrefix(body, start) {
+		body = body[len(start):]
+	} else {
+		body = body[len(strings.TrimSpace(start)):]
+	}
+	if strings.HasSuffix(body, end) {
+		return body[:len(body)-len(end)]
+	}
+	return body[:len(body)-len(strings.TrimSpace(end))]
+}
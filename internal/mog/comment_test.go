@@ -0,0 +1,31 @@
+package mog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleFrame_toggleComment(t *testing.T) {
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+	f.loadBuffer([]byte("\tfoo()\n\n  bar()"))
+
+	typeKeys(f, "gcc")
+	assert.EqualValues(t, []string{"\t/* foo() */", "", "  bar()"}, f.buffer.lines())
+	typeKeys(f, "gcc")
+	assert.EqualValues(t, []string{"\tfoo()", "", "  bar()"}, f.buffer.lines())
+
+	assert.Nil(t, f.Execute("set cms=//%s"))
+	f.toggleComment(0, 2)
+	assert.EqualValues(t, []string{"\t//foo()", "", "  //bar()"}, f.buffer.lines())
+	// Comments written without the white space of commentstring are
+	// removed as well.
+	assert.Nil(t, f.Execute("set cms=//\\ %s"))
+	f.toggleComment(0, 2)
+	assert.EqualValues(t, []string{"\tfoo()", "", "  bar()"}, f.buffer.lines())
+	f.toggleComment(0, 1)
+	assert.EqualValues(t, []string{"\t// foo()", "", "  bar()"}, f.buffer.lines())
+
+	assert.EqualValues(t, "E474: Invalid argument: commentstring=//", f.Execute("set cms=//").Error())
+}
@@ -0,0 +1,183 @@
+package mog
+
+import "strings"
+
+// damage keeps track of the parts of the screen that no longer match the
+// frame and have to be redrawn by the next call to Show.
+type damage struct {
+	// full is set when the whole screen has to be redrawn.
+	full bool
+	// lines holds the buffer lines that have to be redrawn, together with
+	// the number of screen lines they occupied when they were last drawn or
+	// 0 when that is unknown.
+	lines map[int]int
+	// below is set when every buffer line from line from onwards has to be
+	// redrawn, e.g. because the lines above it changed height.
+	below bool
+	from  int
+}
+
+// markAll marks the whole screen as damaged.
+func (d *damage) markAll() {
+	d.full = true
+}
+
+// markLine marks buffer line y as damaged. height is the number of screen
+// lines y occupied before it changed, or 0 if that is unknown.
+func (d *damage) markLine(y, height int) {
+	if d.lines == nil {
+		d.lines = make(map[int]int)
+	}
+	if _, ok := d.lines[y]; ok {
+		// The line was already damaged, so what is on the screen is what it
+		// looked like before the first change.
+		return
+	}
+	d.lines[y] = height
+}
+
+// markBelow marks every buffer line from line y onwards as damaged.
+func (d *damage) markBelow(y int) {
+	if !d.below || y < d.from {
+		d.from = y
+	}
+	d.below = true
+}
+
+// reset marks the whole screen as up to date.
+func (d *damage) reset() {
+	d.full = false
+	d.lines = nil
+	d.below = false
+	d.from = 0
+}
+
+// drawnState describes what was shown on the screen when it was last
+// redrawn, so that changes to it can be detected.
+type drawnState struct {
+	valid         bool
+	width, height int
+	offset        int
+	cursorLine    int
+	bottomLine    string
+	bottomError   bool
+	fileFormat    string
+	// selection holds the first and last line of the selected text, if any.
+	selection [2]int
+}
+
+// Show redraws the parts of the screen that changed since it was last shown
+// and makes the changes visible.
+func (f *SimpleFrame) Show() {
+	if f.hex != nil {
+		f.showHex()
+		return
+	}
+	f.ensureVisibleLoaded()
+	f.trackViewChanges()
+	if f.damage.full {
+		f.screen.SetStyle(f.style(GroupNormal))
+		f.screen.Clear()
+		f.writeBufferToScreen()
+	} else {
+		f.redrawDamage()
+	}
+	f.damage.reset()
+	f.drawn.bottomLine = f.bottomLine()
+	f.drawn.bottomError = f.message.isError
+	f.drawn.fileFormat = f.fileFormat
+	f.screen.Show()
+	f.showCursor()
+}
+
+// trackViewChanges marks the parts of the screen that are affected by
+// changes to the view itself rather than to the buffer: resizing, scrolling
+// and moving the cursor to another line.
+func (f *SimpleFrame) trackViewChanges() {
+	w, h := f.screen.Size()
+	if !f.drawn.valid || w != f.drawn.width || h != f.drawn.height || f.offset != f.drawn.offset {
+		f.damage.markAll()
+	}
+	// Messages on more than one line cover the text, which has to be
+	// redrawn when they change.
+	if bottom := f.bottomLine(); bottom != f.drawn.bottomLine &&
+		(strings.Contains(bottom, "\n") || strings.Contains(f.drawn.bottomLine, "\n")) {
+		f.damage.markAll()
+	}
+	if y := f.cursor.YPos(); y != f.drawn.cursorLine {
+		f.damage.markLine(f.drawn.cursorLine, f.lineHeightIfValid(f.drawn.cursorLine))
+		f.damage.markLine(y, f.lineHeightIfValid(y))
+	}
+	first, last := f.selectionLines()
+	// Moving the cursor changes the selection, so every line that is or was
+	// selected may look different.
+	if first >= 0 || f.drawn.selection[0] >= 0 {
+		f.markLines(f.drawn.selection[0], f.drawn.selection[1])
+		f.markLines(first, last)
+	}
+	f.drawn = drawnState{
+		valid:       true,
+		width:       w,
+		height:      h,
+		offset:      f.offset,
+		cursorLine:  f.cursor.YPos(),
+		bottomLine:  f.drawn.bottomLine,
+		bottomError: f.drawn.bottomError,
+		fileFormat:  f.drawn.fileFormat,
+		selection:   [2]int{first, last},
+	}
+}
+
+// markLines marks the visible buffer lines from first to last as damaged.
+func (f *SimpleFrame) markLines(first, last int) {
+	if first < 0 {
+		return
+	}
+	last = minInt(last, f.lastVisibleLine())
+	for y := maxInt(first, f.offset); y <= last; y++ {
+		f.damage.markLine(y, f.lineHeightIfValid(y))
+	}
+}
+
+// lineHeightIfValid returns the height of buffer line y, or 0 if y is not a
+// line of the buffer.
+func (f *SimpleFrame) lineHeightIfValid(y int) int {
+	if y < 0 || y >= f.buffer.len() {
+		return 0
+	}
+	return f.lineHeight(y)
+}
+
+// redrawDamage redraws the damaged buffer lines and the bottom line if its
+// contents changed. A damaged line that changed height moves every line
+// below it, so those are redrawn as well.
+func (f *SimpleFrame) redrawDamage() {
+	for y, height := range f.damage.lines {
+		if y < f.offset || y >= f.buffer.len() {
+			continue
+		}
+		if height != f.lineHeight(y) {
+			f.damage.markBelow(y)
+		}
+	}
+	// Every line takes up at least one screen line, so lines this far
+	// down are not visible.
+	if f.damage.below && f.damage.from < f.offset+f.textHeight() {
+		f.writeBufferLines(maxInt(f.damage.from, f.offset))
+	}
+	textHeight := f.textHeight()
+	for y := range f.damage.lines {
+		if y < f.offset || y >= f.buffer.len() || f.damage.below && y >= f.damage.from {
+			continue
+		}
+		row := f.syncLayout().start(&f.buffer, f.offset, y)
+		if row >= textHeight {
+			continue
+		}
+		f.writeLine(y, row)
+	}
+	if f.bottomLine() != f.drawn.bottomLine || f.message.isError != f.drawn.bottomError ||
+		f.fileFormat != f.drawn.fileFormat {
+		f.writeBufferBottomLine()
+	}
+}
@@ -0,0 +1,83 @@
+package mog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func screenContents(ss *SimulationScreen) []string {
+	cells, w, h := ss.GetContents()
+	var lines []string
+	for i := 0; i < h; i++ {
+		var line []rune
+		for j := 0; j < w; j++ {
+			line = append(line, cells[i*w+j].Runes[0])
+		}
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+func TestSimpleFrame_Show_OnlyRedrawsDamagedLines(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"ab", "cd", "ef"}, 5, 5)
+	f.mode = ModeInsert
+	f.Show()
+
+	// Scribble over a line that is not damaged, a redraw should leave it.
+	ss.SetContent(0, 2, '#', nil, StyleDefault)
+	f.InsertRune('x')
+	f.Show()
+
+	assert.EqualValues(t, []string{"xab  ", "cd   ", "#f   ", "~    ", " -- I"}, screenContents(ss))
+}
+
+func TestSimpleFrame_Show_RedrawsLinesBelowALineThatChangedHeight(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"abcd", "ef", "gh"}, 5, 5)
+	f.mode = ModeInsert
+	f.Show()
+
+	ss.SetContent(4, 3, '#', nil, StyleDefault)
+	f.InsertRune('x')
+	f.Show()
+
+	assert.EqualValues(t, []string{"xabcd", "     ", "ef   ", "gh   ", " -- I"}, screenContents(ss))
+}
+
+func TestSimpleFrame_Show_RedrawsCursorLines(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"ab", "cd", "ef"}, 5, 5)
+	f.mode = ModeInsert
+	f.Show()
+
+	ss.SetContent(4, 0, '#', nil, StyleDefault)
+	ss.SetContent(4, 1, '#', nil, StyleDefault)
+	ss.SetContent(4, 2, '#', nil, StyleDefault)
+	f.MoveCursor(dirDown)
+	f.Show()
+
+	assert.EqualValues(t, []string{"ab   ", "cd   ", "ef  #", "~    ", " -- I"}, screenContents(ss))
+}
+
+func TestSimpleFrame_Show_RedrawsBottomLineWhenItChanges(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"ab"}, 14, 3)
+	f.mode = ModeInsert
+	f.Show()
+
+	ss.SetContent(0, 1, '#', nil, StyleDefault)
+	f.mode = ModeNormal
+	f.Show()
+
+	assert.EqualValues(t, []string{"ab            ", "#             ", " -- Normal -- "}, screenContents(ss))
+}
+
+func TestSimpleFrame_Show_RedrawsEverythingAfterScrolling(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"a", "b", "c", "d"}, 3, 3)
+	f.mode = ModeInsert
+	f.Show()
+
+	ss.SetContent(2, 0, '#', nil, StyleDefault)
+	f.ScrollLines(1)
+	f.Show()
+
+	assert.EqualValues(t, []string{"b  ", "c  ", " --"}, screenContents(ss))
+}
@@ -0,0 +1,334 @@
+package mog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// editorConfigName is the name of the files holding the EditorConfig
+// properties of the files in their directory and below it.
+const editorConfigName = ".editorconfig"
+
+// editorConfig holds the EditorConfig properties of a file, by their name.
+// Names and values are in lower case.
+type editorConfig map[string]string
+
+// editorConfigFor returns the EditorConfig properties of the file at path.
+// The .editorconfig files of its directory and every directory above it
+// are read, up to the first one declaring itself the root. Properties of
+// files closer to the file, and of later sections within a file, win.
+// Lines that cannot be parsed are skipped; the first of them is returned
+// as the error together with the properties.
+func editorConfigFor(path string) (editorConfig, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []*editorConfigFile
+	var skipped error
+	for dir := filepath.Dir(path); ; {
+		file, err := readEditorConfigFile(filepath.Join(dir, editorConfigName))
+		if err != nil && file == nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err != nil && file != nil && skipped == nil {
+			skipped = err
+		}
+		if file != nil {
+			files = append(files, file)
+			if file.root {
+				break
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	c := editorConfig{}
+	for i := len(files) - 1; i >= 0; i-- {
+		files[i].apply(c, path)
+	}
+	for name, value := range c {
+		if value == "unset" {
+			delete(c, name)
+		}
+	}
+	return c, skipped
+}
+
+// editorConfigFile is a parsed .editorconfig file.
+type editorConfigFile struct {
+	dir      string
+	root     bool
+	sections []editorConfigSection
+}
+
+// editorConfigSection holds the properties of the files a glob matches.
+type editorConfigSection struct {
+	glob       *editorConfigGlob
+	properties [][2]string
+}
+
+// readEditorConfigFile parses the .editorconfig file at path. Lines are
+// either comments starting with '#' or ';', section headers holding a glob
+// in brackets, or name = value pairs, which are the properties of the
+// files matching the glob of their section. Pairs before the first section
+// are about the file itself.
+//
+// Lines that are none of these are skipped, and so is a section whose glob
+// is invalid. The file is still returned then, together with an error
+// about the first such line.
+func readEditorConfigFile(path string) (*editorConfigFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	c := &editorConfigFile{dir: filepath.Dir(path)}
+	var section *editorConfigSection
+	inSection := false
+	var skipped error
+	skip := func(n int, err error) {
+		if skipped == nil {
+			skipped = fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+	}
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[' && line[len(line)-1] == ']':
+			inSection, section = true, nil
+			glob, err := compileEditorConfigGlob(line[1 : len(line)-1])
+			if err != nil {
+				skip(n, err)
+				continue
+			}
+			c.sections = append(c.sections, editorConfigSection{glob: glob})
+			section = &c.sections[len(c.sections)-1]
+		default:
+			kv := strings.SplitN(line, "=", 2)
+			if len(kv) != 2 {
+				skip(n, errors.New("expected name = value"))
+				continue
+			}
+			name := strings.ToLower(strings.TrimSpace(kv[0]))
+			value := strings.ToLower(strings.TrimSpace(kv[1]))
+			if section != nil {
+				section.properties = append(section.properties, [2]string{name, value})
+			} else if !inSection && name == "root" {
+				c.root = value == "true"
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, skipped
+}
+
+// apply adds the properties of the sections matching the file at path to c.
+func (c *editorConfigFile) apply(props editorConfig, path string) {
+	rel, err := filepath.Rel(c.dir, path)
+	if err != nil {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	for _, s := range c.sections {
+		if s.glob.match(rel) {
+			for _, p := range s.properties {
+				props[p[0]] = p[1]
+			}
+		}
+	}
+}
+
+// editorConfigGlob is the glob of a section, matching the path of a file
+// relative to the directory of the .editorconfig file. Globs are
+//
+//	?              any character but '/'
+//	*              any characters but '/'
+//	**             any characters
+//	[abc], [!abc]  any character in, or not in, the brackets
+//	{a,b,c}        any of the comma separated globs
+//	{1..10}        any integer between the numbers
+//	\c             the character c
+//
+// A glob without a '/' matches a file in any directory, one starting with
+// a '/' only files relative to the .editorconfig file.
+type editorConfigGlob struct {
+	re *regexp.Regexp
+	// ranges holds the bounds of the {n..m} of the glob, in the order of
+	// the groups of re matching them.
+	ranges [][2]int
+}
+
+var editorConfigRange = regexp.MustCompile(`^\{([+-]?\d+)\.\.([+-]?\d+)\}`)
+
+func compileEditorConfigGlob(glob string) (*editorConfigGlob, error) {
+	g := &editorConfigGlob{}
+	var re strings.Builder
+	re.WriteString("^")
+	if !strings.Contains(glob, "/") {
+		re.WriteString("(?:.*/)?")
+	}
+	glob = strings.TrimPrefix(glob, "/")
+	depth := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '\\' && i+1 < len(glob):
+			i++
+			re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			i++
+			re.WriteString(".*")
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			re.WriteString("[")
+			if strings.HasPrefix(class, "!") {
+				re.WriteString("^")
+				class = class[1:]
+			}
+			re.WriteString(strings.NewReplacer(`\`, `\\`, "[", `\[`).Replace(class))
+			re.WriteString("]")
+			i += end + 1
+		case c == '{':
+			if m := editorConfigRange.FindStringSubmatch(glob[i:]); m != nil {
+				lo, _ := strconv.Atoi(m[1])
+				hi, _ := strconv.Atoi(m[2])
+				g.ranges = append(g.ranges, [2]int{lo, hi})
+				re.WriteString(`([+-]?\d+)`)
+				i += len(m[0]) - 1
+				continue
+			}
+			if !strings.Contains(glob[i:], "}") {
+				re.WriteString(`\{`)
+				continue
+			}
+			depth++
+			re.WriteString("(?:")
+		case c == ',' && depth > 0:
+			re.WriteString("|")
+		case c == '}' && depth > 0:
+			depth--
+			re.WriteString(")")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	re.WriteString("$")
+	r, err := regexp.Compile(re.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q", glob)
+	}
+	g.re = r
+	return g, nil
+}
+
+func (g *editorConfigGlob) match(path string) bool {
+	m := g.re.FindStringSubmatch(path)
+	if m == nil {
+		return false
+	}
+	for i, r := range g.ranges {
+		n, err := strconv.Atoi(m[i+1])
+		if err != nil || n < r[0] || n > r[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// encoding returns the encoding charset names, or "" if there is none.
+func (c editorConfig) encoding() string {
+	if c["charset"] == "utf-8-bom" {
+		return encodingUTF8
+	}
+	return normalizeEncoding(c["charset"])
+}
+
+var editorConfigFileFormats = map[string]string{
+	"lf":   fileFormatUnix,
+	"crlf": fileFormatDos,
+	"cr":   fileFormatMac,
+}
+
+// settings returns the :setlocal arguments that give the buffer options
+// the values of the properties.
+func (c editorConfig) settings() []string {
+	var args []string
+	switch c["indent_style"] {
+	case "tab":
+		args = append(args, "noexpandtab")
+	case "space":
+		args = append(args, "expandtab")
+	}
+	if size := c["indent_size"]; size == "tab" {
+		args = append(args, "shiftwidth=0")
+	} else if size != "" {
+		args = append(args, "shiftwidth="+size)
+		if c["tab_width"] == "" {
+			args = append(args, "tabstop="+size)
+		}
+	}
+	if width := c["tab_width"]; width != "" {
+		args = append(args, "tabstop="+width)
+	}
+	if format, ok := editorConfigFileFormats[c["end_of_line"]]; ok {
+		args = append(args, "fileformat="+format)
+	}
+	if encoding := c.encoding(); encoding != "" {
+		args = append(args, "fileencoding="+encoding)
+		if c["charset"] == "utf-8-bom" {
+			args = append(args, "bomb")
+		} else if encoding == encodingUTF8 {
+			args = append(args, "nobomb")
+		}
+	}
+	switch c["trim_trailing_whitespace"] {
+	case "true":
+		args = append(args, "trimtrailingwhitespace")
+	case "false":
+		args = append(args, "notrimtrailingwhitespace")
+	}
+	switch c["insert_final_newline"] {
+	case "true":
+		args = append(args, "fixendofline")
+	case "false":
+		args = append(args, "nofixendofline")
+	}
+	if length := c["max_line_length"]; length == "off" {
+		args = append(args, "textwidth=0")
+	} else if length != "" {
+		args = append(args, "textwidth="+length)
+	}
+	return args
+}
+
+// applyEditorConfig sets the buffer options to the properties in c. Values
+// the options do not take are shown as errors.
+func (f *SimpleFrame) applyEditorConfig(c editorConfig) {
+	for _, arg := range c.settings() {
+		if err := f.setOption(arg, localValue); err != nil {
+			f.showError(fmt.Errorf("%s: %w", editorConfigName, err))
+		}
+	}
+}
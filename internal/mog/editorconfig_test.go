@@ -0,0 +1,121 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_compileEditorConfigGlob(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		{"*", "a/b/main.go", true},
+		{"*.go", "a/b/main.go", true},
+		{"*.go", "main.c", false},
+		{"/*.go", "a/main.go", false},
+		{"/*.go", "main.go", true},
+		{"a/*.go", "a/main.go", true},
+		{"a/*.go", "a/b/main.go", false},
+		{"a/**.go", "a/b/main.go", true},
+		{"?.c", "x.c", true},
+		{"?.c", "xy.c", false},
+		{"[abc].txt", "b.txt", true},
+		{"[!abc].txt", "b.txt", false},
+		{"*.{js,ts}", "app.ts", true},
+		{"*.{js,ts}", "app.go", false},
+		{"{Makefile,*.mk}", "build/rules.mk", true},
+		{"file{1..10}.txt", "file7.txt", true},
+		{"file{1..10}.txt", "file11.txt", false},
+		{`\*.txt`, "*.txt", true},
+		{`\*.txt`, "a.txt", false},
+	}
+	for _, tt := range tests {
+		g, err := compileEditorConfigGlob(tt.glob)
+		assert.Nil(t, err)
+		assert.EqualValues(t, tt.want, g.match(tt.path), "%s %s", tt.glob, tt.path)
+	}
+}
+
+func writeEditorConfig(t *testing.T, dir, contents string) {
+	assert.Nil(t, os.MkdirAll(dir, 0o755))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, editorConfigName), []byte(contents), 0o644))
+}
+
+func Test_editorConfigFor(t *testing.T) {
+	dir := t.TempDir()
+	writeEditorConfig(t, dir, "[*]\nindent_style = tab\n")
+	writeEditorConfig(t, filepath.Join(dir, "repo"), `
+root = true
+
+[*]
+indent_style = space
+indent_size = 4
+; comment
+charset = utf-8
+
+[*.{yml,yaml}]
+indent_size = 2
+
+[Makefile]
+indent_style = Tab
+indent_size = unset
+`)
+	writeEditorConfig(t, filepath.Join(dir, "repo", "sub"), "[*.yaml]\nindent_size = 8\n")
+
+	c, err := editorConfigFor(filepath.Join(dir, "repo", "sub", "a.yaml"))
+	assert.Nil(t, err)
+	assert.EqualValues(t, editorConfig{"indent_style": "space", "indent_size": "8", "charset": "utf-8"}, c)
+	c, err = editorConfigFor(filepath.Join(dir, "repo", "Makefile"))
+	assert.Nil(t, err)
+	assert.EqualValues(t, editorConfig{"indent_style": "tab", "charset": "utf-8"}, c)
+	c, err = editorConfigFor(filepath.Join(dir, "main.go"))
+	assert.Nil(t, err)
+	assert.EqualValues(t, editorConfig{"indent_style": "tab"}, c)
+
+	// Malformed lines are skipped, the rest still applies.
+	writeEditorConfig(t, filepath.Join(dir, "bad"), "[*]\nindent_style\nindent_size = 2\n[[z-a]]\ntab_width = 8\n")
+	c, err = editorConfigFor(filepath.Join(dir, "bad", "main.go"))
+	assert.EqualValues(t, filepath.Join(dir, "bad", editorConfigName)+":2: expected name = value", err.Error())
+	assert.EqualValues(t, editorConfig{"indent_style": "tab", "indent_size": "2"}, c)
+}
+
+func TestSimpleFrame_loadFile_EditorConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeEditorConfig(t, dir, `
+root = true
+[*.txt]
+indent_style = space
+indent_size = 2
+tab_width = 4
+end_of_line = crlf
+charset = latin1
+trim_trailing_whitespace = true
+insert_final_newline = true
+max_line_length = 20
+`)
+	path := filepath.Join(dir, "a.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("caf\xe9  \r\nok"), 0o644))
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+
+	assert.Nil(t, f.loadFile(path))
+	assert.EqualValues(t, []string{"café  ", "ok"}, f.buffer.lines())
+	assert.True(t, f.expandTab)
+	assert.EqualValues(t, 2, f.shiftWidth)
+	assert.EqualValues(t, 4, f.tabStop)
+	assert.EqualValues(t, 20, f.textWidth)
+	assert.EqualValues(t, fileFormatDos, f.fileFormat)
+	assert.EqualValues(t, encodingLatin1, f.fileEncoding)
+	assert.False(t, f.modified)
+
+	assert.Nil(t, f.Execute("write"))
+	bs, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "caf\xe9\r\nok\r\n", string(bs))
+	f.Undo()
+	assert.EqualValues(t, []string{"café  ", "ok"}, f.buffer.lines())
+}
@@ -0,0 +1,316 @@
+package mog
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The encodings files can be read and written in. The buffer itself always
+// holds UTF-8, except for bytes of UTF-8 files that are not valid UTF-8,
+// which are kept as they are so that writing the file does not change them.
+// Likewise, what cannot be decoded from UTF-16 is kept as bytes that are not
+// valid UTF-8, see decodeUTF16.
+const (
+	encodingUTF8    = "utf-8"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+	encodingLatin1  = "latin1"
+	encodingCP1252  = "cp1252"
+)
+
+var encodingAliases = map[string]string{
+	"utf8":         encodingUTF8,
+	"utf-16":       encodingUTF16BE,
+	"ucs-2le":      encodingUTF16LE,
+	"ucs-2":        encodingUTF16BE,
+	"latin-1":      encodingLatin1,
+	"iso-8859-1":   encodingLatin1,
+	"windows-1252": encodingCP1252,
+}
+
+// normalizeEncoding returns the name of the encoding called name, or "" if
+// there is no such encoding.
+func normalizeEncoding(name string) string {
+	name = strings.ToLower(name)
+	switch name {
+	case encodingUTF8, encodingUTF16LE, encodingUTF16BE, encodingLatin1, encodingCP1252:
+		return name
+	}
+	return encodingAliases[name]
+}
+
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+func byteOrderMark(encoding string) []byte {
+	switch encoding {
+	case encodingUTF8:
+		return bomUTF8
+	case encodingUTF16LE:
+		return bomUTF16LE
+	case encodingUTF16BE:
+		return bomUTF16BE
+	}
+	return nil
+}
+
+// detectEncoding returns the encoding of bs and whether it starts with a
+// byte order mark. Without one, text that is mostly UTF-8 is taken to be
+// UTF-8 with a few invalid bytes and anything else to be Windows-1252.
+func detectEncoding(bs []byte) (encoding string, bom bool) {
+	for _, enc := range []string{encodingUTF8, encodingUTF16LE, encodingUTF16BE} {
+		if bytes.HasPrefix(bs, byteOrderMark(enc)) {
+			return enc, true
+		}
+	}
+	if utf8.Valid(bs) || hasMultiByteRune(bs) {
+		return encodingUTF8, false
+	}
+	return encodingCP1252, false
+}
+
+func hasMultiByteRune(bs []byte) bool {
+	for len(bs) > 0 {
+		r, size := utf8.DecodeRune(bs)
+		if r != utf8.RuneError && size > 1 {
+			return true
+		}
+		bs = bs[size:]
+	}
+	return false
+}
+
+// cp1252 maps the bytes 0x80 to 0x9f of Windows-1252 to runes. The five
+// bytes that are not used are mapped to the C1 control characters, just
+// like in Latin-1, so that every byte survives decoding and encoding.
+var cp1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// decode converts bs from encoding to UTF-8, dropping the byte order mark
+// if it has one.
+func decode(bs []byte, encoding string, bom bool) []byte {
+	if bom {
+		bs = bytes.TrimPrefix(bs, byteOrderMark(encoding))
+	}
+	switch encoding {
+	case encodingUTF16LE, encodingUTF16BE:
+		return decodeUTF16(bs, encoding == encodingUTF16BE)
+	case encodingLatin1, encodingCP1252:
+		var b strings.Builder
+		for _, c := range bs {
+			r := rune(c)
+			if encoding == encodingCP1252 && c >= 0x80 && c < 0xa0 {
+				r = cp1252[c-0x80]
+			}
+			b.WriteRune(r)
+		}
+		return []byte(b.String())
+	default:
+		return bs
+	}
+}
+
+// encode converts text from UTF-8 to encoding, adding a byte order mark if
+// bom is set. It fails for text that cannot be represented in encoding.
+func encode(text string, encoding string, bom bool) ([]byte, error) {
+	var out []byte
+	if bom {
+		out = append(out, byteOrderMark(encoding)...)
+	}
+	switch encoding {
+	case encodingUTF16LE, encodingUTF16BE:
+		return encodeUTF16(out, text, encoding == encodingUTF16BE), nil
+	case encodingLatin1, encodingCP1252:
+		for _, r := range text {
+			c, ok := encodeSingleByte(r, encoding)
+			if !ok {
+				return nil, editorErrorf(513, "Write error, conversion failed (make 'fenc' utf-8 to override)")
+			}
+			out = append(out, c)
+		}
+		return out, nil
+	default:
+		return append(out, text...), nil
+	}
+}
+
+// decodeUTF16 converts bs from UTF-16 to UTF-8. A surrogate that is not
+// part of a pair is kept in the three bytes UTF-8 would encode it with,
+// which are not valid UTF-8, and a final odd byte either as it is, if it is
+// not valid UTF-8 on its own, or in the two bytes of an overlong encoding.
+// encodeUTF16 turns both back into what they were.
+func decodeUTF16(bs []byte, bigEndian bool) []byte {
+	unit := func(i int) rune {
+		if bigEndian {
+			return rune(bs[i])<<8 | rune(bs[i+1])
+		}
+		return rune(bs[i]) | rune(bs[i+1])<<8
+	}
+	out := make([]byte, 0, len(bs))
+	var buf [utf8.UTFMax]byte
+	appendRune := func(r rune) {
+		out = append(out, buf[:utf8.EncodeRune(buf[:], r)]...)
+	}
+	i := 0
+	for ; i+1 < len(bs); i += 2 {
+		r := unit(i)
+		if utf16.IsSurrogate(r) && r < 0xdc00 && i+3 < len(bs) {
+			if pair := utf16.DecodeRune(r, unit(i+2)); pair != utf8.RuneError {
+				appendRune(pair)
+				i += 2
+				continue
+			}
+		}
+		if utf16.IsSurrogate(r) {
+			out = append(out, 0xe0|byte(r>>12), 0x80|byte(r>>6)&0x3f, 0x80|byte(r)&0x3f)
+			continue
+		}
+		appendRune(r)
+	}
+	if i < len(bs) {
+		if c := bs[i]; c < utf8.RuneSelf {
+			out = append(out, 0xc0|c>>6, 0x80|c&0x3f)
+		} else {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// encodeUTF16 appends text in UTF-16 to out, turning what decodeUTF16 kept
+// of surrogates and odd bytes back into them. Other bytes that are not
+// valid UTF-8 are written as they are.
+func encodeUTF16(out []byte, text string, bigEndian bool) []byte {
+	appendUnit := func(u uint16) {
+		if bigEndian {
+			out = append(out, byte(u>>8), byte(u))
+		} else {
+			out = append(out, byte(u), byte(u>>8))
+		}
+	}
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r != utf8.RuneError || size > 1:
+			r1, r2 := utf16.EncodeRune(r)
+			if r1 == utf8.RuneError {
+				appendUnit(uint16(r))
+			} else {
+				appendUnit(uint16(r1))
+				appendUnit(uint16(r2))
+			}
+		case isEncodedSurrogate(text[i:]):
+			appendUnit(uint16(text[i]&0x0f)<<12 | uint16(text[i+1]&0x3f)<<6 | uint16(text[i+2]&0x3f))
+			size = 3
+		case i+1 < len(text) && text[i]&0xfe == 0xc0 && text[i+1]&0xc0 == 0x80:
+			out = append(out, text[i]<<6|text[i+1]&0x3f)
+			size = 2
+		default:
+			out = append(out, text[i])
+		}
+		i += size
+	}
+	return out
+}
+
+// isEncodedSurrogate reports whether s starts with a surrogate encoded like
+// a rune in UTF-8.
+func isEncodedSurrogate(s string) bool {
+	return len(s) >= 3 && s[0] == 0xed && s[1]&0xe0 == 0xa0 && s[2]&0xc0 == 0x80
+}
+
+func encodeSingleByte(r rune, encoding string) (byte, bool) {
+	if encoding == encodingCP1252 {
+		for i, c := range cp1252 {
+			if c == r {
+				return byte(0x80 + i), true
+			}
+		}
+		if r >= 0x80 && r < 0xa0 {
+			return 0, false
+		}
+	}
+	if r < 0 || r > 0xff {
+		return 0, false
+	}
+	return byte(r), true
+}
+
+// decodeFile decodes the contents of a file, which are in encoding or, if
+// that is empty, in the encoding they appear to be in, and splits them into
+// lines. The encoding, byte order mark and line endings are remembered for
+// when the buffer is written. Empty contents take the global fileencoding
+// and bomb, contents without a line ending the global fileformat.
+func (f *SimpleFrame) decodeFile(bs []byte, encoding string) []string {
+	bom := false
+	if encoding == "" && len(bs) == 0 {
+		encoding, bom = f.globalOption("fileencoding").s, f.globalOption("bomb").b
+	} else if encoding == "" {
+		encoding, bom = detectEncoding(bs)
+	} else {
+		bom = bytes.HasPrefix(bs, byteOrderMark(encoding))
+	}
+	text := decode(bs, encoding, bom)
+	f.fileEncoding = encoding
+	f.bomb = bom
+	f.fileFormat = detectFileFormat(text)
+	if !bytes.ContainsAny(text, "\r\n") {
+		f.fileFormat = f.globalOption("fileformat").s
+	}
+	return splitLines(text, f.fileFormat)
+}
+
+// encodeFile returns the contents of the file the buffer is written to,
+// which ends with a line ending if fixendofline is set.
+func (f *SimpleFrame) encodeFile() ([]byte, error) {
+	encoding := f.fileEncoding
+	if encoding == "" {
+		encoding = encodingUTF8
+	}
+	lines := f.buffer.lines()
+	if f.fixEndOfLine && lines[len(lines)-1] != "" {
+		lines = append(lines[:len(lines):len(lines)], "")
+	}
+	return encode(joinLines(lines, f.fileFormat), encoding, f.bomb && byteOrderMark(encoding) != nil)
+}
+
+func checkFileEncoding(v *optionValue) bool {
+	v.s = normalizeEncoding(v.s)
+	return v.s != ""
+}
+
+func (f *SimpleFrame) setFileEncoding(v optionValue) {
+	if v.s != f.fileEncoding {
+		f.fileEncoding = v.s
+		f.fileOptionChanged()
+	}
+}
+
+func (f *SimpleFrame) setBomb(v optionValue) {
+	if v.b != f.bomb {
+		f.bomb = v.b
+		f.fileOptionChanged()
+	}
+}
+
+// fileOptionChanged marks the buffer modified after an option changed how
+// it is written, unless it is an empty buffer without a file, such as the
+// one the configuration file is run for.
+func (f *SimpleFrame) fileOptionChanged() {
+	if f.filePath != "" || f.buffer.len() > 1 || f.buffer.len() == 1 && f.buffer.line(0) != "" {
+		f.modified = true
+	}
+}
+
+// globalOption returns the global value of the option called name.
+func (f *SimpleFrame) globalOption(name string) optionValue {
+	o, _ := findOption(name)
+	return f.optionValue(o, globalValue)
+}
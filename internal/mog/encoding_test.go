@@ -0,0 +1,124 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_detectEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		encoding string
+		bom      bool
+	}{
+		{"ascii", "abc", encodingUTF8, false},
+		{"utf-8", "gr\xc3\xbc\xc3\x9f", encodingUTF8, false},
+		{"utf-8 with bom", "\xef\xbb\xbfabc", encodingUTF8, true},
+		{"utf-8 with invalid bytes", "\xc3\xbc\xff", encodingUTF8, false},
+		{"utf-16le", "\xff\xfea\x00", encodingUTF16LE, true},
+		{"utf-16be", "\xfe\xff\x00a", encodingUTF16BE, true},
+		{"windows-1252", "caf\xe9 \x80", encodingCP1252, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, bom := detectEncoding([]byte(tt.contents))
+			assert.EqualValues(t, tt.encoding, encoding)
+			assert.EqualValues(t, tt.bom, bom)
+		})
+	}
+}
+
+func Test_decode_encode(t *testing.T) {
+	tests := []struct {
+		encoding string
+		bom      bool
+		encoded  string
+		decoded  string
+	}{
+		{encodingUTF8, false, "a\xffb", "a\xffb"},
+		{encodingUTF8, true, "\xef\xbb\xbfa", "a"},
+		{encodingUTF16LE, true, "\xff\xfea\x00\xe9\x00=\xd8\x00\xde", "aé😀"},
+		{encodingUTF16BE, false, "\x00a\x00\xe9", "aé"},
+		{encodingUTF16LE, false, "=\xd8a\x00\x00\xdc", "\xed\xa0\xbda\xed\xb0\x80"},
+		{encodingUTF16BE, false, "\x00a\x00", "a\xc0\x80"},
+		{encodingUTF16BE, false, "\x00a\xff", "a\xff"},
+		{encodingLatin1, false, "caf\xe9\x80", "café\u0080"},
+		{encodingCP1252, false, "caf\xe9\x80\x81", "café€\u0081"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.encoding, func(t *testing.T) {
+			decoded := decode([]byte(tt.encoded), tt.encoding, tt.bom)
+			assert.EqualValues(t, tt.decoded, string(decoded))
+			encoded, err := encode(tt.decoded, tt.encoding, tt.bom)
+			assert.Nil(t, err)
+			assert.EqualValues(t, tt.encoded, string(encoded))
+		})
+	}
+
+	_, err := encode("€", encodingLatin1, false)
+	assert.EqualError(t, err, "E513: Write error, conversion failed (make 'fenc' utf-8 to override)")
+}
+
+func TestSimpleFrame_loadFile_PreservesEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		buffer   []string
+	}{
+		{"utf-8 with bom", "\xef\xbb\xbfa\nb", []string{"a", "b"}},
+		{"utf-16le", "\xff\xfea\x00\r\x00\n\x00\xe9\x00", []string{"a", "é"}},
+		{"utf-16le with stray bytes", "\xff\xfea\x00\x00\xd8b", []string{"a\xed\xa0\x80\xc1\xa2"}},
+		{"windows-1252", "caf\xe9\n\x80", []string{"café", "€"}},
+		{"invalid utf-8", "\xc3\xbc\xff\nb", []string{"ü\xff", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "file.txt")
+			assert.Nil(t, os.WriteFile(path, []byte(tt.contents), 0o644))
+			f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+			assert.Nil(t, f.loadFile(path))
+			assert.EqualValues(t, tt.buffer, f.buffer.lines())
+
+			assert.Nil(t, f.Execute("w!"))
+			bs, err := os.ReadFile(path)
+			assert.Nil(t, err)
+			assert.EqualValues(t, tt.contents, string(bs))
+			assert.Nil(t, f.Close())
+		})
+	}
+}
+
+func TestSimpleFrame_setFileEncoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("caf\xc3\xa9"), 0o644))
+	f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+	assert.Nil(t, f.loadFile(path))
+
+	assert.EqualError(t, f.Execute("set fenc=ebcdic"), "E474: Invalid argument: fileencoding=ebcdic")
+	assert.Nil(t, f.Execute("set fenc=latin-1 bomb"))
+	assert.EqualValues(t, encodingLatin1, f.fileEncoding)
+	assert.True(t, f.modified)
+	assert.Nil(t, f.Execute("w"))
+	bs, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "caf\xe9", string(bs), "latin1 has no byte order mark")
+	assert.Nil(t, f.Close())
+}
+
+func TestSimpleFrame_exEdit_WithEncoding(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("caf\xc3\xa9"), 0o644))
+	f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+	assert.Nil(t, f.loadFile(path))
+	assert.EqualValues(t, []string{"café"}, f.buffer.lines())
+
+	assert.EqualError(t, f.Execute("e ++enc=klingon"), "E474: Invalid argument: ++enc=klingon")
+	assert.Nil(t, f.Execute("e ++enc=latin1"))
+	assert.EqualValues(t, []string{"cafÃ©"}, f.buffer.lines())
+	assert.EqualValues(t, encodingLatin1, f.fileEncoding)
+	assert.Nil(t, f.Close())
+}
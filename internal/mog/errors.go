@@ -0,0 +1,105 @@
+package mog
+
+import "fmt"
+
+// editorError is an error that is reported to the user with a number, e.g.
+// "E37: No write since last change", like the errors of vi.
+type editorError struct {
+	code int
+	text string
+}
+
+func (e *editorError) Error() string {
+	return fmt.Sprintf("E%d: %s", e.code, e.text)
+}
+
+// editorErrorf returns an editorError with the given number and formatted
+// text.
+func editorErrorf(code int, format string, a ...interface{}) error {
+	return &editorError{code: code, text: fmt.Sprintf(format, a...)}
+}
+
+var (
+	errNoWriteSinceLastChange = editorErrorf(37, "No write since last change (add ! to override)")
+	errNoFileName             = editorErrorf(32, "No file name")
+	errReadonly               = editorErrorf(45, "'readonly' option is set (add ! to override)")
+)
+
+// message is a line shown on the bottom line of the screen.
+type message struct {
+	text    string
+	isError bool
+}
+
+// maxMessages is the number of messages kept for :messages.
+const maxMessages = 200
+
+// showMessage shows text on the bottom line until the next key is pressed
+// and adds it to the message history.
+func (f *SimpleFrame) showMessage(text string) {
+	f.addMessage(message{text: text})
+}
+
+// showError shows err on the bottom line in the ErrorMsg highlight group
+// and adds it to the message history.
+func (f *SimpleFrame) showError(err error) {
+	f.addMessage(message{text: err.Error(), isError: true})
+}
+
+func (f *SimpleFrame) addMessage(m message) {
+	f.message = m
+	f.messages = append(f.messages, m)
+	if len(f.messages) > maxMessages {
+		f.messages = f.messages[len(f.messages)-maxMessages:]
+	}
+}
+
+// exMessages implements :messages, which shows the messages shown so far
+// above the bottom line. With the argument clear it forgets them instead.
+func (f *SimpleFrame) exMessages(args string) error {
+	switch args {
+	case "":
+	case "clear":
+		f.messages = nil
+		return nil
+	default:
+		return errTrailingCharacters(args)
+	}
+	f.listMessages = len(f.messages) > 0
+	return nil
+}
+
+// bottomMessages returns the messages shown at the bottom of the screen,
+// which take up more than the bottom line while :messages lists them.
+func (f *SimpleFrame) bottomMessages() []message {
+	_, h := f.screen.Size()
+	switch {
+	case f.prompt != nil:
+		return lastMessages(f.prompt.lines, h)
+	case f.mode == ModeCommand:
+		if f.searching {
+			return []message{{text: "/" + f.cmdline}}
+		}
+		return []message{{text: ":" + f.cmdline}}
+	case f.listMessages:
+		return lastMessages(f.messages, h)
+	case len(f.listing) > 0:
+		return lastMessages(f.listing, h)
+	case f.message.text != "":
+		return []message{f.message}
+	default:
+		return nil
+	}
+}
+
+// lastMessages returns the last n messages of msgs.
+func lastMessages(msgs []message, n int) []message {
+	if len(msgs) > n {
+		return msgs[len(msgs)-n:]
+	}
+	return msgs
+}
+
+func errTrailingCharacters(args string) error {
+	return editorErrorf(488, "Trailing characters: %s", args)
+}
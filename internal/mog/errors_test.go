@@ -0,0 +1,87 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleFrame_exQuit_RefusesToDropChanges(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(1), 20, 5)
+	f.InsertText("x")
+
+	err := f.Execute("q")
+	assert.EqualError(t, err, "E37: No write since last change (add ! to override)")
+	assert.False(t, f.closed)
+
+	assert.EqualError(t, f.Execute("q now"), "E488: Trailing characters: now")
+	assert.Nil(t, f.Execute("q!"))
+	assert.True(t, f.closed)
+}
+
+func TestSimpleFrame_exWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	f, _ := newTestFrame(t, numberedLines(1), 40, 5)
+	f.InsertText("x\ny\n")
+
+	assert.EqualError(t, f.Execute("w"), "E32: No file name")
+	assert.True(t, f.modified)
+
+	assert.Nil(t, f.Execute("w "+path))
+	assert.False(t, f.modified)
+	assert.EqualValues(t, path, f.filePath)
+	bs, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "x\ny\n0", string(bs))
+	assert.EqualValues(t, "\""+path+"\" 3L, 5B written", f.bottomLine())
+
+	f.InsertText("z")
+	assert.Nil(t, f.Execute("wq"))
+	assert.True(t, f.closed)
+	bs, err = os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "x\ny\nz0", string(bs))
+}
+
+func TestSimpleFrame_Show_ErrorsUseErrorMsg(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"ab"}, 10, 3)
+	for _, r := range ":frob" {
+		f.handleEventKey(*NewEventKey(KeyRune, r, ModNone))
+	}
+	f.handleEventKey(*NewEventKey(KeyEnter, 0, ModNone))
+	f.Show()
+
+	cells, w, _ := ss.GetContents()
+	assert.EqualValues(t, "E492: Not an editor command: frob", f.bottomLine())
+	assert.EqualValues(t, f.style(GroupErrorMsg), cells[2*w].Style)
+
+	f.handleEventKey(*NewEventKey(KeyRune, 'l', ModNone))
+	f.Show()
+	cells, _, _ = ss.GetContents()
+	assert.EqualValues(t, f.style(GroupStatusLine), cells[2*w].Style)
+}
+
+func TestSimpleFrame_exMessages(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"ab", "cd", "ef"}, 10, 4)
+	f.showMessage("one")
+	f.showError(errNoFileName)
+	f.handleEventKey(*NewEventKey(KeyRune, 'l', ModNone))
+
+	assert.Nil(t, f.Execute("messages"))
+	f.Show()
+	assert.EqualValues(t, []string{"ab        ", "cd        ", "one       ", "E32: No fi"}, screenContents(ss))
+	cells, w, _ := ss.GetContents()
+	assert.EqualValues(t, f.style(GroupNormal), cells[2*w].Style)
+	assert.EqualValues(t, f.style(GroupErrorMsg), cells[3*w].Style)
+
+	// The next key hides the messages again
+	f.handleEventKey(*NewEventKey(KeyRune, 'h', ModNone))
+	f.Show()
+	assert.EqualValues(t, []string{"ab        ", "cd        ", "ef        ", " -- Normal"}, screenContents(ss))
+
+	assert.Nil(t, f.Execute("mes clear"))
+	assert.Nil(t, f.Execute("mes"))
+	assert.False(t, f.listMessages)
+}
@@ -0,0 +1,248 @@
+package mog
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event is something that happened that a frame reacts to, e.g. a key that
+// was pressed. Screens deliver the events of their front-end, and the
+// editor posts its own events to them.
+type Event interface {
+	When() time.Time
+}
+
+// EventTime is embedded in events to implement Event.
+type EventTime struct {
+	when time.Time
+}
+
+// When returns when the event happened.
+func (e *EventTime) When() time.Time {
+	return e.when
+}
+
+// SetEventNow records that the event happened now.
+func (e *EventTime) SetEventNow() {
+	e.when = time.Now()
+}
+
+// Key is a key on the keyboard. The control keys are the ASCII control
+// characters they produce, other special keys lie above the range of
+// characters. KeyRune stands for any key that types a rune.
+type Key int16
+
+const (
+	KeyCtrlA Key = iota + 1
+	KeyCtrlB
+	KeyCtrlC
+	KeyCtrlD
+	KeyCtrlE
+	KeyCtrlF
+	KeyCtrlG
+	KeyCtrlH
+	KeyCtrlI
+	KeyCtrlJ
+	KeyCtrlK
+	KeyCtrlL
+	KeyCtrlM
+	KeyCtrlN
+	KeyCtrlO
+	KeyCtrlP
+	KeyCtrlQ
+	KeyCtrlR
+	KeyCtrlS
+	KeyCtrlT
+	KeyCtrlU
+	KeyCtrlV
+	KeyCtrlW
+	KeyCtrlX
+	KeyCtrlY
+	KeyCtrlZ
+	KeyEscape
+)
+
+const (
+	KeyBackspace  = KeyCtrlH
+	KeyTab        = KeyCtrlI
+	KeyLF         = KeyCtrlJ
+	KeyEnter      = KeyCtrlM
+	KeyBackspace2 = Key(0x7f)
+)
+
+const (
+	KeyRune Key = iota + 256
+	KeyUp
+	KeyDown
+	KeyRight
+	KeyLeft
+	KeyHome
+	KeyEnd
+	KeyPgUp
+	KeyPgDn
+	KeyInsert
+	KeyDelete
+)
+
+var keyNames = map[Key]string{
+	KeyBackspace:  "Backspace",
+	KeyTab:        "Tab",
+	KeyEnter:      "Enter",
+	KeyEscape:     "Esc",
+	KeyBackspace2: "Backspace2",
+	KeyUp:         "Up",
+	KeyDown:       "Down",
+	KeyRight:      "Right",
+	KeyLeft:       "Left",
+	KeyHome:       "Home",
+	KeyEnd:        "End",
+	KeyPgUp:       "PgUp",
+	KeyPgDn:       "PgDn",
+	KeyInsert:     "Insert",
+	KeyDelete:     "Delete",
+}
+
+// ModMask is the set of modifier keys held down with a key or mouse button.
+type ModMask int16
+
+const (
+	ModShift ModMask = 1 << iota
+	ModCtrl
+	ModAlt
+	ModNone ModMask = 0
+)
+
+// EventKey is a key that was pressed.
+type EventKey struct {
+	EventTime
+	key Key
+	ch  rune
+	mod ModMask
+}
+
+// NewEventKey returns the event of pressing k, or typing ch if k is KeyRune.
+// A control character typed as a rune is turned into its key.
+func NewEventKey(k Key, ch rune, mod ModMask) *EventKey {
+	if k == KeyRune && (ch < ' ' || ch == 0x7f) {
+		k, ch = Key(ch), 0
+	}
+	ev := &EventKey{key: k, ch: ch, mod: mod}
+	ev.SetEventNow()
+	return ev
+}
+
+// Key returns the key that was pressed.
+func (ev *EventKey) Key() Key {
+	return ev.key
+}
+
+// Rune returns the rune that was typed if Key is KeyRune.
+func (ev *EventKey) Rune() rune {
+	return ev.ch
+}
+
+// Modifiers returns the modifier keys that were held down.
+func (ev *EventKey) Modifiers() ModMask {
+	return ev.mod
+}
+
+// Name describes the key, e.g. "Rune[a]", "Esc" or "Ctrl-R".
+func (ev *EventKey) Name() string {
+	switch name, ok := keyNames[ev.key]; {
+	case ev.key == KeyRune:
+		return fmt.Sprintf("Rune[%c]", ev.ch)
+	case ok:
+		return name
+	case KeyCtrlA <= ev.key && ev.key <= KeyCtrlZ:
+		return fmt.Sprintf("Ctrl-%c", 'A'+rune(ev.key-KeyCtrlA))
+	default:
+		return fmt.Sprintf("Key[%d]", ev.key)
+	}
+}
+
+// ButtonMask is the set of mouse buttons that are pressed. The wheel counts
+// as buttons that are pressed for every step it is turned.
+type ButtonMask int16
+
+const (
+	Button1 ButtonMask = 1 << iota
+	Button2
+	Button3
+	WheelUp
+	WheelDown
+	ButtonNone ButtonMask = 0
+)
+
+// EventMouse is the mouse moving or a button being pressed or released.
+type EventMouse struct {
+	EventTime
+	x, y    int
+	buttons ButtonMask
+	mod     ModMask
+}
+
+// NewEventMouse returns the event of the mouse being at x, y with buttons
+// pressed.
+func NewEventMouse(x, y int, buttons ButtonMask, mod ModMask) *EventMouse {
+	ev := &EventMouse{x: x, y: y, buttons: buttons, mod: mod}
+	ev.SetEventNow()
+	return ev
+}
+
+// Position returns the cell the mouse is over.
+func (ev *EventMouse) Position() (x, y int) {
+	return ev.x, ev.y
+}
+
+// Buttons returns the buttons that are pressed.
+func (ev *EventMouse) Buttons() ButtonMask {
+	return ev.buttons
+}
+
+// Modifiers returns the modifier keys that were held down.
+func (ev *EventMouse) Modifiers() ModMask {
+	return ev.mod
+}
+
+// EventPaste marks the start or the end of text being pasted. The text
+// arrives as key events in between.
+type EventPaste struct {
+	EventTime
+	start bool
+}
+
+// NewEventPaste returns the event of pasting starting, or ending if start is
+// false.
+func NewEventPaste(start bool) *EventPaste {
+	ev := &EventPaste{start: start}
+	ev.SetEventNow()
+	return ev
+}
+
+// Start reports whether pasting starts.
+func (ev *EventPaste) Start() bool {
+	return ev.start
+}
+
+// End reports whether pasting ends.
+func (ev *EventPaste) End() bool {
+	return !ev.start
+}
+
+// EventResize is the screen changing its size.
+type EventResize struct {
+	EventTime
+	w, h int
+}
+
+// NewEventResize returns the event of the screen changing to w by h cells.
+func NewEventResize(w, h int) *EventResize {
+	ev := &EventResize{w: w, h: h}
+	ev.SetEventNow()
+	return ev
+}
+
+// Size returns the new size of the screen.
+func (ev *EventResize) Size() (w, h int) {
+	return ev.w, ev.h
+}
@@ -0,0 +1,242 @@
+package mog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// exCommand is a command that can be run from the command line. args holds
+// everything following the name of the command, with surrounding white
+// space removed.
+type exCommand struct {
+	name string
+	// short is the shortest abbreviation of name that is accepted.
+	short string
+	run   func(f *SimpleFrame, args string) error
+}
+
+var exCommands []exCommand
+
+func init() {
+	exCommands = []exCommand{
+		{name: "args", short: "ar", run: (*SimpleFrame).exArgs},
+		{name: "autocmd", short: "au", run: (*SimpleFrame).exAutocmd},
+		{name: "cmap", short: "cm", run: exMap(mapCommand, false)},
+		{name: "cnoremap", short: "cno", run: exMap(mapCommand, true)},
+		{name: "colorscheme", short: "colo", run: (*SimpleFrame).exColorScheme},
+		{name: "cunmap", short: "cu", run: exUnmap(mapCommand)},
+		{name: "edit", short: "e", run: (*SimpleFrame).exEdit},
+		{name: "format", short: "form", run: (*SimpleFrame).exFormat},
+		{name: "hex", short: "hex", run: (*SimpleFrame).exHex},
+		{name: "highlight", short: "hi", run: (*SimpleFrame).exHighlight},
+		{name: "imap", short: "im", run: exMap(mapInsert, false)},
+		{name: "inoremap", short: "ino", run: exMap(mapInsert, true)},
+		{name: "iunmap", short: "iu", run: exUnmap(mapInsert)},
+		{name: "map", short: "map", run: exMap(mapNormal|mapVisual, false)},
+		{name: "messages", short: "mes", run: (*SimpleFrame).exMessages},
+		{name: "next", short: "n", run: (*SimpleFrame).exNext},
+		{name: "nmap", short: "nm", run: exMap(mapNormal, false)},
+		{name: "nnoremap", short: "nn", run: exMap(mapNormal, true)},
+		{name: "noremap", short: "no", run: exMap(mapNormal|mapVisual, true)},
+		{name: "nunmap", short: "nun", run: exUnmap(mapNormal)},
+		{name: "previous", short: "prev", run: (*SimpleFrame).exPrevious},
+		{name: "quit", short: "q", run: (*SimpleFrame).exQuit},
+		{name: "set", short: "se", run: (*SimpleFrame).exSet},
+		{name: "setglobal", short: "setg", run: (*SimpleFrame).exSetGlobal},
+		{name: "setlocal", short: "setl", run: (*SimpleFrame).exSetLocal},
+		{name: "source", short: "so", run: (*SimpleFrame).exSource},
+		{name: "unmap", short: "unm", run: exUnmap(mapNormal | mapVisual)},
+		{name: "vmap", short: "vm", run: exMap(mapVisual, false)},
+		{name: "vnoremap", short: "vn", run: exMap(mapVisual, true)},
+		{name: "vunmap", short: "vu", run: exUnmap(mapVisual)},
+		{name: "write", short: "w", run: (*SimpleFrame).exWrite},
+		{name: "wq", short: "wq", run: (*SimpleFrame).exWriteQuit},
+	}
+}
+
+// findExCommand returns the command with the given name or abbreviation.
+func findExCommand(name string) (*exCommand, error) {
+	for i, c := range exCommands {
+		if strings.HasPrefix(c.name, name) && len(name) >= len(c.short) {
+			return &exCommands[i], nil
+		}
+	}
+	return nil, errNotAnEditorCommand(name)
+}
+
+// Execute runs a command line, e.g. "colorscheme dark", as if it had been
+// typed after a ':'. A line number or $ moves the cursor to that line and
+// /{pattern} searches for pattern.
+func (f *SimpleFrame) Execute(line string) error {
+	line = strings.TrimLeft(line, " \t:")
+	switch {
+	case line == "":
+		return nil
+	case line[0] == '/':
+		return f.search(line[1:])
+	case line[0] == '$' || '0' <= line[0] && line[0] <= '9':
+		return f.goToLine(line)
+	}
+	end := strings.IndexFunc(line, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z')
+	})
+	if end < 0 {
+		end = len(line)
+	}
+	if end == 0 {
+		return errNotAnEditorCommand(line)
+	}
+	c, err := findExCommand(line[:end])
+	if err != nil {
+		return err
+	}
+	return c.run(f, strings.TrimSpace(line[end:]))
+}
+
+func errNotAnEditorCommand(name string) error {
+	return editorErrorf(492, "Not an editor command: %s", name)
+}
+
+// exQuit implements :quit, which refuses to throw away changes that were
+// not written unless it is followed by a '!'.
+func (f *SimpleFrame) exQuit(args string) error {
+	switch {
+	case args == "!":
+	case args != "":
+		return errTrailingCharacters(args)
+	case f.modified:
+		return errNoWriteSinceLastChange
+	}
+	f.closed = true
+	return nil
+}
+
+// exWrite implements :write, which writes the buffer to the file it was
+// loaded from or to the file named by its argument. A buffer without a file
+// is given the file it is first written to.
+func (f *SimpleFrame) exWrite(args string) error {
+	force := strings.HasPrefix(args, "!")
+	path := strings.TrimSpace(strings.TrimPrefix(args, "!"))
+	if path == "" {
+		path = f.filePath
+	}
+	if path == "" {
+		return errNoFileName
+	}
+	if f.readonly && path == f.filePath && !force {
+		return errReadonly
+	}
+	f.fireAutocmds(BufWritePre, path)
+	if f.trimTrailingWhitespace {
+		f.trimLines()
+	}
+	size, err := f.writeBuffer(path)
+	if err != nil {
+		return err
+	}
+	if f.filePath == "" {
+		f.filePath = path
+		f.startWatching()
+	}
+	if path == f.filePath {
+		f.modified = false
+		if f.hex != nil {
+			f.hex.saved = len(f.hex.undo)
+		}
+		if info, err := os.Stat(path); err == nil {
+			f.fileInfo = info
+		}
+		f.updateSwapFile()
+	}
+	f.fireAutocmds(BufWritePost, path)
+	f.showMessage(fmt.Sprintf("\"%s\" %dL, %dB written", path, f.buffer.len(), size))
+	return nil
+}
+
+// trimLines removes the white space at the end of every line, as one
+// change. The lines of large files and the hex view are left alone.
+func (f *SimpleFrame) trimLines() {
+	if f.lazy != nil || f.hex != nil {
+		return
+	}
+	began := false
+	for y, line := range f.buffer.lines() {
+		trimmed := strings.TrimRight(line, " \t")
+		if trimmed == line {
+			continue
+		}
+		if !began {
+			f.undo.begin(f.cursorPos())
+			began = true
+		}
+		f.replaceLines(y, 1, []string{trimmed})
+	}
+	if began {
+		f.undo.end()
+		f.moveCursorTo(f.cursorPos())
+	}
+}
+
+// writeBuffer writes the buffer to path and returns the number of bytes
+// written.
+func (f *SimpleFrame) writeBuffer(path string) (int64, error) {
+	if f.hex != nil {
+		return f.writeHexFile(path)
+	}
+	if f.lazy != nil {
+		n, err := f.writeLargeFile(path)
+		if _, ok := err.(*editorError); err != nil && !ok {
+			return n, editorErrorf(212, "Can't open file for writing: %v", err)
+		}
+		return n, err
+	}
+	data, err := f.encodeFile()
+	if err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return 0, editorErrorf(212, "Can't open file for writing: %v", err)
+	}
+	return int64(len(data)), nil
+}
+
+// exWriteQuit implements :wq.
+func (f *SimpleFrame) exWriteQuit(args string) error {
+	if err := f.exWrite(args); err != nil {
+		return err
+	}
+	f.closed = true
+	return nil
+}
+
+// handleCommandKey handles a key typed on the command line.
+func (f *SimpleFrame) handleCommandKey(ev EventKey) {
+	switch ev.Key() {
+	case KeyEscape:
+		f.mode = ModeNormal
+	case KeyEnter:
+		f.mode = ModeNormal
+		run, prefix := f.Execute, ":"
+		if f.searching {
+			run, prefix = f.search, "/"
+		}
+		if err := run(f.cmdline); err != nil {
+			f.showError(err)
+		} else if f.mapped != nil && !f.mapped.silent && f.message.text == "" {
+			// The command line a mapping ran stays visible, unless
+			// the mapping is silent.
+			f.message = message{text: prefix + f.cmdline}
+		}
+	case KeyBackspace, KeyBackspace2:
+		if f.cmdline == "" {
+			f.mode = ModeNormal
+			return
+		}
+		_, size := utf8.DecodeLastRuneInString(f.cmdline)
+		f.cmdline = f.cmdline[:len(f.cmdline)-size]
+	case KeyRune:
+		f.cmdline += string(ev.Rune())
+	}
+}
@@ -2,10 +2,12 @@ package mog
 
 import "path"
 
-func lockFilePathOf(filePath string) string {
+// swapFilePathOf returns the path of the swap file of the file at filePath,
+// a hidden file next to it.
+func swapFilePathOf(filePath string) string {
 	filename := path.Base(filePath)
 	dir := path.Dir(filePath)
-	lockFileName := ".#" + filename
-	lockFilePath := path.Join(dir, lockFileName)
-	return lockFilePath
+	swapFileName := "." + filename + swapFileSuffix
+	swapFilePath := path.Join(dir, swapFileName)
+	return swapFilePath
 }
@@ -2,7 +2,7 @@ package mog
 
 import "testing"
 
-func Test_lockFilePathOf(t *testing.T) {
+func Test_swapFilePathOf(t *testing.T) {
 	type args struct {
 		filePath string
 	}
@@ -14,19 +14,19 @@ func Test_lockFilePathOf(t *testing.T) {
 		{
 			name: "same directory",
 			args: args{"file.txt"},
-			want: ".#file.txt",
+			want: ".file.txt.swp",
 		},
 		{
 			name: "different directory",
 			args: args{"some/path/file.txt"},
-			want: "some/path/.#file.txt",
+			want: "some/path/.file.txt.swp",
 		},
 		// TODO: Add test cases.
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := lockFilePathOf(tt.args.filePath); got != tt.want {
-				t.Errorf("lockFilePathOf() = %v, want %v", got, tt.want)
+			if got := swapFilePathOf(tt.args.filePath); got != tt.want {
+				t.Errorf("swapFilePathOf() = %v, want %v", got, tt.want)
 			}
 		})
 	}
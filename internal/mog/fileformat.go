@@ -0,0 +1,65 @@
+package mog
+
+import (
+	"bytes"
+	"strings"
+)
+
+// The file formats, which differ in how lines end: with "\n", "\r\n" or "\r".
+const (
+	fileFormatUnix = "unix"
+	fileFormatDos  = "dos"
+	fileFormatMac  = "mac"
+)
+
+// detectFileFormat returns the file format of bs. A file is in dos format if
+// every "\n" follows a "\r", and in mac format if it only contains "\r".
+// Files without line endings are taken to be in unix format.
+func detectFileFormat(bs []byte) string {
+	lf := bytes.Count(bs, []byte("\n"))
+	switch {
+	case lf > 0 && bytes.Count(bs, []byte("\r\n")) == lf:
+		return fileFormatDos
+	case lf == 0 && bytes.IndexByte(bs, '\r') >= 0:
+		return fileFormatMac
+	default:
+		return fileFormatUnix
+	}
+}
+
+func lineEnding(format string) string {
+	switch format {
+	case fileFormatDos:
+		return "\r\n"
+	case fileFormatMac:
+		return "\r"
+	default:
+		return "\n"
+	}
+}
+
+// splitLines splits the contents of a file in the given format into lines.
+func splitLines(bs []byte, format string) []string {
+	return strings.Split(string(bs), lineEnding(format))
+}
+
+// joinLines returns the contents of a file in the given format with the
+// given lines.
+func joinLines(lines []string, format string) string {
+	return strings.Join(lines, lineEnding(format))
+}
+
+func checkFileFormat(v *optionValue) bool {
+	switch v.s {
+	case fileFormatUnix, fileFormatDos, fileFormatMac:
+		return true
+	}
+	return false
+}
+
+func (f *SimpleFrame) setFileFormat(v optionValue) {
+	if v.s != f.fileFormat {
+		f.fileFormat = v.s
+		f.fileOptionChanged()
+	}
+}
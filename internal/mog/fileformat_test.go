@@ -0,0 +1,81 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_detectFileFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		expected string
+	}{
+		{"empty", "", fileFormatUnix},
+		{"single line", "abc", fileFormatUnix},
+		{"unix", "a\nb\n", fileFormatUnix},
+		{"dos", "a\r\nb\r\n", fileFormatDos},
+		{"mac", "a\rb\r", fileFormatMac},
+		{"mixed", "a\r\nb\n", fileFormatUnix},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.EqualValues(t, tt.expected, detectFileFormat([]byte(tt.contents)))
+		})
+	}
+}
+
+func TestSimpleFrame_loadFile_PreservesLineEndings(t *testing.T) {
+	for _, contents := range []string{"a\nb\n", "a\r\nb\r\n", "a\rb\r", "a\r\nb\n"} {
+		path := filepath.Join(t.TempDir(), "file.txt")
+		assert.Nil(t, os.WriteFile(path, []byte(contents), 0o644))
+		f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+		assert.Nil(t, f.loadFile(path))
+
+		if contents != "a\r\nb\n" {
+			assert.EqualValues(t, []string{"a", "b", ""}, f.buffer.lines())
+		}
+		assert.Nil(t, f.Execute("w!"))
+		bs, err := os.ReadFile(path)
+		assert.Nil(t, err)
+		assert.EqualValues(t, contents, string(bs))
+		assert.Nil(t, f.Close())
+	}
+}
+
+func TestSimpleFrame_setFileFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("a\r\nb"), 0o644))
+	f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+	assert.Nil(t, f.loadFile(path))
+	assert.EqualValues(t, fileFormatDos, f.fileFormat)
+
+	assert.EqualError(t, f.Execute("set ff=amiga"), "E474: Invalid argument: fileformat=amiga")
+	assert.Nil(t, f.Execute("set ff=unix"))
+	assert.True(t, f.modified)
+	assert.Nil(t, f.Execute("w"))
+	bs, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "a\nb", string(bs))
+	assert.Nil(t, f.Close())
+}
+
+func TestSimpleFrame_Show_FileFormatInStatusLine(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"ab"}, 20, 2)
+	f.mode = ModeInsert
+	f.fileFormat = fileFormatUnix
+	f.Show()
+	assert.EqualValues(t, " -- Insert --   unix", screenContents(ss)[1])
+
+	assert.Nil(t, f.Execute("set ff=dos"))
+	f.Show()
+	assert.EqualValues(t, " -- Insert --    dos", screenContents(ss)[1])
+
+	// Without room for it the file format is left out
+	ss.SetSize(15, 2)
+	f.Show()
+	assert.EqualValues(t, " -- Insert --  ", screenContents(ss)[1])
+}
@@ -0,0 +1,192 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// defaultTabStop is the default of tabstop and shiftwidth.
+const defaultTabStop = 8
+
+// fileTypesByName holds the types of files that are known by their name.
+var fileTypesByName = map[string]string{
+	"Dockerfile":  "dockerfile",
+	"GNUmakefile": "make",
+	"Makefile":    "make",
+	"makefile":    "make",
+	"go.mod":      "gomod",
+	"mogrc":       "mog",
+	".mogrc":      "mog",
+}
+
+// fileTypesByExtension holds the types of files that are known by their
+// extension.
+var fileTypesByExtension = map[string]string{
+	".bash":     "sh",
+	".c":        "c",
+	".cpp":      "cpp",
+	".css":      "css",
+	".go":       "go",
+	".h":        "c",
+	".html":     "html",
+	".java":     "java",
+	".js":       "javascript",
+	".json":     "json",
+	".lua":      "lua",
+	".markdown": "markdown",
+	".md":       "markdown",
+	".mk":       "make",
+	".mog":      "mog",
+	".py":       "python",
+	".rb":       "ruby",
+	".rs":       "rust",
+	".sh":       "sh",
+	".syntax":   "mogsyntax",
+	".toml":     "toml",
+	".ts":       "typescript",
+	".txt":      "text",
+	".yaml":     "yaml",
+	".yml":      "yaml",
+	".zsh":      "zsh",
+}
+
+// fileTypesByInterpreter holds the types of scripts that are known by the
+// interpreter their #! line names, without any version number.
+var fileTypesByInterpreter = map[string]string{
+	"bash":   "sh",
+	"dash":   "sh",
+	"lua":    "lua",
+	"node":   "javascript",
+	"perl":   "perl",
+	"python": "python",
+	"ruby":   "ruby",
+	"sh":     "sh",
+	"zsh":    "zsh",
+}
+
+// detectFileType returns the type of the file at path with the given
+// lines, or "" if it is not known. The name of the file wins over the #!
+// line of a script.
+func detectFileType(path string, lines []string) string {
+	name := filepath.Base(path)
+	if t, ok := fileTypesByName[name]; ok {
+		return t
+	}
+	if t, ok := fileTypesByExtension[strings.ToLower(filepath.Ext(name))]; ok {
+		return t
+	}
+	if len(lines) > 0 {
+		return fileTypesByInterpreter[interpreterOf(lines[0])]
+	}
+	return ""
+}
+
+// interpreterOf returns the name of the interpreter that the #! line line
+// runs, without any version number, e.g. python for
+// "#!/usr/bin/env python3", or "" if line is no #! line.
+func interpreterOf(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) > 0 && filepath.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		for len(fields) > 0 && strings.HasPrefix(fields[0], "-") {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimRight(filepath.Base(fields[0]), "0123456789.")
+}
+
+// ftpluginPath returns the path of the file with the ex commands that set
+// up buffers of the given type, in the ftplugin directory of the
+// configuration directory. Types that could name a file outside of it are
+// refused.
+func ftpluginPath(fileType string) (string, error) {
+	if !checkFileType(&optionValue{s: fileType}) {
+		return "", editorErrorf(474, "Invalid argument: filetype=%s", fileType)
+	}
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ftplugin", fileType+".mogrc"), nil
+}
+
+// loadFtplugin runs the ftplugin file of the file type, if there is one.
+// Every error in it is shown.
+func (f *SimpleFrame) loadFtplugin() {
+	path, err := ftpluginPath(f.fileType)
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	_ = f.sourceFile(path, f.showError)
+}
+
+// setFileType sets the file type, which picks the highlighter of that
+// name, if any, runs the ftplugin file of the type and then the FileType
+// autocmds, unless it is empty.
+func (f *SimpleFrame) setFileType(v optionValue) {
+	f.fileType = v.s
+	if f.fileType == "" {
+		return
+	}
+	if h, _ := highlighterNamed(f.fileType); h != nil && f.syntax != f.fileType {
+		f.setSyntax(optionValue{s: f.fileType})
+	}
+	f.loadFtplugin()
+	f.fireAutocmds(FileType, f.fileType)
+}
+
+// setSyntax highlights the buffer with the highlighter of the given name,
+// or not at all if it is empty. Large files are never highlighted.
+func (f *SimpleFrame) setSyntax(v optionValue) {
+	f.syntax = v.s
+	if f.lazy != nil {
+		return
+	}
+	h, _ := highlighterNamed(v.s)
+	f.highlights.setHighlighter(h)
+	f.damage.markAll()
+}
+
+// checkSyntax reports whether there is a highlighter of the given name.
+func checkSyntax(v *optionValue) bool {
+	h, _ := highlighterNamed(v.s)
+	return v.s == "" || h != nil
+}
+
+// detectFileTypeOfBuffer sets the filetype option to the type of the file
+// being edited. A filetype set by a modeline wins over the detected one.
+func (f *SimpleFrame) detectFileTypeOfBuffer() {
+	t := f.modelineFileType()
+	if t == "" {
+		t = detectFileType(f.filePath, f.buffer.slice(0, minInt(f.buffer.len(), 1)))
+	}
+	v := optionValue{s: t}
+	if t != "" && checkFileType(&v) {
+		f.setFileType(v)
+	} else if t != "" {
+		f.showError(editorErrorf(474, "Invalid argument: filetype=%s", t))
+	}
+}
+
+// checkFileType reports whether v is a file type, made up of letters,
+// digits, '_', '-' and '.'. Others could name a file outside the ftplugin
+// directory.
+func checkFileType(v *optionValue) bool {
+	for _, r := range v.s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("_-.", r) {
+			return false
+		}
+	}
+	return v.s != "." && v.s != ".."
+}
@@ -0,0 +1,127 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_detectFileType(t *testing.T) {
+	tests := []struct {
+		path  string
+		lines []string
+		want  string
+	}{
+		{"/src/main.go", nil, "go"},
+		{"/src/Makefile", nil, "make"},
+		{"config.YML", []string{"a: b"}, "yaml"},
+		{"notes", []string{"hello"}, ""},
+		{"build", []string{"#!/bin/bash", "make"}, "sh"},
+		{"run", []string{"#!/usr/bin/env -S python3.11 -u"}, "python"},
+		{"main.go", []string{"#!/bin/sh"}, "go"},
+	}
+	for _, tt := range tests {
+		assert.EqualValues(t, tt.want, detectFileType(tt.path, tt.lines), tt.path)
+	}
+}
+
+func TestSimpleFrame_loadFile_FileType(t *testing.T) {
+	dir := t.TempDir()
+	setEnv(t, "XDG_CONFIG_HOME", dir)
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "mog", "ftplugin"), 0o755))
+	plugin := "setlocal sw=2 et\nsetlocal cms=#\\ %s\nsetlocal nosuch\n"
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "mog", "ftplugin", "yaml.mogrc"), []byte(plugin), 0o644))
+	paths := writeTestFiles(t, "a: b", "package main")
+	yaml := filepath.Join(dir, "config.yaml")
+	assert.Nil(t, os.Rename(paths[0], yaml))
+	goFile := filepath.Join(dir, "main.go")
+	assert.Nil(t, os.Rename(paths[1], goFile))
+
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+	var fileTypes []string
+	f.Subscribe(FileType, "*", func(f *SimpleFrame, match string) error {
+		fileTypes = append(fileTypes, match+" "+f.commentString)
+		return nil
+	})
+
+	assert.Nil(t, f.loadFile(yaml))
+	assert.EqualValues(t, "yaml", f.fileType)
+	assert.EqualValues(t, 2, f.shiftWidth)
+	assert.True(t, f.expandTab)
+	assert.EqualValues(t, filepath.Join(dir, "mog", "ftplugin", "yaml.mogrc")+":3: E518: Unknown option: nosuch", f.message.text)
+
+	typeKeys(f, "i\t\x1b")
+	assert.EqualValues(t, "  a: b", f.buffer.line(0))
+
+	// The settings of the ftplugin file stay with the buffer.
+	assert.Nil(t, f.editFile(goFile, ""))
+	assert.EqualValues(t, "go", f.fileType)
+	assert.EqualValues(t, "go", f.syntax)
+	assert.EqualValues(t, defaultTabStop, f.shiftWidth)
+	assert.False(t, f.expandTab)
+	assert.EqualValues(t, []string{"yaml # %s", "go /* %s */"}, fileTypes)
+}
+
+func TestSimpleFrame_insertTab(t *testing.T) {
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+	f.loadBuffer([]byte("ab"))
+
+	typeKeys(f, "i\t")
+	assert.EqualValues(t, "\tab", f.buffer.line(0))
+
+	assert.Nil(t, f.Execute("set et sw=0 ts=4"))
+	typeKeys(f, "\t")
+	assert.EqualValues(t, "\t    ab", f.buffer.line(0))
+	f.cursor.MoveTo(6, 0)
+	typeKeys(f, "\t")
+	assert.EqualValues(t, "\t    a   b", f.buffer.line(0))
+	assert.EqualValues(t, 9, f.cursor.XPos())
+}
+
+func TestSimpleFrame_insertTab_ShiftWidth(t *testing.T) {
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+	f.loadBuffer([]byte("x"))
+	assert.Nil(t, f.Execute("set noet sw=4 ts=8"))
+
+	var lines []string
+	typeKeys(f, "i")
+	f.cursor.MoveTo(1, 0)
+	for i := 0; i < 3; i++ {
+		typeKeys(f, "\t")
+		lines = append(lines, f.buffer.line(0))
+	}
+	// Spaces up to a multiple of tabstop become a tab.
+	assert.EqualValues(t, []string{"x   ", "x\t", "x\t    "}, lines)
+	assert.EqualValues(t, 6, f.cursor.XPos())
+}
+
+func Test_interpreterOf(t *testing.T) {
+	tests := map[string]string{
+		"#!/bin/sh":                 "sh",
+		"#! /usr/bin/python3":       "python",
+		"#!/usr/bin/env node":       "node",
+		"#!/usr/bin/env":            "",
+		"# not an interpreter line": "",
+	}
+	for line, want := range tests {
+		assert.EqualValues(t, want, interpreterOf(line), line)
+	}
+}
+
+func Test_ftpluginPath(t *testing.T) {
+	config := t.TempDir()
+	setEnv(t, "XDG_CONFIG_HOME", config)
+
+	path, err := ftpluginPath("go")
+	assert.Nil(t, err)
+	assert.EqualValues(t, filepath.Join(config, "mog", "ftplugin", "go.mogrc"), path)
+	for _, fileType := range []string{"../x", "a/b", ".."} {
+		_, err := ftpluginPath(fileType)
+		assert.EqualError(t, err, "E474: Invalid argument: filetype="+fileType)
+	}
+}
@@ -0,0 +1,48 @@
+package mog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// exFormat implements :format, which pipes the buffer through the shell
+// command in formatprg and replaces it with what the command writes. The
+// buffer is left alone if the command fails. Formatting can be undone like
+// any other change.
+func (f *SimpleFrame) exFormat(string) error {
+	if f.formatPrg == "" {
+		return errors.New("formatprg is not set")
+	}
+	f.loadAllLines()
+	var stdout, stderr bytes.Buffer
+	cmd := shellCommand(f.formatPrg)
+	cmd.Stdin = strings.NewReader(strings.Join(f.buffer.lines(), "\n") + "\n")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %s", f.formatPrg, strings.SplitN(msg, "\n", 2)[0])
+		}
+		return fmt.Errorf("%s: %w", f.formatPrg, err)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	if !equalLines(lines, f.buffer.lines()) {
+		f.undo.begin(f.cursorPos())
+		f.replaceLines(0, f.buffer.len(), lines)
+		f.undo.end()
+		f.moveCursorTo(f.cursorPos())
+	}
+	return nil
+}
+
+// shellCommand returns the command that runs command with the shell.
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,29 @@
+package mog
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleFrame_exFormat(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a POSIX shell")
+	}
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+	f.loadBuffer([]byte("b\na\nc"))
+
+	assert.EqualValues(t, "formatprg is not set", f.Execute("format").Error())
+
+	assert.Nil(t, f.Execute("set fp=sort"))
+	assert.Nil(t, f.Execute("format"))
+	assert.EqualValues(t, []string{"a", "b", "c"}, f.buffer.lines())
+	f.Undo()
+	assert.EqualValues(t, []string{"b", "a", "c"}, f.buffer.lines())
+
+	assert.Nil(t, f.Execute("set fp=echo\\ oops\\ >&2;\\ exit\\ 1"))
+	assert.EqualValues(t, "echo oops >&2; exit 1: oops", f.Execute("format").Error())
+	assert.EqualValues(t, []string{"b", "a", "c"}, f.buffer.lines())
+}
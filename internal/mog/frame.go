@@ -4,9 +4,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"strings"
-
-	"github.com/gdamore/tcell/v2"
+	"unicode/utf8"
 )
 
 type Cursor interface {
@@ -23,78 +23,353 @@ type TextBuffer interface {
 }
 
 type SimpleFrame struct {
-	screen       tcell.Screen
-	buffer       []string
-	cursor       Cursor
-	mode         Mode
-	offset       int
-	filePath     string
-	lockFilePath string
+	screen     Screen
+	buffer     lineBuffer
+	cursor     Cursor
+	mode       Mode
+	pending    string
+	offset     int
+	scrollOff  int
+	layout     layout
+	undo       undoHistory
+	paste      *strings.Builder
+	highlights highlightCache
+	damage     damage
+	drawn      drawnState
+	groups     *highlightGroups
+	cmdline    string
+	// searching is set while a search pattern rather than a command is
+	// typed on the command line.
+	searching    bool
+	message      message
+	messages     []message
+	listMessages bool
+	modified     bool
+	closed       bool
+	mouse        bool
+	mouseState   mouseState
+	visualStart  bufferPos
+	prompt       *prompt
+	readonly     bool
+	autoread     bool
+	fileFormat   string
+	fileEncoding string
+	// bomb is set if the file starts with a byte order mark.
+	bomb     bool
+	filePath string
+	// fileInfo describes the file being edited when it was loaded or last
+	// written.
+	fileInfo os.FileInfo
+	watcher  *fileWatcher
+	swap     *swapFile
+	// foundSwap is the swap file that already existed when the file was
+	// loaded, if any.
+	foundSwap *foundSwap
+	// lazy is the large file being edited, whose lines are only read when
+	// needed.
+	lazy *lazyFile
+	// argList holds the files given on the command line, argIndex the
+	// one being edited.
+	argList  []string
+	argIndex int
+	// lastSearch is the pattern searched for last, whose matches are
+	// highlighted if hlSearch is set.
+	lastSearch *regexp.Regexp
+	hlSearch   bool
+	// cursorLine is set when the line with the cursor is highlighted.
+	cursorLine bool
+	// input reads the buffer from stdin until all of it was read.
+	// inputHeld is the end of what was read that is only added to the
+	// buffer with the text following it.
+	input     *inputReader
+	inputHeld []byte
+	// pager is set when the frame is used as a pager, following while
+	// it keeps the end of the text in view.
+	pager     bool
+	following bool
+	// hex is the hex view the bytes of the file are edited in instead of
+	// its lines, if any.
+	hex *hexView
+	// headless is set when the frame is not shown on a terminal but runs
+	// a script. It uses no swap file and does not watch its file.
+	headless bool
+	// globalOptions holds the global values of the window and buffer
+	// options that were set.
+	globalOptions map[string]optionValue
+	// mappings holds the mappings of every mode. typeahead holds the
+	// keys waiting to be handled, which keyWait is closed to stop
+	// waiting for more of, and mapped is the mapping that typed the key
+	// being handled, if any.
+	mappings  map[mapModes]*keyTrie
+	typeahead []typedKey
+	keyWait   chan struct{}
+	mapped    *mapping
+	// timeoutLen is how many milliseconds to wait for the rest of a
+	// mapping, mapLeader what <Leader> stands for in mappings.
+	timeoutLen int
+	mapLeader  string
+	// listing holds the lines a command listed, shown until the next key.
+	listing []message
+	// autocmds holds what runs when editor events fire, autocmdDepth
+	// how many events are running theirs. changedTick counts the changes
+	// to the buffer, autocmdTick and autocmdCursor are what it and the
+	// cursor were when CursorMoved and TextChanged were last checked for.
+	autocmds      []*autocmd
+	autocmdDepth  int
+	changedTick   int
+	autocmdTick   int
+	autocmdCursor bufferPos
+	// fileType is the type of the file being edited, e.g. "go", and
+	// syntax the name of the highlighter used for it.
+	fileType string
+	syntax   string
+	// tabStop is the distance between the columns tabs reach and shiftWidth
+	// the number of columns Tab indents by in insert mode, if it is not
+	// 0, in which case it indents by tabStop. Tab inserts spaces instead
+	// of a tab if expandTab is set.
+	tabStop    int
+	shiftWidth int
+	expandTab  bool
+	// commentString is how a line is commented out, with %s standing for
+	// the line.
+	commentString string
+	// formatPrg is the shell command :format pipes the buffer through.
+	formatPrg string
+	// textWidth is the width at which lines typed in insert mode are
+	// broken, if it is not 0.
+	textWidth int
+	// fixEndOfLine makes the file end with a line ending when it is
+	// written and trimTrailingWhitespace removes the white space at the
+	// end of lines before.
+	fixEndOfLine           bool
+	trimTrailingWhitespace bool
+	// modeline is set if the options set by modelines in the first and
+	// last modelines lines of the file are applied.
+	modeline  bool
+	modelines int
 }
 
-func EmptyFrame() *SimpleFrame {
-	s, err := tcell.NewScreen()
-	if err != nil {
-		log.Fatalf("%+v", err)
-	}
+// EmptyFrame returns a frame with an empty buffer shown on s, which it
+// initializes. The mouse is left to the terminal until the mouse option is
+// set.
+func EmptyFrame(s Screen) (*SimpleFrame, error) {
 	if err := s.Init(); err != nil {
-		log.Fatalf("%+v", err)
+		return nil, err
 	}
+	s.EnablePaste()
+	return newFrame(s), nil
+}
+
+// newFrame returns a frame with an empty buffer shown on s.
+func newFrame(s Screen) *SimpleFrame {
 	return &SimpleFrame{
-		screen:   s,
-		buffer:   []string{""},
-		cursor:   NewSimpleCursor(),
-		filePath: "",
-		mode:     ModeInsert,
+		screen:        s,
+		buffer:        newLineBuffer([]string{""}),
+		cursor:        NewSimpleCursor(),
+		groups:        newHighlightGroups(),
+		autoread:      true,
+		fileFormat:    fileFormatUnix,
+		fileEncoding:  encodingUTF8,
+		filePath:      "",
+		mode:          ModeNormal,
+		timeoutLen:    defaultTimeoutLen,
+		mapLeader:     defaultMapLeader,
+		tabStop:       defaultTabStop,
+		shiftWidth:    defaultTabStop,
+		commentString: defaultCommentString,
+		modeline:      true,
+		modelines:     defaultModelines,
 	}
 }
 
-func NewFrame(bs []byte) *SimpleFrame {
-	f := EmptyFrame()
-	buf := strings.Split(string(bs), "\n")
-	f.buffer = buf
-	return f
+func NewFrame(s Screen, bs []byte) (*SimpleFrame, error) {
+	f, err := EmptyFrame(s)
+	if err != nil {
+		return nil, err
+	}
+	f.loadBuffer(bs)
+	return f, nil
 }
 
-func NewFrameFromFile(filename string) *SimpleFrame {
-	f := EmptyFrame()
-	err := f.loadFile(filename)
+// NewFrameFromFile returns a frame editing the file at filename. The screen
+// is restored if the file cannot be loaded.
+func NewFrameFromFile(s Screen, filename string) (*SimpleFrame, error) {
+	f, err := EmptyFrame(s)
 	if err != nil {
-		log.Fatalf("%+v", err)
+		return nil, err
 	}
-	return f
+	if err := f.loadFile(filename); err != nil {
+		f.screen.Fini()
+		return nil, err
+	}
+	f.startWatching()
+	return f, nil
 }
 
-func (f *SimpleFrame) loadFile(filePath string) error {
-	lockFilePath := lockFilePathOf(filePath)
-	if _, err := os.Stat(lockFilePath); err == nil {
-		return fmt.Errorf("file open in another frame")
+// NewFrameRecoveringFile returns a frame editing the file at filename with
+// the changes saved in its swap file.
+func NewFrameRecoveringFile(s Screen, filename string) (*SimpleFrame, error) {
+	f, err := NewFrameFromFile(s, filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.recoverFile(filename); err != nil {
+		_ = f.Close()
+		return nil, err
 	}
+	return f, nil
+}
 
-	bs, err := os.ReadFile(filePath)
+// recoverFile replaces the buffer of the file at filename, which was just
+// loaded, with the changes saved in its swap file.
+func (f *SimpleFrame) recoverFile(filename string) error {
+	found := f.foundSwap
+	if found == nil || found.err != nil {
+		return editorErrorf(305, "No swap file found for %s", filename)
+	}
+	f.prompt = nil
+	info, err := os.Stat(filename)
 	if err != nil {
 		return err
 	}
+	return f.recoverSwapFile(found, info.ModTime())
+}
 
-	file, err := os.Create(lockFilePath)
+// loadFile loads the file at filePath and creates its swap file. If there
+// already is a swap file, it asks what to do about it instead.
+func (f *SimpleFrame) loadFile(filePath string) error {
+	c, err := f.readFile(filePath, "")
 	if err != nil {
 		return err
 	}
-	defer func(file *os.File) {
-		err := file.Close()
-		if err != nil {
-			log.Fatalf("%+v", err)
+	return f.loadContents(filePath, c)
+}
+
+// fileContents is a file that was read to be loaded into the buffer.
+type fileContents struct {
+	info os.FileInfo
+	// lazy is set for a large file, whose lines are read when they are
+	// needed, and hex for a large binary file, whose bytes are. Otherwise
+	// bs holds the whole file.
+	lazy *lazyFile
+	hex  *hexView
+	bs   []byte
+	// encoding is the encoding of the file, or "" if it is to be detected.
+	encoding string
+	config   editorConfig
+}
+
+// readFile reads the file at filePath without touching the buffer, so that
+// nothing is lost if it cannot be read. Unless encoding is "", the file is
+// decoded with it rather than the one .editorconfig files give, whose
+// errors are shown.
+func (f *SimpleFrame) readFile(filePath, encoding string) (*fileContents, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	lazy, err := openLargeFile(filePath, info)
+	if err != nil {
+		return nil, err
+	}
+	if lazy != nil && encoding != "" && encoding != encodingUTF8 {
+		lazy.file.Close()
+		lazy = nil
+	}
+	config, err := editorConfigFor(filePath)
+	if err != nil {
+		f.showError(err)
+	}
+	c := &fileContents{info: info, lazy: lazy, encoding: encoding, config: config}
+	if c.encoding == "" {
+		c.encoding = config.encoding()
+	}
+	if lazy == nil {
+		if c.hex, err = openLargeBinaryFile(filePath, info); err != nil {
+			return nil, err
+		}
+	}
+	if lazy == nil && c.hex == nil {
+		if c.bs, err = os.ReadFile(filePath); err != nil {
+			return nil, err
 		}
-	}(file)
+	}
+	return c, nil
+}
 
-	f.loadBuffer(bs)
+// close closes the large file that was read, if it is not loaded after all.
+func (c *fileContents) close() {
+	if c.lazy != nil {
+		c.lazy.file.Close()
+	}
+	if c.hex != nil {
+		c.hex.close()
+	}
+}
+
+// loadContents loads a file that was read from filePath and creates its
+// swap file, or asks what to do about the one there already is.
+func (f *SimpleFrame) loadContents(filePath string, c *fileContents) error {
+	info := c.info
+	switch {
+	case c.lazy != nil:
+		f.loadLargeFile(c.lazy)
+		if f.headless {
+			// There is no event loop to hand the lines found over.
+			f.finishIndexing()
+		}
+	case c.hex != nil:
+		f.loadHexView(c.hex)
+		f.highlights.setHighlighter(nil)
+	default:
+		h, err := highlighterFor(filePath)
+		if err != nil {
+			f.showError(err)
+		}
+		f.highlights.setHighlighter(h)
+		f.syntax = highlighterName(h)
+		f.loadBuffer(c.bs)
+		if c.encoding != "" && c.encoding != f.fileEncoding && f.hex == nil {
+			f.buffer = newLineBuffer(f.decodeFile(c.bs, c.encoding))
+		}
+	}
 	f.filePath = filePath
-	f.lockFilePath = lockFilePath
+	f.fileInfo = info
+	f.detectFileTypeOfBuffer()
+	f.applyEditorConfig(c.config)
+	f.applyModelines()
+	// The options just set describe the file as it was read.
+	f.modified = false
+	f.fireBufferAutocmds(BufReadPost)
+	if f.headless {
+		return nil
+	}
+
+	swapPath := swapFilePathOf(filePath)
+	err := f.createSwapFile(swapPath, info.ModTime())
+	if err != nil && !os.IsExist(err) {
+		// The file is edited without a swap file then.
+		f.showError(editorErrorf(303, "Unable to open swap file for %q, recovery impossible", filePath))
+	}
+	if !os.IsExist(err) {
+		return nil
+	}
+	swapInfo, buffer, err := readSwapFile(swapPath)
+	f.foundSwap = &foundSwap{path: swapPath, info: swapInfo, buffer: buffer, err: err}
+	f.prompt = f.swapFilePrompt(f.foundSwap, info.ModTime())
 	return nil
 }
 
+// loadBuffer replaces the buffer with the contents of a file, whose
+// encoding and line endings are detected. Binary files are shown in the hex
+// view instead, without being decoded.
 func (f *SimpleFrame) loadBuffer(bs []byte) {
-	f.buffer = strings.Split(string(bs), "\n")
+	if isBinary(bs) {
+		f.loadHexView(newHexView(bs))
+		return
+	}
+	f.closeHex()
+	f.buffer = newLineBuffer(f.decodeFile(bs, ""))
 }
 
 // MoveCursor moves the Cursor in the given direction.
@@ -105,9 +380,8 @@ func (f *SimpleFrame) loadBuffer(bs []byte) {
 // This value is instead adjusted to the length of the line when moving to
 // the left.
 //
-// If the cursor is at the top of the screen but not on the first line of
-// the content in the buffer the screen scrolls upwards. An equivalent
-// mechanic is implemented when moving down when on the bottom of the screen.
+// The view is scrolled whenever the cursor would otherwise come closer than
+// scrolloff lines to the top or bottom of the screen.
 func (f *SimpleFrame) MoveCursor(d dir) {
 	switch d {
 	case dirUp:
@@ -115,93 +389,200 @@ func (f *SimpleFrame) MoveCursor(d dir) {
 			f.cursor.MoveUp()
 		}
 	case dirDown:
-		if f.cursor.YPos() < len(f.buffer)-1 {
+		if f.cursor.YPos() < f.buffer.len()-1 {
 			f.cursor.MoveDown()
 		}
 	case dirLeft:
 		if f.cursor.XPos() > len(f.currentLine()) {
-			f.cursor.MoveTo(len(f.currentLine())-1, f.cursor.YPos())
+			f.cursor.MoveTo(lastCharStart(f.currentLine()), f.cursor.YPos())
 		}
 		if f.cursor.XPos() > 0 {
-			f.cursor.MoveLeft()
+			f.cursor.MoveTo(prevCharStart(f.currentLine(), f.cursor.XPos()), f.cursor.YPos())
 		}
 	case dirRight:
-		if f.cursor.XPos() < len(f.currentLine())-1 {
-			f.cursor.MoveRight()
+		if f.cursor.XPos() < lastCharStart(f.currentLine()) {
+			f.cursor.MoveTo(nextCharStart(f.currentLine(), f.cursor.XPos()), f.cursor.YPos())
 		}
 	}
-	_, h := f.screen.Size()
-	_, y := f.bufferPosToViewPos(f.cursor.XPos(), f.cursor.YPos())
-	if y == h-1 {
-		f.offset++
-	}
-	if f.cursor.YPos()-f.offset == -1 {
-		f.offset--
-	}
+	f.scrollToCursor()
 	f.showCursor()
 }
 
 func (f *SimpleFrame) bufferPosToViewPos(bufX, bufY int) (int, int) {
 	w, _ := f.screen.Size()
-	x := bufX % w
+	if w <= 0 {
+		return 0, 0
+	}
+	col := bufX
+	if bufY >= 0 && bufY < f.buffer.len() {
+		col = columnOf(f.buffer.line(bufY), bufX, f.tabStop)
+	}
+	if bufY < f.offset {
+		return col % w, col/w - f.rowsBetween(bufY, f.offset)
+	}
+	return col % w, f.syncLayout().start(&f.buffer, f.offset, bufY) + col/w
+}
+
+// syncLayout returns the layout cache of the frame, brought up to date with
+// the size of the screen and the buffer.
+func (f *SimpleFrame) syncLayout() *layout {
+	w, _ := f.screen.Size()
+	f.layout.sync(w, f.tabStop, f.buffer.len())
+	return &f.layout
+}
 
-	offs := 0
-	for i := 0; i < bufY-f.offset; i++ {
-		offs += 1 + len(f.buffer[f.offset+i])/w
+// lineChanged must be called after the contents of buffer line y changed
+// without adding or removing lines.
+func (f *SimpleFrame) lineChanged(y int) {
+	f.damage.markLine(y, f.layout.cachedHeight(y))
+	f.layout.invalidateLine(y)
+	if f.highlights.invalidateLine(&f.buffer, y) {
+		f.damage.markBelow(y + 1)
 	}
+}
 
-	y := bufX/w + offs
+// linesInserted must be called after n lines have been inserted before line
+// y.
+func (f *SimpleFrame) linesInserted(y, n int) {
+	f.layout.insertLines(y, n)
+	f.highlights.insertLines(y, n)
+	f.damage.markBelow(y)
+}
 
-	return x, y
+// linesDeleted must be called after the n lines starting at line y have
+// been removed.
+func (f *SimpleFrame) linesDeleted(y, n int) {
+	f.layout.deleteLines(y, n)
+	f.highlights.deleteLines(y, n)
+	f.damage.markBelow(y)
 }
 
 func (f *SimpleFrame) currentLine() string {
-	return f.buffer[f.cursor.YPos()]
+	f.ensureLoaded(f.cursor.YPos(), f.cursor.YPos()+1)
+	return f.buffer.line(f.cursor.YPos())
 }
 
 func (f *SimpleFrame) InsertRune(r rune) {
-	if f.cursor.XPos() >= len(f.buffer[f.cursor.YPos()]) {
-		var toX = len(f.currentLine()) - 1
+	if f.cursor.XPos() >= len(f.buffer.line(f.cursor.YPos())) {
+		var toX = lastCharStart(f.currentLine())
 		if f.currentLine() == "" {
 			toX = 0
 		}
 		f.cursor.MoveTo(toX, f.cursor.YPos())
 	}
-	f.buffer[f.cursor.YPos()] = f.buffer[f.cursor.YPos()][:f.cursor.XPos()] + string(r) + f.buffer[f.cursor.YPos()][f.cursor.XPos():]
+	line := f.currentLine()
+	f.replaceLines(f.cursor.YPos(), 1, []string{line[:f.cursor.XPos()] + string(r) + line[f.cursor.XPos():]})
 }
 
-func (f *SimpleFrame) Show() {
-	f.screen.Clear()
-	f.writeBufferToScreen()
-	f.screen.Show()
-	f.showCursor()
+// InsertText inserts s at the cursor position and moves the cursor to the end
+// of it. Every newline in s starts a new line.
+func (f *SimpleFrame) InsertText(s string) {
+	y := f.cursor.YPos()
+	line := f.currentLine()
+	x := minInt(f.cursor.XPos(), len(line))
+	lines := strings.Split(s, "\n")
+	last := len(lines) - 1
+	endX := len(lines[last])
+	if last == 0 {
+		endX += x
+	}
+	lines[0] = line[:x] + lines[0]
+	lines[last] += line[x:]
+	f.replaceLines(y, 1, lines)
+	f.cursor.MoveTo(endX, y+last)
+	f.scrollToCursor()
 }
 
+// writeBufferToScreen draws the visible part of the buffer, followed by a
+// '~' on every screen line below the end of the buffer, and the bottom line.
+// Only the lines that fit on the screen are looked at.
 func (f *SimpleFrame) writeBufferToScreen() {
-	_, h := f.screen.Size()
-	lastPrintedScreenLine := -1
-	for bufY := range f.buffer {
-		// Leave an extra line free at the bottom
-		if bufY < f.offset || bufY >= f.offset+h-1 {
-			continue
+	f.writeBufferLines(f.offset)
+	f.writeBufferBottomLine()
+}
+
+// writeBufferLines redraws the buffer from buffer line from down to the
+// bottom of the text area, clearing whatever was on those screen lines.
+func (f *SimpleFrame) writeBufferLines(from int) {
+	w, _ := f.screen.Size()
+	if w <= 0 {
+		return
+	}
+	textHeight := f.textHeight()
+	l := f.syncLayout()
+	row := l.start(&f.buffer, f.offset, minInt(from, f.buffer.len()))
+	for bufY := from; bufY < f.buffer.len() && row < textHeight; bufY++ {
+		f.writeLine(bufY, row)
+		row += l.lineHeight(&f.buffer, bufY)
+	}
+	for ; row < textHeight; row++ {
+		f.clearBufferLine(row)
+		f.screen.SetContent(0, row, '~', nil, f.style(GroupNonText))
+	}
+}
+
+// writeLine draws buffer line bufY starting at screen line row, clearing
+// the screen lines it occupies first.
+func (f *SimpleFrame) writeLine(bufY, row int) {
+	w, _ := f.screen.Size()
+	textHeight := f.textHeight()
+	cursorLine := f.cursorLine && bufY == f.cursor.YPos()
+	for y := row; y < row+f.lineHeight(bufY) && y < textHeight; y++ {
+		if cursorLine {
+			f.clearBufferLineWith(y, f.cursorLineStyle(GroupNormal))
+		} else {
+			f.clearBufferLine(y)
 		}
-		if f.buffer[bufY] == "" {
-			lastPrintedScreenLine++
-			continue
+	}
+	spans := f.highlights.line(&f.buffer, bufY)
+	line := f.buffer.line(bufY)
+	matches := f.searchMatches(line)
+	span, match := 0, 0
+	col := 0
+	for bufX := 0; bufX < len(line); {
+		r, size, width := charAt(line[bufX:], col, f.tabStop)
+		group := groupAt(spans, &span, bufX)
+		if groupAt(matches, &match, bufX) == GroupSearch {
+			group = GroupSearch
 		}
-		for bufX, r := range f.buffer[bufY] {
-			x, y := f.bufferPosToViewPos(bufX, bufY)
-			f.screen.SetContent(x, y, r, nil, tcell.StyleDefault)
-			lastPrintedScreenLine = y
+		if f.inSelection(bufX, bufY) {
+			group = GroupVisual
 		}
+		cells := []rune{r}
+		if r == '\t' {
+			cells = []rune(strings.Repeat(" ", width))
+		} else if width == invalidByteWidth {
+			cells = []rune(fmt.Sprintf("<%02x>", line[bufX]))
+			if group != GroupVisual {
+				group = GroupNonText
+			}
+		}
+		style := f.style(group)
+		if cursorLine {
+			style = f.cursorLineStyle(group)
+		}
+		for _, c := range cells {
+			y := row + col/w
+			if y >= textHeight {
+				return
+			}
+			f.screen.SetContent(col%w, y, c, nil, style)
+			col++
+		}
+		bufX += size
 	}
-	for i := lastPrintedScreenLine + 1; i < h-1; i++ {
-		f.screen.SetContent(0, i, '~', nil, tcell.StyleDefault)
-	}
-	f.writeBufferBottomLine()
 }
 
 func (f *SimpleFrame) showCursor() {
+	if f.mode == ModeCommand {
+		_, h := f.screen.Size()
+		f.screen.ShowCursor(utf8.RuneCountInString(f.bottomLine()), h-1)
+		return
+	}
+	if f.hex != nil {
+		f.screen.ShowCursor(f.hexCursorScreenPos())
+		return
+	}
 	x, y := f.cursorScreenPos()
 	f.screen.ShowCursor(x, y)
 }
@@ -211,80 +592,354 @@ func (f *SimpleFrame) cursorScreenPos() (int, int) {
 		if f.currentLine() == "" {
 			return f.bufferPosToViewPos(0, f.cursor.YPos())
 		}
-		return f.bufferPosToViewPos(len(f.currentLine())-1, f.cursor.YPos())
+		return f.bufferPosToViewPos(lastCharStart(f.currentLine()), f.cursor.YPos())
 	}
 	return f.bufferPosToViewPos(f.cursor.XPos(), f.cursor.YPos())
 }
 
 func (f *SimpleFrame) Close() error {
+	f.stopKeyTimeout()
+	f.stopWatching()
+	f.closeLargeFile()
+	f.closeHex()
+	if f.input != nil {
+		f.input.close()
+	}
 	f.screen.Fini()
-	err := os.Remove(f.lockFilePath)
+	if f.swap == nil {
+		return nil
+	}
+	err := os.Remove(f.swap.path)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (f *SimpleFrame) PollEvent() tcell.Event {
+func (f *SimpleFrame) PollEvent() Event {
 	return f.screen.PollEvent()
 }
 
-func (f *SimpleFrame) HandleEvent(e tcell.Event) bool {
+func (f *SimpleFrame) HandleEvent(e Event) bool {
+	defer f.fireChangeAutocmds()
 	switch ev := e.(type) {
-	case *tcell.EventResize:
+	case *EventResize:
 		f.screen.Sync()
+		f.fireBufferAutocmds(VimResized)
 		f.Show()
-	case *tcell.EventKey:
+	case *EventKey:
+		if f.paste != nil {
+			f.handlePastedKey(*ev)
+			return false
+		}
 		return f.handleEventKey(*ev)
+	case *EventMouse:
+		f.handleEventMouse(ev)
+	case *EventPaste:
+		f.handleEventPaste(ev)
+	case *eventSnapshot:
+		f.writeSnapshot()
+	case *eventFileChanged:
+		f.checkFile()
+	case *eventIndexed:
+		f.takeIndexed()
+	case *eventInput:
+		f.takeInput()
+	case *eventKeyTimeout:
+		f.handleKeyTimeout(ev)
+		return f.closed
 	default:
 		log.Print(ev)
 	}
 	return false
 }
 
-func (f *SimpleFrame) handleEventKey(ev tcell.EventKey) bool {
+// handleEventKey handles a key typed after the keys that are held because
+// they could be the start of a mapping.
+func (f *SimpleFrame) handleEventKey(ev EventKey) bool {
+	f.stopKeyTimeout()
+	f.typeahead = append(f.typeahead, typedKey{ev: ev})
+	f.runTypeahead(false)
+	return f.closed
+}
+
+// dispatchKey handles a key, after mappings were applied, in the current
+// mode.
+func (f *SimpleFrame) dispatchKey(ev EventKey) {
+	f.message = message{}
+	f.listMessages = false
+	f.listing = nil
+	switch {
+	case f.prompt != nil:
+		f.handlePromptKey(ev)
+	case f.hex != nil && f.mode != ModeCommand:
+		f.handleHexKey(ev)
+	case f.pager && f.mode == ModeNormal:
+		f.handlePagerKey(ev)
+	case f.mode == ModeNormal:
+		f.handleNormalKey(ev)
+	case f.mode == ModeVisual:
+		f.handleVisualKey(ev)
+	case f.mode == ModeCommand:
+		f.handleCommandKey(ev)
+	default:
+		f.handleInsertKey(ev)
+	}
+}
+
+func (f *SimpleFrame) handleInsertKey(ev EventKey) {
 	switch ev.Key() {
-	case tcell.KeyEscape:
-		err := f.Close()
-		if err != nil {
-			log.Fatalf("%+v", err)
-		}
-		return true
-	case tcell.KeyUp:
+	case KeyEscape:
+		f.undo.end()
+		f.mode = ModeNormal
+		f.fireBufferAutocmds(InsertLeave)
+	case KeyUp:
 		f.MoveCursor(dirUp)
-	case tcell.KeyDown:
+	case KeyDown:
 		f.MoveCursor(dirDown)
-	case tcell.KeyRight:
+	case KeyRight:
 		f.MoveCursor(dirRight)
-	case tcell.KeyLeft:
+	case KeyLeft:
 		f.MoveCursor(dirLeft)
-	case tcell.KeyRune:
+	case KeyEnter:
+		f.InsertText("\n")
+	case KeyTab:
+		f.insertTab()
+	case KeyRune:
 		f.handleEventRune(ev.Rune())
 	}
-	return false
+}
+
+func (f *SimpleFrame) handleNormalKey(ev EventKey) {
+	if f.pending != "" {
+		f.handlePendingKey(ev)
+		return
+	}
+	switch ev.Key() {
+	case KeyUp:
+		f.MoveCursor(dirUp)
+	case KeyDown:
+		f.MoveCursor(dirDown)
+	case KeyRight:
+		f.MoveCursor(dirRight)
+	case KeyLeft:
+		f.MoveCursor(dirLeft)
+	case KeyCtrlE:
+		f.ScrollLines(1)
+	case KeyCtrlY:
+		f.ScrollLines(-1)
+	case KeyCtrlD:
+		f.ScrollHalfPage(1)
+	case KeyCtrlU:
+		f.ScrollHalfPage(-1)
+	case KeyCtrlF:
+		f.ScrollPage(1)
+	case KeyCtrlB:
+		f.ScrollPage(-1)
+	case KeyCtrlR:
+		f.Redo()
+	case KeyRune:
+		f.handleNormalRune(ev.Rune())
+	}
+}
+
+func (f *SimpleFrame) handleNormalRune(r rune) {
+	switch r {
+	case 'i':
+		f.undo.begin(f.cursorPos())
+		f.mode = ModeInsert
+		f.fireBufferAutocmds(InsertEnter)
+	case 'u':
+		f.Undo()
+	case 'n':
+		if err := f.search(""); err != nil {
+			f.showError(err)
+		}
+	case 'v':
+		f.StartVisual()
+	case ':':
+		f.mode = ModeCommand
+		f.searching = false
+		f.cmdline = ""
+	case '/':
+		f.mode = ModeCommand
+		f.searching = true
+		f.cmdline = ""
+	case 'h':
+		f.MoveCursor(dirLeft)
+	case 'j':
+		f.MoveCursor(dirDown)
+	case 'k':
+		f.MoveCursor(dirUp)
+	case 'l':
+		f.MoveCursor(dirRight)
+	case 'H', 'M', 'L':
+		f.MoveCursorToScreenLine(r)
+	case 'g', 'z':
+		f.pending = string(r)
+	}
+}
+
+// handlePendingKey completes a multi-key normal mode command such as zz.
+// Any key that does not complete the command cancels it.
+func (f *SimpleFrame) handlePendingKey(ev EventKey) {
+	pending := f.pending
+	f.pending = ""
+	if ev.Key() != KeyRune {
+		return
+	}
+	switch pending + string(ev.Rune()) {
+	case "zz", "zt", "zb":
+		f.ScrollCursorTo(ev.Rune())
+	case "gc":
+		f.pending = "gc"
+	case "gcc":
+		f.toggleComment(f.cursor.YPos(), f.cursor.YPos())
+	}
 }
 
 func (f *SimpleFrame) handleEventRune(r rune) {
 	f.InsertRune(r)
 	f.MoveCursor(dirRight)
+	if f.textWidth > 0 && r != ' ' && r != '\t' {
+		f.breakLine()
+	}
+}
+
+// breakLine breaks the line with the cursor at the last blank that keeps
+// it within textwidth, if it is wider than that.
+func (f *SimpleFrame) breakLine() {
+	line := f.currentLine()
+	if lineWidth(line, f.tabStop) <= f.textWidth {
+		return
+	}
+	at := -1
+	for i := 0; i < len(line) && columnOf(line, i, f.tabStop) <= f.textWidth; i++ {
+		if (line[i] == ' ' || line[i] == '\t') && strings.TrimSpace(line[:i]) != "" {
+			at = i
+		}
+	}
+	if at < 0 {
+		return
+	}
+	head := strings.TrimRight(line[:at], " \t")
+	tail := strings.TrimLeft(line[at:], " \t")
+	y := f.cursor.YPos()
+	x := maxInt(f.cursor.XPos()-(len(line)-len(tail)), 0)
+	f.replaceLines(y, 1, []string{head, tail})
+	f.cursor.MoveTo(x, y+1)
+	f.scrollToCursor()
+}
+
+// insertTab indents to the next multiple of shiftwidth, or of tabstop if
+// that is 0. Unless expandtab is set, the spaces before the cursor and the
+// ones inserted are made into tabs as far as they reach a multiple of
+// tabstop.
+func (f *SimpleFrame) insertTab() {
+	width := f.shiftWidth
+	if width == 0 {
+		width = f.tabStop
+	}
+	line := f.currentLine()
+	x := minInt(f.cursor.XPos(), len(line))
+	start := x
+	if !f.expandTab {
+		for start > 0 && line[start-1] == ' ' {
+			start--
+		}
+	}
+	col := columnOf(line, start, f.tabStop)
+	end := (columnOf(line, x, f.tabStop)/width + 1) * width
+	var indent strings.Builder
+	if !f.expandTab {
+		for next := (col/f.tabStop + 1) * f.tabStop; next <= end; next += f.tabStop {
+			indent.WriteByte('\t')
+			col = next
+		}
+	}
+	indent.WriteString(strings.Repeat(" ", end-col))
+	y := f.cursor.YPos()
+	f.replaceLines(y, 1, []string{line[:start] + indent.String() + line[x:]})
+	f.cursor.MoveTo(start+indent.Len(), y)
+	f.scrollToCursor()
+}
+
+// setTabStop sets tabstop, which changes how wide the tabs on the screen
+// are.
+func (f *SimpleFrame) setTabStop(v optionValue) {
+	f.tabStop = v.n
+	f.damage.markAll()
 }
 
+// writeBufferBottomLine writes the bottom line, and the lines above it that
+// are covered by messages when there are more than one.
 func (f *SimpleFrame) writeBufferBottomLine() {
 	_, h := f.screen.Size()
-	bottomLine := " -- " + f.mode.Name + " --"
-	f.writeBufferLine(bottomLine, h-1)
+	msgs := f.bottomMessages()
+	if len(msgs) == 0 {
+		style := f.style(GroupStatusLine)
+		f.clearBufferLineWith(h-1, style)
+		f.writeBufferLine(f.bottomLine(), h-1, style)
+		f.writeStatusInfo(h-1, style)
+		return
+	}
+	for i, m := range msgs {
+		style := f.style(GroupNormal)
+		if m.isError {
+			style = f.style(GroupErrorMsg)
+		}
+		row := h - len(msgs) + i
+		f.clearBufferLineWith(row, f.style(GroupNormal))
+		f.writeBufferLine(m.text, row, style)
+	}
+}
+
+// writeStatusInfo writes the file format at the right end of the status
+// line if there is room for it.
+func (f *SimpleFrame) writeStatusInfo(row int, style Style) {
+	w, _ := f.screen.Size()
+	info := f.fileFormat
+	x := w - len(info)
+	if info == "" || x <= utf8.RuneCountInString(f.bottomLine()) {
+		return
+	}
+	f.writeBufferLineAt(info, x, row, style)
 }
 
-func (f *SimpleFrame) writeBufferLine(s string, line int) {
-	for i, r := range s {
-		f.screen.SetContent(i, line, r, nil, tcell.StyleDefault)
+// bottomLine returns the contents of the bottom line of the screen: the
+// command line being typed, the messages shown or the current mode.
+// Messages shown on more than one line are separated by newlines.
+func (f *SimpleFrame) bottomLine() string {
+	msgs := f.bottomMessages()
+	if len(msgs) == 0 {
+		return " -- " + f.mode.Name + " --"
+	}
+	lines := make([]string, len(msgs))
+	for i, m := range msgs {
+		lines[i] = m.text
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (f *SimpleFrame) writeBufferLine(s string, line int, style Style) {
+	f.writeBufferLineAt(s, 0, line, style)
+}
+
+func (f *SimpleFrame) writeBufferLineAt(s string, x, line int, style Style) {
+	i := x
+	for _, r := range s {
+		f.screen.SetContent(i, line, r, nil, style)
+		i++
 	}
 }
 
-//nolint:unused
 func (f *SimpleFrame) clearBufferLine(line int) {
+	f.clearBufferLineWith(line, f.style(GroupNormal))
+}
+
+func (f *SimpleFrame) clearBufferLineWith(line int, style Style) {
 	w, _ := f.screen.Size()
 	for i := 0; i < w; i++ {
-		f.screen.SetContent(i, line, ' ', nil, tcell.StyleDefault)
+		f.screen.SetContent(i, line, ' ', nil, style)
 	}
 }
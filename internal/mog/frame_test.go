@@ -8,17 +8,34 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-
-	"github.com/gdamore/tcell/v2"
 )
 
+// newTestFrame returns a frame in normal mode editing buffer, on a screen of
+// the given size that events can be posted to.
+func newTestFrame(t *testing.T, buffer []string, width, height int) (*SimpleFrame, *SimulationScreen) {
+	ss := NewSimulationScreen()
+	assert.Nil(t, ss.Init())
+	t.Cleanup(ss.Fini)
+	ss.SetSize(width, height)
+	return &SimpleFrame{screen: ss, buffer: newLineBuffer(buffer), cursor: NewSimpleCursor(), mode: ModeNormal}, ss
+}
+
+// numberedLines returns n lines holding their index.
+func numberedLines(n int) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprint(i)
+	}
+	return lines
+}
+
 func TestSimpleFrame_MoveCursor_MovingDownFromLastLineScrollsTheView(t *testing.T) {
-	ss := tcell.NewSimulationScreen("UTF-8")
+	ss := NewSimulationScreen()
 	ss.SetSize(3, 3)
 
 	f := &SimpleFrame{
 		screen: ss,
-		buffer: []string{"a", "b", "c", "d"},
+		buffer: newLineBuffer([]string{"a", "b", "c", "d"}),
 		cursor: NewSimpleCursorAt(0, 1),
 		mode:   ModeInsert,
 		offset: 0,
@@ -29,12 +46,12 @@ func TestSimpleFrame_MoveCursor_MovingDownFromLastLineScrollsTheView(t *testing.
 }
 
 func TestSimpleFrame_MoveCursor_MovingUpFromFirstListScrollsTheView(t *testing.T) {
-	ss := tcell.NewSimulationScreen("UTF-8")
+	ss := NewSimulationScreen()
 	ss.SetSize(3, 3)
 
 	f := &SimpleFrame{
 		screen: ss,
-		buffer: []string{"a", "b", "c", "d"},
+		buffer: newLineBuffer([]string{"a", "b", "c", "d"}),
 		cursor: NewSimpleCursor(),
 		mode:   ModeInsert,
 		offset: 1,
@@ -164,17 +181,17 @@ func TestSimpleFrame_MoveCursor(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			simulationScreen := tcell.NewSimulationScreen("UTF-8")
+			simulationScreen := NewSimulationScreen()
 			simulationScreen.SetSize(5, 5)
 			f := &SimpleFrame{
 				screen: simulationScreen,
-				buffer: tt.fields.buffer,
+				buffer: newLineBuffer(tt.fields.buffer),
 				cursor: tt.fields.cursor,
 				offset: tt.fields.offset,
 			}
 			f.MoveCursor(tt.args.d)
 
-			ss := f.screen.(tcell.SimulationScreen)
+			ss := f.screen.(*SimulationScreen)
 			cx, cy, vis := ss.GetCursor()
 			assert.True(t, vis)
 			assert.Equal(t, tt.expectedScreenCursorX, cx)
@@ -243,14 +260,14 @@ func TestSimpleFrame_writeBufferToScreen(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			simulationScreen := tcell.NewSimulationScreen("UTF-8")
+			simulationScreen := NewSimulationScreen()
 			err := simulationScreen.Init()
 			assert.Nil(t, err)
 			simulationScreen.SetSize(tt.screenWidth, tt.screenHeight)
 
 			f := &SimpleFrame{
 				screen: simulationScreen,
-				buffer: tt.fields.buffer,
+				buffer: newLineBuffer(tt.fields.buffer),
 				cursor: tt.fields.cursor,
 				offset: tt.fields.offset,
 				mode:   ModeInsert,
@@ -262,7 +279,7 @@ func TestSimpleFrame_writeBufferToScreen(t *testing.T) {
 			f.screen.Show()
 
 			// Read actual screen contents
-			ss := f.screen.(tcell.SimulationScreen)
+			ss := f.screen.(*SimulationScreen)
 			cells, w, h := ss.GetContents()
 			var actualBuffer []string
 			for i := 0; i < h; i++ {
@@ -279,12 +296,12 @@ func TestSimpleFrame_writeBufferToScreen(t *testing.T) {
 }
 
 func TestSimpleFrame_InsertRune(t *testing.T) {
-	simulationScreen := tcell.NewSimulationScreen("UTF-8")
+	simulationScreen := NewSimulationScreen()
 	err := simulationScreen.Init()
 	assert.Nil(t, err)
 	simulationScreen.SetSize(3, 3)
 	type fields struct {
-		screen tcell.Screen
+		screen Screen
 		buffer []string
 		cursor Cursor
 		offset int
@@ -314,19 +331,19 @@ func TestSimpleFrame_InsertRune(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			f := &SimpleFrame{
 				screen: tt.fields.screen,
-				buffer: tt.fields.buffer,
+				buffer: newLineBuffer(tt.fields.buffer),
 				cursor: tt.fields.cursor,
 				offset: tt.fields.offset,
 			}
 			f.InsertRune(tt.args.r)
-			assert.EqualValues(t, tt.expectedBuffer, f.buffer)
+			assert.EqualValues(t, tt.expectedBuffer, f.buffer.lines())
 		})
 	}
 }
 
 func Test_loadFile(t *testing.T) {
 	filename := "TestNewFrameFromFile.txt"
-	lockFileName := lockFilePathOf(filename)
+	swapFileName := swapFilePathOf(filename)
 	file, err := os.Create(filename)
 	assert.Nil(t, err)
 	defer func() {
@@ -339,55 +356,60 @@ func Test_loadFile(t *testing.T) {
 	assert.Nil(t, err)
 
 	f := &SimpleFrame{
-		screen:       tcell.NewSimulationScreen("UTF-8"),
-		buffer:       nil,
-		cursor:       nil,
-		offset:       0,
-		filePath:     "",
-		lockFilePath: "",
+		screen:   NewSimulationScreen(),
+		cursor:   nil,
+		offset:   0,
+		filePath: "",
 	}
 	err = f.loadFile(filename)
 	assert.Nil(t, err)
-	assert.EqualValues(t, lockFileName, f.lockFilePath)
+	assert.EqualValues(t, swapFileName, f.swap.path)
 	assert.EqualValues(t, filename, f.filePath)
+	assert.Nil(t, f.prompt)
 
-	info, err := os.Stat(lockFileName)
+	info, err := os.Stat(swapFileName)
 	assert.Nil(t, err)
 	assert.Equal(t, false, info.IsDir())
-	assert.EqualValues(t, 0, info.Size())
-	assert.Equal(t, path.Base(lockFileName), info.Name())
+	assert.Equal(t, path.Base(swapFileName), info.Name())
+	swapInfo, buffer, err := readSwapFile(swapFileName)
+	assert.Nil(t, err)
+	assert.EqualValues(t, os.Getpid(), swapInfo.pid)
+	assert.False(t, swapInfo.modified)
+	assert.Nil(t, buffer)
 
 	err = f.Close()
 	assert.Nil(t, err)
-	_, err = os.Stat(lockFileName)
+	_, err = os.Stat(swapFileName)
 	assert.NotNil(t, err)
 }
 
-func Test_loadFile_ReturnsErrorWhenLockFileExists(t *testing.T) {
+func Test_loadFile_PromptsWhenSwapFileExists(t *testing.T) {
 	filename := "TestNewFrameFromFile.txt"
-
-	lockFileName := lockFilePathOf(filename)
-	file, err := os.Create(lockFileName)
+	err := os.WriteFile(filename, []byte("text"), 0o644)
+	assert.Nil(t, err)
+	swapFileName := swapFilePathOf(filename)
+	err = os.WriteFile(swapFileName, []byte("garbage"), 0o600)
 	assert.Nil(t, err)
 	defer func() {
-		err := os.Remove(lockFileName)
-		if err != nil {
-			log.Println(fmt.Errorf("couldn't remove file at end of test: %w", err))
+		for _, name := range []string{filename, swapFileName} {
+			err := os.Remove(name)
+			if err != nil {
+				log.Println(fmt.Errorf("couldn't remove file at end of test: %w", err))
+			}
 		}
 	}()
-	err = file.Close()
-	assert.Nil(t, err)
 	f := &SimpleFrame{
-		screen:       tcell.NewSimulationScreen("UTF-8"),
-		buffer:       nil,
-		cursor:       nil,
-		offset:       0,
-		filePath:     "",
-		lockFilePath: "",
+		screen:   NewSimulationScreen(),
+		cursor:   nil,
+		offset:   0,
+		filePath: "",
 	}
 
 	err = f.loadFile(filename)
-	assert.Error(t, err)
+	assert.Nil(t, err)
+	assert.NotNil(t, f.prompt)
+	assert.Nil(t, f.swap, "the swap file of someone else is left alone")
+	assert.EqualValues(t, []string{"text"}, f.buffer.lines())
 }
 
 //nolint:funlen
@@ -446,12 +468,12 @@ func TestSimpleFrame_cursorScreenPos(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			simulationScreen := tcell.NewSimulationScreen("UTF-8")
+			simulationScreen := NewSimulationScreen()
 			simulationScreen.SetSize(3, 5)
 
 			f := &SimpleFrame{
 				screen: simulationScreen,
-				buffer: tt.fields.buffer,
+				buffer: newLineBuffer(tt.fields.buffer),
 				cursor: tt.fields.cursor,
 				mode:   ModeInsert,
 				offset: tt.fields.offset,
@@ -466,3 +488,19 @@ func TestSimpleFrame_cursorScreenPos(t *testing.T) {
 		})
 	}
 }
+
+func TestSimpleFrame_breakLine(t *testing.T) {
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+	f.loadBuffer([]byte("one two three"))
+	assert.Nil(t, f.Execute("set tw=8"))
+
+	f.cursor.MoveTo(10, 0)
+	typeKeys(f, "ix")
+	assert.EqualValues(t, []string{"one two", "thxree"}, f.buffer.lines())
+	assert.EqualValues(t, bufferPos{3, 1}, f.cursorPos())
+
+	// Lines without a blank to break them at stay as they are.
+	typeKeys(f, "xxxxx")
+	assert.EqualValues(t, []string{"one two", "thxxxxxxree"}, f.buffer.lines())
+}
@@ -0,0 +1,72 @@
+package mog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The size of the screen a headless frame pretends to be shown on, which
+// matters to commands that scroll.
+const (
+	headlessWidth  = 80
+	headlessHeight = 24
+)
+
+// NewHeadlessFrame returns a frame that is not shown on a terminal, for
+// running scripts.
+func NewHeadlessFrame() *SimpleFrame {
+	s := NewSimulationScreen()
+	s.SetSize(headlessWidth, headlessHeight)
+	f := newFrame(s)
+	f.headless = true
+	return f
+}
+
+// RunHeadless runs the commands and the script of a against each of its
+// files in turn, or against a buffer read from stdin or an empty one, in
+// headless frames. The errors are written to stderr. A script that leaves
+// changes that were not written fails as well. It returns the exit status,
+// which is 1 if anything failed.
+func RunHeadless(a *Args, stdin io.Reader, stderr io.Writer) int {
+	files := a.Files
+	if len(files) == 0 {
+		files = []string{""}
+	}
+	status := 0
+	for _, file := range files {
+		name := file
+		if name == "" {
+			name = "[No Name]"
+		}
+		for _, err := range runHeadless(a, file, stdin) {
+			fmt.Fprintf(stderr, "mog: %s: %v\n", name, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// runHeadless runs a against file and returns the errors that occurred.
+func runHeadless(a *Args, file string, stdin io.Reader) []error {
+	f := NewHeadlessFrame()
+	defer f.Close()
+	args := *a
+	args.Files = nil
+	if file != "" {
+		args.Files = []string{file}
+	}
+	if err := f.start(&args, stdin); err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, m := range f.messages {
+		if m.isError {
+			errs = append(errs, errors.New(m.text))
+		}
+	}
+	if !f.closed && f.modified {
+		errs = append(errs, errNoWriteSinceLastChange)
+	}
+	return errs
+}
@@ -0,0 +1,63 @@
+package mog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeScript(t *testing.T, script string) string {
+	path := filepath.Join(t.TempDir(), "script.mog")
+	assert.Nil(t, os.WriteFile(path, []byte(script), 0o644))
+	return path
+}
+
+func TestRunHeadless(t *testing.T) {
+	paths := writeTestFiles(t, "one\ntwo", "three")
+	script := writeScript(t, "jix<Esc>:wq\n")
+	var stderr bytes.Buffer
+
+	status := RunHeadless(&Args{Files: paths, Commands: []string{"$"}, Script: script}, nil, &stderr)
+
+	assert.EqualValues(t, 0, status)
+	assert.EqualValues(t, "", stderr.String())
+	for i, want := range []string{"one\nxtwo", "xthree"} {
+		bs, err := os.ReadFile(paths[i])
+		assert.Nil(t, err)
+		assert.EqualValues(t, want, string(bs))
+		_, err = os.Stat(swapFilePathOf(paths[i]))
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestRunHeadless_Failures(t *testing.T) {
+	paths := writeTestFiles(t, "one")
+	var stderr bytes.Buffer
+
+	status := RunHeadless(&Args{Files: paths, Script: writeScript(t, ":frob\nix")}, nil, &stderr)
+
+	assert.EqualValues(t, 1, status)
+	assert.EqualValues(t, "mog: "+paths[0]+": E492: Not an editor command: frob\n"+
+		"mog: "+paths[0]+": E37: No write since last change (add ! to override)\n", stderr.String())
+
+	stderr.Reset()
+	status = RunHeadless(&Args{Files: []string{paths[0] + ".missing"}}, nil, &stderr)
+	assert.EqualValues(t, 1, status)
+	assert.Contains(t, stderr.String(), "no such file")
+}
+
+func TestRunHeadless_Stdin(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.txt")
+	var stderr bytes.Buffer
+
+	status := RunHeadless(&Args{Stdin: true, Commands: []string{"w " + out}}, strings.NewReader("a\nb"), &stderr)
+
+	assert.EqualValues(t, 0, status, stderr.String())
+	bs, err := os.ReadFile(out)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "a\nb", string(bs))
+}
@@ -0,0 +1,583 @@
+package mog
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// maxHexRowBytes is the number of bytes shown on a line of the hex view
+	// when the screen is wide enough.
+	maxHexRowBytes = 16
+	// hexOffsetWidth is the width of the offset column, including the
+	// space after it.
+	hexOffsetWidth = 10
+	// binaryCheckSize is how much of a file is looked at to tell whether
+	// it is binary.
+	binaryCheckSize = 8000
+	// hexBlockSize is how much of a large file the hex view reads at once.
+	hexBlockSize = 64 << 10
+)
+
+// isBinary reports whether bs looks like the contents of a binary file
+// rather than text: it contains a NUL byte that is not part of UTF-16 text.
+func isBinary(bs []byte) bool {
+	if len(bs) > binaryCheckSize {
+		bs = bs[:binaryCheckSize]
+	}
+	encoding, bom := detectEncoding(bs)
+	if bom && (encoding == encodingUTF16LE || encoding == encodingUTF16BE) {
+		return false
+	}
+	return bytes.IndexByte(bs, 0) >= 0
+}
+
+// hexView shows and edits the bytes of a file rather than its lines.
+type hexView struct {
+	// data holds the bytes, unless they are those of file, a large file
+	// that is read when needed, with the bytes that were changed in
+	// changed.
+	data    []byte
+	file    *os.File
+	size    int
+	changed map[int]byte
+	// block holds the bytes of file from blockStart on that were read
+	// last.
+	block      []byte
+	blockStart int
+	cursor     int
+	// ascii is set while the cursor is in the ASCII pane rather than the
+	// hex pane.
+	ascii bool
+	// low is set when the next hex digit typed replaces the low half of
+	// the byte under the cursor.
+	low bool
+	// offset is the first row shown.
+	offset int
+	undo   []hexEdit
+	redo   []hexEdit
+	// saved is the length of undo when the bytes were last loaded or
+	// written, or -1 if undoing cannot get back there.
+	saved int
+	// pattern is the byte sequence searched for last.
+	pattern []byte
+	match   int
+}
+
+// hexEdit is a byte that was overwritten.
+type hexEdit struct {
+	pos      int
+	old, new byte
+}
+
+func newHexView(data []byte) *hexView {
+	return &hexView{data: data, match: -1}
+}
+
+// openLargeBinaryFile opens the file at filePath to be shown in a hex view
+// that reads it when needed, or returns nil if it is too small or not
+// binary.
+func openLargeBinaryFile(filePath string, info os.FileInfo) (*hexView, error) {
+	if info.Size() < largeFileSize {
+		return nil, nil
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, binaryCheckSize)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+	if !isBinary(head[:n]) {
+		file.Close()
+		return nil, nil
+	}
+	return &hexView{file: file, size: int(info.Size()), changed: map[int]byte{}, match: -1}, nil
+}
+
+// len returns the number of bytes.
+func (v *hexView) len() int {
+	if v.file != nil {
+		return v.size
+	}
+	return len(v.data)
+}
+
+// at returns the byte at pos. Bytes of a large file that cannot be read
+// are zero.
+func (v *hexView) at(pos int) byte {
+	if v.file == nil {
+		return v.data[pos]
+	}
+	if b, ok := v.changed[pos]; ok {
+		return b
+	}
+	if pos < v.blockStart || pos >= v.blockStart+len(v.block) {
+		v.blockStart = pos - pos%hexBlockSize
+		v.block = make([]byte, minInt(hexBlockSize, v.size-v.blockStart))
+		if n, err := v.file.ReadAt(v.block, int64(v.blockStart)); err != nil && err != io.EOF {
+			v.block = v.block[:n]
+			return 0
+		}
+	}
+	return v.block[pos-v.blockStart]
+}
+
+// readAt reads the bytes from pos on into buf and returns how many there
+// were.
+func (v *hexView) readAt(buf []byte, pos int) (int, error) {
+	if v.file == nil {
+		return copy(buf, v.data[minInt(pos, len(v.data)):]), nil
+	}
+	buf = buf[:minInt(len(buf), maxInt(v.size-pos, 0))]
+	n, err := v.file.ReadAt(buf, int64(pos))
+	if err != nil && err != io.EOF {
+		return n, err
+	}
+	for p, b := range v.changed {
+		if p >= pos && p < pos+n {
+			buf[p-pos] = b
+		}
+	}
+	return n, nil
+}
+
+// contents returns all the bytes.
+func (v *hexView) contents() ([]byte, error) {
+	if v.file == nil {
+		return v.data, nil
+	}
+	buf := make([]byte, v.size)
+	n, err := v.readAt(buf, 0)
+	return buf[:n], err
+}
+
+// writeTo writes all the bytes to w.
+func (v *hexView) writeTo(w io.Writer) (int64, error) {
+	if v.file == nil {
+		n, err := w.Write(v.data)
+		return int64(n), err
+	}
+	buf := make([]byte, hexBlockSize)
+	var written int64
+	for pos := 0; pos < v.size; pos += hexBlockSize {
+		n, err := v.readAt(buf, pos)
+		if err != nil {
+			return written, err
+		}
+		m, err := w.Write(buf[:n])
+		written += int64(m)
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (v *hexView) put(pos int, b byte) {
+	if v.file != nil {
+		v.changed[pos] = b
+	} else {
+		v.data[pos] = b
+	}
+}
+
+// modified reports whether the bytes differ from those last loaded or
+// written.
+func (v *hexView) modified() bool {
+	return len(v.undo) != v.saved
+}
+
+func (v *hexView) close() {
+	if v.file != nil {
+		v.file.Close()
+	}
+}
+
+// loadHexView shows the bytes of a binary file in v, leaving the buffer
+// empty.
+func (f *SimpleFrame) loadHexView(v *hexView) {
+	f.closeHex()
+	f.hex = v
+	f.buffer = newLineBuffer([]string{""})
+	f.fileEncoding = ""
+	f.bomb = false
+	f.fileFormat = fileFormatUnix
+}
+
+// closeHex leaves the hex view.
+func (f *SimpleFrame) closeHex() {
+	if f.hex != nil {
+		f.hex.close()
+		f.hex = nil
+	}
+}
+
+// hexRowBytes returns the number of bytes shown on a line of the hex view on
+// a screen w cells wide: the offset, three cells for every byte in the hex
+// pane, a space and a cell for every byte in the ASCII pane.
+func hexRowBytes(w int) int {
+	return clampInt((w-hexOffsetWidth-1)/4, 1, maxHexRowBytes)
+}
+
+func (f *SimpleFrame) hexRowBytes() int {
+	w, _ := f.screen.Size()
+	return hexRowBytes(w)
+}
+
+// set sets the byte at pos and remembers the old one to be undone.
+func (v *hexView) set(pos int, b byte) {
+	old := v.at(pos)
+	if old == b {
+		return
+	}
+	if len(v.undo) < v.saved {
+		v.saved = -1
+	}
+	v.undo = append(v.undo, hexEdit{pos: pos, old: old, new: b})
+	v.redo = nil
+	v.put(pos, b)
+}
+
+// moveTo moves the cursor to the byte at pos, which is clamped to the data.
+func (v *hexView) moveTo(pos int) {
+	v.cursor = clampInt(pos, 0, maxInt(v.len()-1, 0))
+	v.low = false
+}
+
+// scrollToCursor scrolls the view so that the row of the cursor is one of
+// the height rows shown.
+func (v *hexView) scrollToCursor(rowBytes, height int) {
+	row := v.cursor / rowBytes
+	if row < v.offset {
+		v.offset = row
+	} else if row >= v.offset+height {
+		v.offset = row - height + 1
+	}
+}
+
+// exHex implements :hex, which switches between editing the lines of the
+// buffer and the bytes they are written as.
+func (f *SimpleFrame) exHex(args string) error {
+	if args != "" {
+		return errTrailingCharacters(args)
+	}
+	if f.hex == nil {
+		f.loadAllLines()
+		data, err := f.encodeFile()
+		if err != nil {
+			return err
+		}
+		if f.mode == ModeVisual {
+			f.StopVisual()
+		}
+		f.hex = newHexView(data)
+		if f.modified {
+			f.hex.saved = -1
+		}
+		return nil
+	}
+	data, err := f.hex.contents()
+	if err != nil {
+		return err
+	}
+	lines := f.decodeFile(data, f.fileEncoding)
+	f.closeHex()
+	f.mode = ModeNormal
+	if !equalLines(lines, f.buffer.lines()) {
+		modified := f.modified
+		f.undo.begin(f.cursorPos())
+		f.replaceLines(0, f.buffer.len(), lines)
+		f.undo.end()
+		f.modified = modified
+	}
+	f.moveCursorTo(f.cursorPos())
+	f.damage.markAll()
+	return nil
+}
+
+// handleHexKey handles a key in normal or replace mode while the hex view
+// is shown.
+func (f *SimpleFrame) handleHexKey(ev EventKey) {
+	v := f.hex
+	n := f.hexRowBytes()
+	switch ev.Key() {
+	case KeyEscape:
+		f.mode = ModeNormal
+		v.low = false
+	case KeyTab:
+		v.ascii = !v.ascii
+		v.low = false
+	case KeyLeft:
+		v.moveTo(v.cursor - 1)
+	case KeyRight:
+		v.moveTo(v.cursor + 1)
+	case KeyUp:
+		f.hexMoveRows(-1)
+	case KeyDown:
+		f.hexMoveRows(1)
+	case KeyCtrlF:
+		f.hexMoveRows(f.textHeight())
+	case KeyCtrlB:
+		f.hexMoveRows(-f.textHeight())
+	case KeyCtrlR:
+		if f.mode == ModeNormal {
+			f.hexRedo()
+		}
+	case KeyRune:
+		if f.mode == ModeReplace {
+			f.hexTypeRune(ev.Rune())
+		} else {
+			f.handleHexRune(ev.Rune(), n)
+		}
+	}
+	v.scrollToCursor(n, f.textHeight())
+}
+
+func (f *SimpleFrame) handleHexRune(r rune, rowBytes int) {
+	v := f.hex
+	switch r {
+	case 'h':
+		v.moveTo(v.cursor - 1)
+	case 'l':
+		v.moveTo(v.cursor + 1)
+	case 'k':
+		f.hexMoveRows(-1)
+	case 'j':
+		f.hexMoveRows(1)
+	case '0':
+		v.moveTo(v.cursor - v.cursor%rowBytes)
+	case '$':
+		v.moveTo(v.cursor - v.cursor%rowBytes + rowBytes - 1)
+	case 'i', 'R':
+		f.mode = ModeReplace
+	case 'u':
+		f.hexUndo()
+	case 'n':
+		if err := f.hexSearch(""); err != nil {
+			f.showError(err)
+		}
+	case ':':
+		f.mode = ModeCommand
+		f.searching = false
+		f.cmdline = ""
+	case '/':
+		f.mode = ModeCommand
+		f.searching = true
+		f.cmdline = ""
+	}
+}
+
+// hexMoveRows moves the cursor n rows down, or up if n is negative, staying
+// in the same column where possible.
+func (f *SimpleFrame) hexMoveRows(n int) {
+	v := f.hex
+	pos := v.cursor + n*f.hexRowBytes()
+	if pos < 0 || pos >= v.len() {
+		return
+	}
+	v.moveTo(pos)
+}
+
+// hexTypeRune overwrites the byte under the cursor with a rune typed in
+// replace mode: a hex digit in the hex pane sets half of it, an ASCII
+// character in the ASCII pane all of it.
+func (f *SimpleFrame) hexTypeRune(r rune) {
+	v := f.hex
+	if v.len() == 0 {
+		return
+	}
+	b := v.at(v.cursor)
+	switch {
+	case v.ascii && r < 0x80:
+		b = byte(r)
+	case !v.ascii && strings.ContainsRune("0123456789abcdefABCDEF", r):
+		d, _ := hex.DecodeString("0" + string(r))
+		if v.low {
+			b = b&0xf0 | d[0]
+		} else {
+			b = b&0x0f | d[0]<<4
+		}
+	default:
+		return
+	}
+	v.set(v.cursor, b)
+	f.modified = v.modified()
+	if !v.ascii && !v.low {
+		v.low = true
+		return
+	}
+	if v.cursor < v.len()-1 {
+		v.moveTo(v.cursor + 1)
+	}
+	v.low = false
+}
+
+func (f *SimpleFrame) hexUndo() {
+	v := f.hex
+	if len(v.undo) == 0 {
+		return
+	}
+	e := v.undo[len(v.undo)-1]
+	v.undo = v.undo[:len(v.undo)-1]
+	v.redo = append(v.redo, e)
+	v.put(e.pos, e.old)
+	v.moveTo(e.pos)
+	f.modified = v.modified()
+}
+
+func (f *SimpleFrame) hexRedo() {
+	v := f.hex
+	if len(v.redo) == 0 {
+		return
+	}
+	e := v.redo[len(v.redo)-1]
+	v.redo = v.redo[:len(v.redo)-1]
+	v.undo = append(v.undo, e)
+	v.put(e.pos, e.new)
+	v.moveTo(e.pos)
+	f.modified = v.modified()
+}
+
+// hexSearch moves the cursor to the next occurrence of the bytes in pattern
+// after it, continuing at the start. The pattern is given as hex digits in
+// the hex pane and as text in the ASCII pane. An empty pattern searches for
+// the last one again.
+func (f *SimpleFrame) hexSearch(pattern string) error {
+	v := f.hex
+	if pattern != "" {
+		needle := []byte(pattern)
+		if !v.ascii {
+			var err error
+			needle, err = hex.DecodeString(strings.Join(strings.Fields(pattern), ""))
+			if err != nil {
+				return editorErrorf(486, "Pattern not found: %s", pattern)
+			}
+		}
+		v.pattern = needle
+	}
+	if len(v.pattern) == 0 {
+		return editorErrorf(35, "No previous regular expression")
+	}
+	i, err := v.index(minInt(v.cursor+1, v.len()), v.pattern)
+	if err == nil && i < 0 {
+		if i, err = v.index(0, v.pattern); i >= 0 {
+			f.showMessage("search hit BOTTOM, continuing at TOP")
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if i < 0 {
+		return editorErrorf(486, "Pattern not found: %s", v.describePattern())
+	}
+	v.moveTo(i)
+	v.match = i
+	v.scrollToCursor(f.hexRowBytes(), f.textHeight())
+	return nil
+}
+
+// index returns the position of the first occurrence of pattern from from
+// on, or -1 if there is none. Large files are read a block at a time.
+func (v *hexView) index(from int, pattern []byte) (int, error) {
+	buf := make([]byte, hexBlockSize+len(pattern)-1)
+	for pos := from; pos < v.len(); pos += hexBlockSize {
+		n, err := v.readAt(buf, pos)
+		if err != nil {
+			return -1, err
+		}
+		if i := bytes.Index(buf[:n], pattern); i >= 0 {
+			return pos + i, nil
+		}
+	}
+	return -1, nil
+}
+
+func (v *hexView) describePattern() string {
+	if v.ascii {
+		return string(v.pattern)
+	}
+	return fmt.Sprintf("% x", v.pattern)
+}
+
+// showHex draws the hex view: a line for every row of bytes with its offset,
+// the bytes in hex and the bytes as ASCII, where bytes that are not
+// printable are shown as dots.
+func (f *SimpleFrame) showHex() {
+	v := f.hex
+	f.screen.SetStyle(f.style(GroupNormal))
+	f.screen.Clear()
+	w, _ := f.screen.Size()
+	n := hexRowBytes(w)
+	height := f.textHeight()
+	v.scrollToCursor(n, height)
+	for row := 0; row < height; row++ {
+		start := (v.offset + row) * n
+		if start >= v.len() && start > 0 {
+			f.screen.SetContent(0, row, '~', nil, f.style(GroupNonText))
+			continue
+		}
+		f.writeBufferLineAt(fmt.Sprintf("%08x", start), 0, row, f.style(GroupLineNr))
+		for i := start; i < start+n && i < v.len(); i++ {
+			b := v.at(i)
+			normal, text := f.style(GroupNormal), f.style(GroupNormal)
+			if v.match >= 0 && i >= v.match && i < v.match+len(v.pattern) {
+				normal, text = f.style(GroupSearch), f.style(GroupSearch)
+			}
+			c := rune(b)
+			if b < 0x20 || b >= 0x7f {
+				c = '.'
+				if text == f.style(GroupNormal) {
+					text = f.style(GroupNonText)
+				}
+			}
+			col := i - start
+			f.writeBufferLineAt(fmt.Sprintf("%02x", b), hexOffsetWidth+3*col, row, normal)
+			f.screen.SetContent(hexOffsetWidth+3*n+1+col, row, c, nil, text)
+		}
+	}
+	f.writeBufferBottomLine()
+	// The text has to be drawn from scratch when the hex view is left.
+	f.drawn = drawnState{}
+	f.screen.Show()
+	f.showCursor()
+}
+
+// hexCursorScreenPos returns where the cursor is shown in the hex view.
+func (f *SimpleFrame) hexCursorScreenPos() (int, int) {
+	v := f.hex
+	n := f.hexRowBytes()
+	row, col := v.cursor/n-v.offset, v.cursor%n
+	if v.ascii {
+		return hexOffsetWidth + 3*n + 1 + col, row
+	}
+	if v.low {
+		return hexOffsetWidth + 3*col + 1, row
+	}
+	return hexOffsetWidth + 3*col, row
+}
+
+// writeHexFile writes the bytes of the hex view to path as they are. Those
+// of a large file are written to a temporary file that replaces path, which
+// may be the file they are read from.
+func (f *SimpleFrame) writeHexFile(path string) (int64, error) {
+	v := f.hex
+	if v.file != nil {
+		n, err := replaceFile(path, v.writeTo)
+		if err != nil {
+			return n, editorErrorf(212, "Can't open file for writing: %v", err)
+		}
+		return n, nil
+	}
+	if err := os.WriteFile(path, v.data, 0o644); err != nil {
+		return 0, editorErrorf(212, "Can't open file for writing: %v", err)
+	}
+	return int64(len(v.data)), nil
+}
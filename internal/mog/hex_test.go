@@ -0,0 +1,170 @@
+package mog
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// typeKeys sends keys to the frame, with \t, \r and \x1b standing for Tab,
+// Enter and Escape.
+func typeKeys(f *SimpleFrame, keys string) {
+	for _, r := range keys {
+		switch r {
+		case '\t':
+			f.handleEventKey(*NewEventKey(KeyTab, 0, ModNone))
+		case '\r':
+			f.handleEventKey(*NewEventKey(KeyEnter, 0, ModNone))
+		case '\x1b':
+			f.handleEventKey(*NewEventKey(KeyEscape, 0, ModNone))
+		default:
+			f.handleEventKey(*NewEventKey(KeyRune, r, ModNone))
+		}
+	}
+}
+
+func newHexTestFrame(t *testing.T, data string) (*SimpleFrame, *SimulationScreen) {
+	f, ss := newTestFrame(t, []string{""}, 80, 4)
+	f.loadBuffer([]byte(data))
+	assert.NotNil(t, f.hex)
+	return f, ss
+}
+
+func TestIsBinary(t *testing.T) {
+	assert.False(t, isBinary([]byte("text\n")))
+	assert.False(t, isBinary([]byte("\xff\xfea\x00b\x00")))
+	assert.True(t, isBinary([]byte("ELF\x00\x01")))
+}
+
+func TestSimpleFrame_Show_HexView(t *testing.T) {
+	f, ss := newHexTestFrame(t, "\x00\x01ABCDEFGHIJKLMNOPQ\xff")
+	f.Show()
+
+	assert.EqualValues(t, []string{
+		"00000000  00 01 41 42 43 44 45 46 47 48 49 4a 4b 4c 4d 4e  ..ABCDEFGHIJKLMN     ",
+		"00000010  4f 50 51 ff                                      OPQ.                 ",
+		"~                                                                               ",
+		" -- Normal --                                                               unix",
+	}, screenContents(ss))
+	x, y, _ := ss.GetCursor()
+	assert.EqualValues(t, []int{10, 0}, []int{x, y})
+
+	typeKeys(f, "jl\t")
+	f.Show()
+	x, y, _ = ss.GetCursor()
+	assert.EqualValues(t, []int{60, 1}, []int{x, y})
+}
+
+func TestSimpleFrame_handleHexKey_OverwritesBytes(t *testing.T) {
+	f, _ := newHexTestFrame(t, "\x00\x01\x02\x03")
+
+	typeKeys(f, "R4")
+	assert.EqualValues(t, "\x40\x01\x02\x03", string(f.hex.data))
+	typeKeys(f, "1g71")
+	assert.EqualValues(t, "\x41\x71\x02\x03", string(f.hex.data))
+	typeKeys(f, "\tz\x1b")
+	assert.EqualValues(t, "\x41\x71\x7a\x03", string(f.hex.data))
+	assert.EqualValues(t, ModeNormal, f.mode)
+	assert.True(t, f.modified)
+
+	typeKeys(f, "uu")
+	assert.EqualValues(t, "\x41\x01\x02\x03", string(f.hex.data))
+	f.handleEventKey(*NewEventKey(KeyCtrlR, 0, ModNone))
+	assert.EqualValues(t, "\x41\x71\x02\x03", string(f.hex.data))
+	assert.EqualValues(t, 1, f.hex.cursor)
+
+	typeKeys(f, "uuu")
+	assert.EqualValues(t, "\x00\x01\x02\x03", string(f.hex.data))
+	assert.False(t, f.modified, "undoing every change leaves the bytes unmodified")
+	f.handleEventKey(*NewEventKey(KeyCtrlR, 0, ModNone))
+	assert.True(t, f.modified)
+}
+
+func TestSimpleFrame_loadFile_LargeBinaryFile(t *testing.T) {
+	size := largeFileSize
+	largeFileSize = 1
+	defer func() { largeFileSize = size }()
+	path := filepath.Join(t.TempDir(), "file.bin")
+	data := bytes.Repeat([]byte("\x00\x01ab"), hexBlockSize/2)
+	assert.Nil(t, os.WriteFile(path, data, 0o644))
+	f, ss := newTestFrame(t, numberedLines(1), 80, 4)
+	assert.Nil(t, f.loadFile(path))
+	t.Cleanup(f.closeHex)
+
+	assert.NotNil(t, f.hex.file, "the bytes are read when needed")
+	assert.Nil(t, f.hex.data)
+	assert.EqualValues(t, []string{""}, f.buffer.lines(), "binary files are not decoded")
+	f.Show()
+	assert.EqualValues(t, "00000000  00 01 61 62 00 01 61 62 00 01 61 62 00 01 61 62  ..ab..ab..ab..ab     ", screenContents(ss)[0])
+
+	f.hex.moveTo(hexBlockSize + 2)
+	typeKeys(f, "R7a7a\x1b")
+	assert.True(t, f.modified)
+	f.hex.moveTo(0)
+	typeKeys(f, "/7a 7a 00\r")
+	assert.EqualValues(t, hexBlockSize+2, f.hex.cursor)
+
+	assert.Nil(t, f.Execute("w"))
+	assert.False(t, f.modified)
+	bs, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	copy(data[hexBlockSize+2:], "zz")
+	assert.EqualValues(t, data, bs)
+
+	typeKeys(f, "u")
+	assert.True(t, f.modified)
+	typeKeys(f, "u")
+	assert.EqualValues(t, 'b', f.hex.at(hexBlockSize+3))
+}
+
+func TestSimpleFrame_hexSearch(t *testing.T) {
+	f, _ := newHexTestFrame(t, "\x00ab\x00ab\x00")
+
+	typeKeys(f, "/61 62\r")
+	assert.EqualValues(t, 1, f.hex.cursor)
+	typeKeys(f, "n")
+	assert.EqualValues(t, 4, f.hex.cursor)
+	typeKeys(f, "n")
+	assert.EqualValues(t, 1, f.hex.cursor)
+	assert.EqualValues(t, "search hit BOTTOM, continuing at TOP", f.bottomLine())
+
+	typeKeys(f, "\t/ab\r")
+	assert.EqualValues(t, 4, f.hex.cursor)
+	typeKeys(f, "/x\r")
+	assert.EqualValues(t, "E486: Pattern not found: x", f.bottomLine())
+}
+
+func TestSimpleFrame_exWrite_HexViewWritesExactBytes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.bin")
+	data := "\x00\r\n\xff\xfe\n"
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0o644))
+	f, _ := newTestFrame(t, numberedLines(1), 80, 5)
+	assert.Nil(t, f.loadFile(path))
+	assert.NotNil(t, f.hex)
+	assert.EqualValues(t, []string{""}, f.buffer.lines())
+
+	typeKeys(f, "R00")
+	assert.Nil(t, f.Execute("w"))
+
+	bs, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.EqualValues(t, data, string(bs))
+	assert.False(t, f.modified)
+}
+
+func TestSimpleFrame_exHex(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(2), 80, 5)
+
+	assert.Nil(t, f.Execute("hex"))
+	assert.EqualValues(t, "0\n1", string(f.hex.data))
+	typeKeys(f, "R32\x1b")
+
+	assert.Nil(t, f.Execute("hex"))
+	assert.Nil(t, f.hex)
+	assert.EqualValues(t, []string{"2", "1"}, f.buffer.lines())
+	typeKeys(f, "u")
+	assert.EqualValues(t, []string{"0", "1"}, f.buffer.lines())
+}
@@ -0,0 +1,163 @@
+package mog
+
+// HighlightGroup names the kind of text a span of a line contains.
+type HighlightGroup string
+
+const (
+	GroupNormal  HighlightGroup = "Normal"
+	GroupComment HighlightGroup = "Comment"
+	GroupString  HighlightGroup = "String"
+	GroupKeyword HighlightGroup = "Keyword"
+	GroupNumber  HighlightGroup = "Number"
+	GroupBuiltin HighlightGroup = "Builtin"
+)
+
+// Span is a part of a line, given as byte offsets, that is highlighted as
+// a group.
+type Span struct {
+	Start, End int
+	Group      HighlightGroup
+}
+
+// Highlighter splits lines into highlighted spans.
+//
+// How a line is highlighted may depend on the lines before it, e.g. when it
+// is inside a block comment. The state returned for a line is passed when
+// highlighting the next one, the first line of a buffer is highlighted with
+// state 0.
+type Highlighter interface {
+	HighlightLine(line string, state int) ([]Span, int)
+}
+
+// highlightCache holds the spans of every line that has been highlighted,
+// together with the state at the end of it.
+//
+// Lines are highlighted lazily. After an edit only the edited line has to be
+// highlighted again, unless the state at its end changed, in which case the
+// change propagates to the following lines as they are needed.
+type highlightCache struct {
+	highlighter  Highlighter
+	spans        [][]Span
+	ends         []int
+	valid        []bool
+	firstInvalid int
+}
+
+// setHighlighter replaces the highlighter and drops every highlighted line.
+func (c *highlightCache) setHighlighter(h Highlighter) {
+	c.highlighter = h
+	c.spans, c.ends, c.valid = nil, nil, nil
+	c.firstInvalid = 0
+}
+
+// sync makes sure the cache has room for the given number of lines.
+// A line count that changed behind the cache's back drops everything.
+func (c *highlightCache) sync(lines int) {
+	if len(c.valid) != lines {
+		c.spans = make([][]Span, lines)
+		c.ends = make([]int, lines)
+		c.valid = make([]bool, lines)
+		c.firstInvalid = 0
+	}
+}
+
+// line returns the spans of line y of buf.
+func (c *highlightCache) line(buf *lineBuffer, y int) []Span {
+	if c.highlighter == nil {
+		return nil
+	}
+	c.sync(buf.len())
+	c.ensure(buf, y)
+	return c.spans[y]
+}
+
+// ensure highlights every invalid line up to and including line y.
+func (c *highlightCache) ensure(buf *lineBuffer, y int) {
+	for i := c.firstInvalid; i <= y; i++ {
+		if c.valid[i] {
+			continue
+		}
+		c.highlight(buf, i)
+	}
+	if y+1 > c.firstInvalid {
+		c.firstInvalid = y + 1
+	}
+}
+
+// highlight highlights line y of buf, whose start state must be valid, and
+// reports whether the state at its end changed. If it did, the next line is
+// invalidated.
+func (c *highlightCache) highlight(buf *lineBuffer, y int) bool {
+	start := 0
+	if y > 0 {
+		start = c.ends[y-1]
+	}
+	old := c.ends[y]
+	c.spans[y], c.ends[y] = c.highlighter.HighlightLine(buf.line(y), start)
+	c.valid[y] = true
+	if c.ends[y] == old || y+1 >= len(c.valid) {
+		return false
+	}
+	c.valid[y+1] = false
+	return true
+}
+
+// invalidateLine highlights line y of buf again after it has been edited and
+// reports whether the change affects the highlighting of the lines below.
+func (c *highlightCache) invalidateLine(buf *lineBuffer, y int) bool {
+	if c.highlighter == nil {
+		return false
+	}
+	c.sync(buf.len())
+	if y > 0 {
+		c.ensure(buf, y-1)
+	}
+	changed := c.highlight(buf, y)
+	if changed {
+		c.firstInvalid = minInt(c.firstInvalid, y+1)
+	}
+	return changed
+}
+
+// insertLines updates the cache after n lines have been inserted before
+// line y.
+func (c *highlightCache) insertLines(y, n int) {
+	if c.highlighter == nil || y > len(c.valid) {
+		return
+	}
+	c.spans = append(c.spans[:y], append(make([][]Span, n), c.spans[y:]...)...)
+	c.ends = append(c.ends[:y], append(make([]int, n), c.ends[y:]...)...)
+	c.valid = append(c.valid[:y], append(make([]bool, n), c.valid[y:]...)...)
+	if y+n < len(c.valid) {
+		c.valid[y+n] = false
+	}
+	c.firstInvalid = minInt(c.firstInvalid, y)
+}
+
+// deleteLines updates the cache after the n lines starting at line y have
+// been removed.
+func (c *highlightCache) deleteLines(y, n int) {
+	if c.highlighter == nil || y+n > len(c.valid) {
+		return
+	}
+	c.spans = append(c.spans[:y], c.spans[y+n:]...)
+	c.ends = append(c.ends[:y], c.ends[y+n:]...)
+	c.valid = append(c.valid[:y], c.valid[y+n:]...)
+	if y < len(c.valid) {
+		c.valid[y] = false
+	}
+	c.firstInvalid = minInt(c.firstInvalid, y)
+}
+
+// groupAt returns the highlight group of the byte at offset x of a line with
+// the given spans. Spans are sorted, so i is advanced to the span containing
+// x, if any, to make drawing a whole line linear.
+func groupAt(spans []Span, i *int, x int) HighlightGroup {
+	for *i < len(spans) && spans[*i].End <= x {
+		*i++
+	}
+	if *i < len(spans) && spans[*i].Start <= x {
+		return spans[*i].Group
+	}
+	return GroupNormal
+}
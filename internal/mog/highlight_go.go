@@ -0,0 +1,91 @@
+package mog
+
+import (
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// States of goHighlighter at the end of a line.
+const (
+	goStateCode = iota
+	goStateComment
+	goStateRawString
+)
+
+var goBuiltins = map[string]bool{
+	"append": true, "cap": true, "clear": true, "close": true, "complex": true,
+	"copy": true, "delete": true, "imag": true, "len": true, "make": true,
+	"max": true, "min": true, "new": true, "panic": true, "print": true,
+	"println": true, "real": true, "recover": true,
+
+	"any": true, "bool": true, "byte": true, "comparable": true,
+	"complex64": true, "complex128": true, "error": true, "float32": true,
+	"float64": true, "int": true, "int8": true, "int16": true, "int32": true,
+	"int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+
+	"true": true, "false": true, "iota": true, "nil": true,
+}
+
+// goHighlighter highlights Go source code using the tokens produced by
+// go/scanner. Block comments and raw strings spanning several lines are
+// tracked through the line state.
+type goHighlighter struct{}
+
+func (goHighlighter) HighlightLine(line string, state int) ([]Span, int) {
+	var spans []Span
+	pos := 0
+	switch state {
+	case goStateComment, goStateRawString:
+		group, end := GroupComment, "*/"
+		if state == goStateRawString {
+			group, end = GroupString, "`"
+		}
+		i := strings.Index(line, end)
+		if i < 0 {
+			return []Span{{0, len(line), group}}, state
+		}
+		pos = i + len(end)
+		spans = append(spans, Span{0, pos, group})
+	}
+
+	src := []byte(line[pos:])
+	file := token.NewFileSet().AddFile("", -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+
+	state = goStateCode
+	for {
+		p, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		start := pos + file.Offset(p)
+		end := minInt(start+len(lit), len(line))
+		var group HighlightGroup
+		switch {
+		case tok == token.COMMENT:
+			group = GroupComment
+			if strings.HasPrefix(lit, "/*") && (len(lit) < 4 || !strings.HasSuffix(lit, "*/")) {
+				state = goStateComment
+			}
+		case tok == token.STRING || tok == token.CHAR:
+			group = GroupString
+			if strings.HasPrefix(lit, "`") && (len(lit) < 2 || !strings.HasSuffix(lit, "`")) {
+				state = goStateRawString
+			}
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			group = GroupNumber
+		case tok.IsKeyword():
+			group = GroupKeyword
+			end = start + len(tok.String())
+		case tok == token.IDENT && goBuiltins[lit]:
+			group = GroupBuiltin
+		default:
+			continue
+		}
+		spans = append(spans, Span{start, end, group})
+	}
+	return spans, state
+}
@@ -0,0 +1,245 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//nolint:funlen
+func Test_goHighlighter_HighlightLine(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		state         int
+		expectedSpans []Span
+		expectedState int
+	}{
+		{
+			name: "keywords, builtins and numbers",
+			line: "for i := range make([]int, 10) {",
+			expectedSpans: []Span{
+				{0, 3, GroupKeyword},
+				{9, 14, GroupKeyword},
+				{15, 19, GroupBuiltin},
+				{22, 25, GroupBuiltin},
+				{27, 29, GroupNumber},
+			},
+		},
+		{
+			name: "strings and line comments",
+			line: `s := "a // b" + 'c' // d`,
+			expectedSpans: []Span{
+				{5, 13, GroupString},
+				{16, 19, GroupString},
+				{20, 24, GroupComment},
+			},
+		},
+		{
+			name: "unterminated block comment",
+			line: "x /* a",
+			expectedSpans: []Span{
+				{2, 6, GroupComment},
+			},
+			expectedState: goStateComment,
+		},
+		{
+			name:  "inside block comment",
+			line:  "still a comment",
+			state: goStateComment,
+			expectedSpans: []Span{
+				{0, 15, GroupComment},
+			},
+			expectedState: goStateComment,
+		},
+		{
+			name:  "end of block comment",
+			line:  "a */ nil",
+			state: goStateComment,
+			expectedSpans: []Span{
+				{0, 4, GroupComment},
+				{5, 8, GroupBuiltin},
+			},
+		},
+		{
+			name: "unterminated raw string",
+			line: "s := `abc",
+			expectedSpans: []Span{
+				{5, 9, GroupString},
+			},
+			expectedState: goStateRawString,
+		},
+		{
+			name:  "end of raw string",
+			line:  "abc` + 1",
+			state: goStateRawString,
+			expectedSpans: []Span{
+				{0, 4, GroupString},
+				{7, 8, GroupNumber},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spans, state := goHighlighter{}.HighlightLine(tt.line, tt.state)
+			assert.EqualValues(t, tt.expectedSpans, spans)
+			assert.EqualValues(t, tt.expectedState, state)
+		})
+	}
+}
+
+const shellSyntax = `
+# A small shell syntax
+name sh
+extensions .sh .bash
+
+state dstring String
+rule main    Comment  #.*
+rule main    Keyword  \b(if|then|fi)\b
+rule main    String   " -> dstring
+rule dstring String   \\.
+rule dstring String   " -> main
+`
+
+func Test_regexHighlighter_HighlightLine(t *testing.T) {
+	h, err := parseSyntax(strings.NewReader(shellSyntax))
+	assert.Nil(t, err)
+	assert.EqualValues(t, "sh", h.name)
+	assert.EqualValues(t, []string{".sh", ".bash"}, h.extensions)
+
+	spans, state := h.HighlightLine(`if x; then echo "a\"b # c" # d`, 0)
+	assert.EqualValues(t, []Span{
+		{0, 2, GroupKeyword},
+		{6, 10, GroupKeyword},
+		{16, 17, GroupString},
+		{17, 18, GroupString},
+		{18, 20, GroupString},
+		{20, 25, GroupString},
+		{25, 26, GroupString},
+		{27, 30, GroupComment},
+	}, spans)
+	assert.EqualValues(t, 0, state)
+
+	spans, state = h.HighlightLine(`echo "abc`, 0)
+	assert.EqualValues(t, []Span{{5, 6, GroupString}, {6, 9, GroupString}}, spans)
+	assert.EqualValues(t, h.states["dstring"], state)
+}
+
+func Test_regexHighlighter_HighlightLine_SeesWholeLine(t *testing.T) {
+	h, err := parseSyntax(strings.NewReader("rule main Comment ^#.*\nrule main Keyword \\bin\\b\nrule main Number [0-9]+\n"))
+	assert.Nil(t, err)
+
+	spans, _ := h.HighlightLine("#x", 0)
+	assert.EqualValues(t, []Span{{0, 2, GroupComment}}, spans)
+	// Neither does ^ match after the first token nor \b inside a word.
+	spans, _ = h.HighlightLine("1 #x 2in in", 0)
+	assert.EqualValues(t, []Span{{0, 1, GroupNumber}, {5, 6, GroupNumber}, {9, 11, GroupKeyword}}, spans)
+}
+
+func Test_parseSyntax_ReportsLineOfError(t *testing.T) {
+	_, err := parseSyntax(strings.NewReader("name sh\nrule main Keyword (\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func Test_highlighterFor(t *testing.T) {
+	dir := t.TempDir()
+	setEnv(t, "XDG_CONFIG_HOME", dir)
+	err := os.MkdirAll(filepath.Join(dir, "mog", "syntax"), 0o755)
+	assert.Nil(t, err)
+	err = os.WriteFile(filepath.Join(dir, "mog", "syntax", "sh.syntax"), []byte(shellSyntax), 0o644)
+	assert.Nil(t, err)
+
+	h, err := highlighterFor("main.go")
+	assert.Nil(t, err)
+	assert.IsType(t, goHighlighter{}, h)
+
+	h, err = highlighterFor("script.bash")
+	assert.Nil(t, err)
+	assert.IsType(t, &regexHighlighter{}, h)
+
+	h, err = highlighterFor("notes.txt")
+	assert.Nil(t, err)
+	assert.Nil(t, h)
+}
+
+// setEnv sets an environment variable for the duration of a test.
+func setEnv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	assert.Nil(t, os.Setenv(key, value))
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, old)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
+
+// countingHighlighter highlights every line as a comment after a line
+// starting with '{' until a line starting with '}', counting the lines it
+// highlights.
+type countingHighlighter struct {
+	count int
+}
+
+func (h *countingHighlighter) HighlightLine(line string, state int) ([]Span, int) {
+	h.count++
+	switch {
+	case strings.HasPrefix(line, "{"):
+		state = 1
+	case strings.HasPrefix(line, "}"):
+		state = 0
+	}
+	if state == 1 {
+		return []Span{{0, len(line), GroupComment}}, state
+	}
+	return nil, state
+}
+
+func TestHighlightCache_OnlyHighlightsWhatIsNeeded(t *testing.T) {
+	buf := newLineBuffer([]string{"a", "b", "c", "d", "e"})
+	h := &countingHighlighter{}
+	c := &highlightCache{}
+	c.setHighlighter(h)
+
+	assert.Nil(t, c.line(&buf, 2))
+	assert.EqualValues(t, 3, h.count)
+
+	// Editing a line without changing its state only highlights that line
+	buf.setLine(1, "bb")
+	assert.False(t, c.invalidateLine(&buf, 1))
+	assert.EqualValues(t, 4, h.count)
+	c.line(&buf, 2)
+	assert.EqualValues(t, 4, h.count)
+
+	// Changing its state invalidates the lines below it
+	buf.setLine(1, "{")
+	assert.True(t, c.invalidateLine(&buf, 1))
+	assert.EqualValues(t, []Span{{0, 1, GroupComment}}, c.line(&buf, 2))
+	assert.EqualValues(t, []Span{{0, 1, GroupComment}}, c.line(&buf, 4))
+	assert.EqualValues(t, 8, h.count)
+
+	// Inserted lines are highlighted with the state of the line above
+	buf = newLineBuffer([]string{"a", "{", "}", "x", "c", "d", "e"})
+	c.insertLines(2, 2)
+	assert.Nil(t, c.line(&buf, 3))
+	assert.Nil(t, c.line(&buf, 4))
+	assert.EqualValues(t, 11, h.count)
+}
+
+func TestSimpleFrame_InsertRune_RedrawsLinesWhoseHighlightingChanged(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"x", "a", "b"}, 5, 5)
+	f.highlights.setHighlighter(&countingHighlighter{})
+	f.Show()
+
+	f.InsertRune('{')
+	f.Show()
+
+	cells, w, _ := ss.GetContents()
+	assert.EqualValues(t, f.style(GroupComment), cells[1*w].Style)
+	assert.EqualValues(t, f.style(GroupComment), cells[2*w].Style)
+}
@@ -0,0 +1,157 @@
+package mog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// eventInput is posted when text was read from stdin.
+type eventInput struct {
+	EventTime
+}
+
+func newEventInput() Event {
+	ev := &eventInput{}
+	ev.SetEventNow()
+	return ev
+}
+
+// inputReader reads text from stdin in the background, so that the editor
+// can be used before all of it arrived.
+type inputReader struct {
+	mu      sync.Mutex
+	pending []byte
+	// notified is set while an eventInput for the pending text has not
+	// been handled yet.
+	notified bool
+	done     bool
+	err      error
+	stop     chan struct{}
+}
+
+func readInput(screen Screen, r io.Reader) *inputReader {
+	in := &inputReader{stop: make(chan struct{})}
+	go in.run(screen, r)
+	return in
+}
+
+func (in *inputReader) run(screen Screen, r io.Reader) {
+	buf := make([]byte, 64<<10)
+	for {
+		n, err := r.Read(buf)
+		in.mu.Lock()
+		in.pending = append(in.pending, buf[:n]...)
+		if err != nil {
+			in.done = true
+			if err != io.EOF {
+				in.err = err
+			}
+		}
+		notify := !in.notified && len(in.pending) > 0 || in.done
+		in.notified = true
+		done := in.done
+		in.mu.Unlock()
+
+		if notify && !postEvent(screen, newEventInput, done, in.stop) {
+			in.mu.Lock()
+			in.notified = false
+			in.mu.Unlock()
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// take returns the text read since the last call.
+func (in *inputReader) take() (data []byte, done bool, err error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	data, in.pending = in.pending, nil
+	in.notified = false
+	return data, in.done, in.err
+}
+
+// close stops posting events. A read that is waiting for input is not
+// interrupted.
+func (in *inputReader) close() {
+	close(in.stop)
+}
+
+// readStdin starts reading the buffer from r, which is usually stdin. The
+// buffer is a scratch buffer: it has no file and quitting does not ask for
+// its text to be written.
+func (f *SimpleFrame) readStdin(r io.Reader) {
+	f.buffer = newLineBuffer([]string{""})
+	f.fileFormat = ""
+	f.input = readInput(f.screen, r)
+}
+
+// takeInput adds the text read from stdin to the end of the buffer.
+func (f *SimpleFrame) takeInput() {
+	if f.input == nil {
+		return
+	}
+	data, done, err := f.input.take()
+	if err != nil {
+		f.showError(err)
+	}
+	data = append(f.inputHeld, data...)
+	f.inputHeld = nil
+	if f.fileFormat == "" && (len(data) > 0 || done) {
+		f.fileFormat = detectFileFormat(data)
+	}
+	if !done && f.fileFormat == fileFormatDos && bytes.HasSuffix(data, []byte("\r")) {
+		// The \r may be the first half of a line break.
+		f.inputHeld = data[len(data)-1:]
+		data = data[:len(data)-1]
+	}
+	f.appendText(data)
+	if done {
+		f.input.close()
+		f.input = nil
+	}
+	f.followEnd()
+}
+
+// appendText adds text to the end of the buffer as it is read from a file
+// or stdin, splitting it at the line breaks of the file format. Appending is
+// not a change of the buffer that can be undone or has to be written.
+func (f *SimpleFrame) appendText(bs []byte) {
+	if len(bs) == 0 {
+		return
+	}
+	lines := splitLines(bs, f.fileFormat)
+	y := f.buffer.len() - 1
+	f.buffer.setLine(y, f.buffer.line(y)+lines[0])
+	f.lineChanged(y)
+	if len(lines) > 1 {
+		f.buffer.appendLines(lines[1:])
+		f.linesInserted(y+1, len(lines)-1)
+	}
+}
+
+// appendGrowth adds what was appended to the file being edited since it was
+// loaded to the buffer. It reports false if the file changed in any other
+// way, or the buffer cannot simply be extended.
+func (f *SimpleFrame) appendGrowth(info os.FileInfo) bool {
+	old := f.fileInfo
+	if f.modified || f.lazy != nil || f.hex != nil || old == nil || !os.SameFile(old, info) || info.Size() <= old.Size() {
+		return false
+	}
+	file, err := os.Open(f.filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	bs := make([]byte, info.Size()-old.Size())
+	if _, err := file.ReadAt(bs, old.Size()); err != nil && err != io.EOF {
+		return false
+	}
+	f.appendText(decode(bs, f.fileEncoding, false))
+	f.fileInfo = info
+	f.followEnd()
+	return true
+}
@@ -0,0 +1,54 @@
+package mog
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleFrame_readStdin_AddsTextAsItArrives(t *testing.T) {
+	f, _ := newTestFrame(t, []string{""}, 80, 10)
+	r, w := io.Pipe()
+	f.readStdin(r)
+
+	write := func(s string, want ...string) {
+		_, err := w.Write([]byte(s))
+		assert.Nil(t, err)
+		handleEventsUntil(t, f, func() bool { return equalLines(f.buffer.lines(), want) })
+	}
+	write("one\r\ntw", "one", "tw")
+	assert.EqualValues(t, fileFormatDos, f.fileFormat)
+	write("o\r", "one", "two")
+	write("\nthree", "one", "two", "three")
+
+	assert.Nil(t, w.Close())
+	handleEventsUntil(t, f, func() bool { return f.input == nil })
+	assert.EqualValues(t, []string{"one", "two", "three"}, f.buffer.lines())
+	assert.False(t, f.modified)
+	assert.EqualValues(t, 0, len(f.undo.undo))
+}
+
+func TestSimpleFrame_checkFile_FollowingAppendsGrowth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("a\nb"), 0o644))
+	f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+	assert.Nil(t, f.loadFile(path))
+	defer f.closeFile()
+	f.startPager(true)
+	f.InsertText("")
+	f.modified = false
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	assert.Nil(t, err)
+	_, err = file.WriteString("c\nd\n")
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+	f.checkFile()
+
+	assert.EqualValues(t, []string{"a", "bc", "d", ""}, f.buffer.lines())
+	assert.EqualValues(t, 3, f.cursor.YPos())
+	assert.Nil(t, f.prompt)
+}
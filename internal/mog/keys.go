@@ -0,0 +1,84 @@
+package mog
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// namedKeys are the keys that can be written as <{name}> in key sequences,
+// e.g. in scripts.
+var namedKeys = map[string]Key{
+	"esc":      KeyEscape,
+	"cr":       KeyEnter,
+	"enter":    KeyEnter,
+	"return":   KeyEnter,
+	"tab":      KeyTab,
+	"bs":       KeyBackspace2,
+	"del":      KeyDelete,
+	"up":       KeyUp,
+	"down":     KeyDown,
+	"left":     KeyLeft,
+	"right":    KeyRight,
+	"home":     KeyHome,
+	"end":      KeyEnd,
+	"pageup":   KeyPgUp,
+	"pagedown": KeyPgDn,
+}
+
+// namedRunes are the characters that can be written as <{name}>, because
+// they are hard to write or to tell apart otherwise.
+var namedRunes = map[string]rune{
+	"lt":     '<',
+	"space":  ' ',
+	"bslash": '\\',
+	"bar":    '|',
+}
+
+// parseKeys turns a sequence of keys as typed into key events. Special keys
+// are written like <Esc>, <CR> or <C-r>, and <lt> stands for a '<', <Space>
+// for a space. A line break is an Enter. Anything else between angle
+// brackets is taken literally.
+func parseKeys(s string) []*EventKey {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	var keys []*EventKey
+	for len(s) > 0 {
+		if ev, n := parseNamedKey(s); n > 0 {
+			keys = append(keys, ev)
+			s = s[n:]
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s)
+		if r == '\n' {
+			keys = append(keys, NewEventKey(KeyEnter, 0, ModNone))
+		} else {
+			keys = append(keys, NewEventKey(KeyRune, r, ModNone))
+		}
+		s = s[size:]
+	}
+	return keys
+}
+
+// parseNamedKey parses a key written as <{name}> at the start of s and
+// returns it with the length of its name, which is 0 if s does not start
+// with one.
+func parseNamedKey(s string) (*EventKey, int) {
+	if !strings.HasPrefix(s, "<") {
+		return nil, 0
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 0 {
+		return nil, 0
+	}
+	name := strings.ToLower(s[1:end])
+	if r, ok := namedRunes[name]; ok {
+		return NewEventKey(KeyRune, r, ModNone), end + 1
+	}
+	switch {
+	case len(name) == 3 && strings.HasPrefix(name, "c-") && 'a' <= name[2] && name[2] <= 'z':
+		return NewEventKey(KeyCtrlA+Key(name[2]-'a'), 0, ModNone), end + 1
+	}
+	if k, ok := namedKeys[name]; ok {
+		return NewEventKey(k, 0, ModNone), end + 1
+	}
+	return nil, 0
+}
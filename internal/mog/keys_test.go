@@ -0,0 +1,21 @@
+package mog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys("iä<lt><Esc><C-r>:w\r\n<foo>")
+
+	var got []string
+	for _, k := range keys {
+		got = append(got, k.Name())
+	}
+	assert.EqualValues(t, []string{
+		"Rune[i]", "Rune[ä]", "Rune[<]", "Esc", "Ctrl-R", "Rune[:]", "Rune[w]", "Enter",
+		"Rune[<]", "Rune[f]", "Rune[o]", "Rune[o]", "Rune[>]",
+	}, got)
+	assert.EqualValues(t, KeyCtrlR, keys[4].Key())
+}
@@ -0,0 +1,468 @@
+package mog
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// largeFileSize is the size from which on files are loaded lazily: their
+// lines are indexed in the background and only read from disk when they are
+// shown.
+var largeFileSize int64 = 64 << 20
+
+const (
+	// indexChunkSize is how much of a large file is read at once when
+	// looking for the starts of lines.
+	indexChunkSize = 1 << 20
+	// indexReportInterval is how often the lines found so far are handed
+	// to the frame.
+	indexReportInterval = 50 * time.Millisecond
+	// indexStride is how many lines apart the lines of a large file are
+	// whose offsets are kept. Others are found by reading on from them.
+	indexStride = 1024
+)
+
+// eventIndexed is posted when more lines of a large file have been found.
+type eventIndexed struct {
+	EventTime
+}
+
+// lineIndexer looks for the starts of lines in a file in the background.
+type lineIndexer struct {
+	mu sync.Mutex
+	// found is the number of lines found since they were last taken and
+	// checkpoints holds the offsets of every indexStride-th line of them.
+	found       int
+	checkpoints []int64
+	// lines is the number of lines found in total and pos where the
+	// indexer continues reading.
+	lines  int
+	pos    int64
+	done   bool
+	err    error
+	stop   chan struct{}
+	exited chan struct{}
+}
+
+// indexLines looks for the lines of file after its first one.
+func indexLines(screen Screen, file io.ReaderAt) *lineIndexer {
+	ix := &lineIndexer{lines: 1, stop: make(chan struct{}), exited: make(chan struct{})}
+	go ix.run(screen, file)
+	return ix
+}
+
+// run indexes the file until its end or until the indexer is stopped. The
+// frame is told about the lines found on screen, unless it is nil.
+func (ix *lineIndexer) run(screen Screen, file io.ReaderAt) {
+	defer close(ix.exited)
+	buf := make([]byte, indexChunkSize)
+	reported := time.Now()
+	for {
+		select {
+		case <-ix.stop:
+			return
+		default:
+		}
+		ix.mu.Lock()
+		pos, lines := ix.pos, ix.lines
+		ix.mu.Unlock()
+		n, err := file.ReadAt(buf, pos)
+		found := 0
+		var checkpoints []int64
+		for i := 0; i < n; {
+			j := bytes.IndexByte(buf[i:n], '\n')
+			if j < 0 {
+				break
+			}
+			i += j + 1
+			if (lines+found)%indexStride == 0 {
+				checkpoints = append(checkpoints, pos+int64(i))
+			}
+			found++
+		}
+
+		ix.mu.Lock()
+		ix.found += found
+		ix.checkpoints = append(ix.checkpoints, checkpoints...)
+		ix.lines += found
+		ix.pos = pos + int64(n)
+		if err != nil {
+			ix.done = true
+			if err != io.EOF {
+				ix.err = err
+			}
+		}
+		done := ix.done
+		ix.mu.Unlock()
+
+		if screen != nil && (done || time.Since(reported) >= indexReportInterval) {
+			reported = time.Now()
+			postEvent(screen, newEventIndexed, done, ix.stop)
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// postEvent posts the event returned by newEvent. An event that has to
+// arrive is posted again while the event queue is full, until stop is
+// closed. It reports whether the event was posted.
+func postEvent(screen Screen, newEvent func() Event, retry bool, stop <-chan struct{}) bool {
+	for {
+		if screen.PostEvent(newEvent()) == nil {
+			return true
+		}
+		if !retry {
+			return false
+		}
+		select {
+		case <-stop:
+			return false
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func newEventIndexed() Event {
+	ev := &eventIndexed{}
+	ev.SetEventNow()
+	return ev
+}
+
+// take returns the number of lines found since the last call and the
+// offsets of every indexStride-th line among them.
+func (ix *lineIndexer) take() (found int, checkpoints []int64, done bool, err error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	found, checkpoints = ix.found, ix.checkpoints
+	ix.found, ix.checkpoints = 0, nil
+	return found, checkpoints, ix.done, ix.err
+}
+
+// finish indexes the rest of the file without waiting for the background.
+func (ix *lineIndexer) finish(file io.ReaderAt) {
+	close(ix.stop)
+	<-ix.exited
+	ix.stop, ix.exited = make(chan struct{}), make(chan struct{})
+	ix.run(nil, file)
+}
+
+func (ix *lineIndexer) close() {
+	close(ix.stop)
+}
+
+// lazyFile is a large file whose lines are read from disk when needed.
+type lazyFile struct {
+	file *os.File
+	size int64
+	// lines is the number of lines found so far and checkpoints holds the
+	// offset of every indexStride-th of them.
+	lines       int
+	checkpoints []int64
+	indexed     bool
+	crlf        bool
+	indexer     *lineIndexer
+	// reader reads on from the start of line next, the one after the line
+	// last read, so that lines read in order are found without going back
+	// to a checkpoint.
+	reader *bufio.Reader
+	next   int
+}
+
+// line reads line i.
+func (l *lazyFile) line(i int) (string, error) {
+	line, _, err := l.lineAt(i)
+	return line, err
+}
+
+// lineAt reads line i and reports whether another line follows it. The end
+// of the last line found is looked for if the file has not been indexed
+// that far yet.
+func (l *lazyFile) lineAt(i int) (string, bool, error) {
+	c := minInt(i/indexStride, len(l.checkpoints)-1)
+	if l.reader == nil || i < l.next || c > l.next/indexStride {
+		start := l.checkpoints[c]
+		l.reader = bufio.NewReaderSize(io.NewSectionReader(l.file, start, l.size-start), 64<<10)
+		l.next = c * indexStride
+	}
+	for {
+		buf, err := l.reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			l.reader = nil
+			return "", false, err
+		}
+		l.next++
+		if l.next <= i {
+			continue
+		}
+		more := err == nil
+		buf = bytes.TrimSuffix(buf, []byte("\n"))
+		if l.crlf {
+			buf = bytes.TrimSuffix(buf, []byte("\r"))
+		}
+		return string(buf), more, nil
+	}
+}
+
+func (l *lazyFile) close() {
+	l.indexer.close()
+	l.file.Close()
+}
+
+// openLargeFile opens the file at filePath to be loaded lazily, or returns
+// nil if it is too small, binary or not in an encoding and file format lines
+// can be found in without decoding the whole file.
+func openLargeFile(filePath string, info os.FileInfo) (*lazyFile, error) {
+	if info.Size() < largeFileSize {
+		return nil, nil
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, 64<<10)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+	head = head[:n]
+	encoding, bom := detectEncoding(head)
+	format := detectFileFormat(head)
+	if encoding != encodingUTF8 || bom || format == fileFormatMac || isBinary(head) {
+		file.Close()
+		return nil, nil
+	}
+	return &lazyFile{file: file, size: info.Size(), lines: 1, checkpoints: []int64{0}, crlf: format == fileFormatDos}, nil
+}
+
+// loadLargeFile starts editing a large file. Its buffer starts out with
+// the lines found so far, which are read when they are shown.
+func (f *SimpleFrame) loadLargeFile(l *lazyFile) {
+	f.lazy = l
+	f.buffer = lineBuffer{}
+	f.buffer.appendUnread(0, 1)
+	f.fileEncoding = encodingUTF8
+	f.bomb = false
+	f.fileFormat = fileFormatUnix
+	if l.crlf {
+		f.fileFormat = fileFormatDos
+	}
+	f.highlights.setHighlighter(nil)
+	l.indexer = indexLines(f.screen, l.file)
+}
+
+// finishIndexing waits until all lines of a large file have been found.
+func (f *SimpleFrame) finishIndexing() {
+	if !f.lazy.indexed {
+		f.lazy.indexer.finish(f.lazy.file)
+		f.takeIndexed()
+	}
+}
+
+// takeIndexed adds the lines the indexer found to the end of the buffer.
+func (f *SimpleFrame) takeIndexed() {
+	if f.lazy == nil {
+		return
+	}
+	found, checkpoints, done, err := f.lazy.indexer.take()
+	if err != nil {
+		f.showError(err)
+	}
+	if found > 0 {
+		y := f.buffer.len()
+		f.buffer.appendUnread(f.lazy.lines, found)
+		f.linesInserted(y, found)
+		f.lazy.lines += found
+		f.lazy.checkpoints = append(f.lazy.checkpoints, checkpoints...)
+	}
+	f.lazy.indexed = done
+}
+
+// lineText returns line y, reading it from the large file without keeping
+// it in the buffer if it was not read yet, like searches do.
+func (f *SimpleFrame) lineText(y int) string {
+	if i := f.buffer.fileLine(y); i >= 0 {
+		line, err := f.lazy.line(i)
+		if err != nil {
+			f.showError(err)
+		}
+		return line
+	}
+	return f.buffer.line(y)
+}
+
+// ensureLoaded reads the lines from from up to to from disk if they have
+// not been read yet.
+func (f *SimpleFrame) ensureLoaded(from, to int) {
+	if f.lazy == nil {
+		return
+	}
+	from, to = maxInt(from, 0), minInt(to, f.buffer.len())
+	if from >= to {
+		return
+	}
+	var loaded []int
+	err := f.buffer.load(from, to, func(y, i int) (string, error) {
+		line, err := f.lazy.line(i)
+		if err == nil {
+			loaded = append(loaded, y)
+		}
+		return line, err
+	})
+	if err != nil {
+		f.showError(err)
+	}
+	for _, y := range loaded {
+		f.lineChanged(y)
+	}
+}
+
+// ensureVisibleLoaded reads the lines around the view and the cursor.
+func (f *SimpleFrame) ensureVisibleLoaded() {
+	if f.lazy == nil {
+		return
+	}
+	h := f.textHeight()
+	f.ensureLoaded(f.offset-h, f.offset+2*h)
+	f.ensureLoaded(f.cursor.YPos()-h, f.cursor.YPos()+h)
+}
+
+// loadAllLines reads every line of a large file, which is edited like any
+// other file from then on.
+func (f *SimpleFrame) loadAllLines() {
+	if f.lazy == nil {
+		return
+	}
+	f.finishIndexing()
+	f.ensureLoaded(0, f.buffer.len())
+	f.buffer = newLineBuffer(f.buffer.lines())
+	f.closeLargeFile()
+}
+
+// copyLargeFile writes what writing the buffer of a large file would write
+// to w, reading the lines that were never loaded from the original file.
+func (f *SimpleFrame) copyLargeFile(w io.Writer) (int64, error) {
+	encoding := f.fileEncoding
+	if encoding == "" {
+		encoding = encodingUTF8
+	}
+	bw := bufio.NewWriter(w)
+	var written int64
+	write := func(line string, first bool) error {
+		bs, err := encode(line, encoding, first && f.bomb && byteOrderMark(encoding) != nil)
+		if err != nil {
+			return err
+		}
+		n, err := bw.Write(bs)
+		written += int64(n)
+		return err
+	}
+	ending := lineEnding(f.fileFormat)
+	for y := 0; y < f.buffer.len(); y++ {
+		line := f.lineText(y)
+		if y < f.buffer.len()-1 {
+			line += ending
+		}
+		if err := write(line, y == 0); err != nil {
+			return written, err
+		}
+	}
+	// The lines that were not found yet are added to the end of the
+	// buffer once they are.
+	if !f.lazy.indexed {
+		_, more, err := f.lazy.lineAt(f.lazy.lines - 1)
+		for i := f.lazy.lines; more && err == nil; i++ {
+			var line string
+			if line, more, err = f.lazy.lineAt(i); err == nil {
+				err = write(ending+line, false)
+			}
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, bw.Flush()
+}
+
+// writeLargeFile writes the buffer of a large file to path. It is written to
+// a temporary file that replaces path when done, as path may be the file the
+// lines that were never loaded are read from.
+func (f *SimpleFrame) writeLargeFile(path string) (int64, error) {
+	return replaceFile(path, f.copyLargeFile)
+}
+
+// replaceFile replaces the file at path with what write writes, which is
+// written to a temporary file first, keeping the mode of the file.
+func replaceFile(path string, write func(w io.Writer) (int64, error)) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	n, err := write(tmp)
+	if err != nil {
+		tmp.Close()
+		return n, err
+	}
+	if err := tmp.Close(); err != nil {
+		return n, err
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return n, err
+	}
+	return n, os.Rename(tmp.Name(), path)
+}
+
+// reloadLargeFile loads a large file again after it changed on disk. Unlike
+// other files it cannot be compared line by line, so it is not undoable.
+func (f *SimpleFrame) reloadLargeFile(encoding string) error {
+	info, err := os.Stat(f.filePath)
+	if err != nil {
+		return err
+	}
+	lazy, err := openLargeFile(f.filePath, info)
+	if err != nil {
+		return err
+	}
+	f.closeLargeFile()
+	f.undo = undoHistory{}
+	f.layout = layout{}
+	if lazy != nil && (encoding == "" || encoding == encodingUTF8) {
+		f.loadLargeFile(lazy)
+	} else {
+		if lazy != nil {
+			lazy.file.Close()
+		}
+		bs, err := os.ReadFile(f.filePath)
+		if err != nil {
+			return err
+		}
+		f.buffer = newLineBuffer(f.decodeFile(bs, encoding))
+		f.highlights.setHighlighter(nil)
+	}
+	f.damage.markAll()
+	f.modified = false
+	f.fileInfo = info
+	f.updateSwapFile()
+	f.moveCursorTo(bufferPos{f.cursor.XPos(), f.cursor.YPos()})
+	return nil
+}
+
+// closeLargeFile stops reading lines from a large file.
+func (f *SimpleFrame) closeLargeFile() {
+	if f.lazy != nil {
+		f.lazy.close()
+		f.lazy = nil
+	}
+}
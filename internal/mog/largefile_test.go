@@ -0,0 +1,186 @@
+package mog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newLargeFileTestFrame returns a frame editing a file with the given
+// contents, which is loaded lazily however small it is.
+func newLargeFileTestFrame(t *testing.T, contents string) (*SimpleFrame, string) {
+	size := largeFileSize
+	largeFileSize = 1
+	defer func() { largeFileSize = size }()
+	path := filepath.Join(t.TempDir(), "file.txt")
+	assert.Nil(t, os.WriteFile(path, []byte(contents), 0o644))
+	f, _ := newTestFrame(t, []string{""}, 20, 5)
+	assert.Nil(t, f.loadFile(path))
+	assert.NotNil(t, f.lazy)
+	t.Cleanup(f.closeLargeFile)
+	return f, path
+}
+
+// handleEventsUntil handles the events of the frame until done returns true.
+func handleEventsUntil(t *testing.T, f *SimpleFrame, done func() bool) {
+	for !done() {
+		events := make(chan Event, 1)
+		go func() { events <- f.screen.PollEvent() }()
+		select {
+		case ev := <-events:
+			f.HandleEvent(ev)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for events")
+		}
+	}
+}
+
+// waitIndexed handles the events of the frame until its file is indexed.
+func waitIndexed(t *testing.T, f *SimpleFrame) {
+	handleEventsUntil(t, f, func() bool { return f.lazy.indexed })
+}
+
+func numberedText(n int, ending string) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "line %d%s", i, ending)
+	}
+	return b.String()
+}
+
+func TestSimpleFrame_loadFile_LargeFileReadsVisibleLinesOnly(t *testing.T) {
+	f, _ := newLargeFileTestFrame(t, numberedText(100, "\n"))
+	waitIndexed(t, f)
+	f.Show()
+
+	assert.EqualValues(t, 101, f.buffer.len())
+	assert.EqualValues(t, "line 0", f.buffer.line(0))
+	assert.EqualValues(t, "line 3", f.buffer.line(3))
+	assert.EqualValues(t, "", f.buffer.line(50))
+	assert.EqualValues(t, 50, f.buffer.fileLine(50))
+
+	f.offset = 48
+	f.Show()
+	assert.EqualValues(t, "line 50", f.buffer.line(50))
+	assert.EqualValues(t, -1, f.buffer.fileLine(50))
+	assert.EqualValues(t, []linePiece{
+		{lines: f.buffer.slice(0, 8)}, {line: 8, n: 36}, {lines: f.buffer.slice(44, 56)}, {line: 56, n: 45},
+	}, f.buffer.pieces)
+}
+
+func TestSimpleFrame_LargeFileKeepsSparseIndex(t *testing.T) {
+	f, _ := newLargeFileTestFrame(t, numberedText(3000, "\n"))
+	waitIndexed(t, f)
+	assert.EqualValues(t, []int64{0, 9130, 19370}, f.lazy.checkpoints)
+	assert.EqualValues(t, "line 2500", f.lineText(2500))
+	assert.EqualValues(t, "line 10", f.lineText(10))
+	assert.EqualValues(t, "line 2999", f.lineText(2999))
+
+	f.cursor.MoveTo(0, 100)
+	f.Paste("a\nb\n")
+	assert.EqualValues(t, 2002, f.buffer.fileLine(2004))
+	assert.EqualValues(t, "line 2002", f.lineText(2004))
+
+	// Searching reads the lines it looks at without keeping them.
+	assert.Nil(t, f.searchText("line 2900"))
+	assert.EqualValues(t, 2902, f.cursor.YPos())
+	assert.EqualValues(t, "line 2900", f.buffer.line(2902))
+	assert.EqualValues(t, 1500, f.buffer.fileLine(1502))
+	assert.EqualValues(t, "", f.buffer.line(1502))
+}
+
+// Nothing is kept per line of a large file that was not read, so opening a
+// file with millions of lines takes as much memory as opening a short one.
+func TestSimpleFrame_LargeFileDoesNotGrowWithLines(t *testing.T) {
+	sizes := map[int][]int{}
+	for _, lines := range []int{1000000, 3000000} {
+		f, _ := newLargeFileTestFrame(t, strings.Repeat("x\n", lines))
+		f.finishIndexing()
+		f.Show()
+		f.moveCursorTo(bufferPos{0, lines})
+		f.Show()
+		f.moveCursorTo(bufferPos{0, lines / 2})
+		f.Show()
+		assert.EqualValues(t, lines+1, f.buffer.len())
+		assert.EqualValues(t, "x", f.buffer.line(lines/2))
+		sizes[lines] = []int{len(f.buffer.pieces), len(f.layout.heights), len(f.highlights.valid)}
+	}
+	assert.EqualValues(t, sizes[1000000], sizes[3000000])
+	assert.LessOrEqual(t, sizes[3000000][1], layoutWindow)
+}
+
+func TestSimpleFrame_exWrite_LargeFile(t *testing.T) {
+	f, path := newLargeFileTestFrame(t, numberedText(100, "\r\n"))
+	waitIndexed(t, f)
+	assert.EqualValues(t, fileFormatDos, f.fileFormat)
+	f.cursor.MoveTo(0, 99)
+	f.InsertText("x")
+	f.cursor.MoveTo(0, 2)
+	f.replaceLines(2, 2, nil)
+
+	assert.Nil(t, f.Execute("w"))
+
+	bs, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	want := strings.Replace(numberedText(100, "\r\n"), "line 99", "xline 99", 1)
+	want = strings.Replace(want, "line 2\r\nline 3\r\n", "", 1)
+	assert.EqualValues(t, want, string(bs))
+	assert.False(t, f.modified)
+
+	// The lines that were not read yet still come from the original file
+	f.cursor.MoveTo(0, 60)
+	f.InsertText("y")
+	assert.Nil(t, f.Execute("w"))
+	bs, err = os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.EqualValues(t, strings.Replace(want, "line 62", "yline 62", 1), string(bs))
+}
+
+func TestSimpleFrame_exWrite_LargeFileBeforeIndexing(t *testing.T) {
+	f, path := newLargeFileTestFrame(t, numberedText(1000, "\n"))
+	// The first line is read even though its end was not found yet
+	f.InsertText("x")
+
+	assert.Nil(t, f.Execute("w"))
+
+	bs, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "x"+numberedText(1000, "\n"), string(bs))
+	assert.False(t, f.lazy.indexed, "writing does not wait for the lines to be found")
+	waitIndexed(t, f)
+	assert.EqualValues(t, 1001, f.buffer.len())
+	assert.EqualValues(t, "line 999", f.lineText(999))
+}
+
+func TestOpenLargeFile(t *testing.T) {
+	size := largeFileSize
+	largeFileSize = 4
+	defer func() { largeFileSize = size }()
+	dir := t.TempDir()
+	for _, test := range []struct {
+		contents string
+		lazy     bool
+	}{
+		{contents: "abc", lazy: false},
+		{contents: "abc\ndef\n", lazy: true},
+		{contents: "ab\xe9\ndef\n", lazy: false},
+		{contents: "abc\rdef\r", lazy: false},
+		{contents: "\xef\xbb\xbfabc\n", lazy: false},
+	} {
+		path := filepath.Join(dir, "file.txt")
+		assert.Nil(t, os.WriteFile(path, []byte(test.contents), 0o644))
+		info, err := os.Stat(path)
+		assert.Nil(t, err)
+		l, err := openLargeFile(path, info)
+		assert.Nil(t, err)
+		assert.EqualValues(t, test.lazy, l != nil, test.contents)
+		if l != nil {
+			l.file.Close()
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package mog
+
+// layoutWindow is the largest number of lines whose heights are kept.
+const layoutWindow = 1 << 14
+
+// layout caches how the lines of a buffer wrap on the screen, so that
+// mapping buffer positions to screen positions only has to look at the
+// lines between the top of the screen and the position itself.
+//
+// The number of screen lines each buffer line occupies is computed lazily
+// and kept until the line is edited or the width of the screen or tabstop
+// changes. Heights are only kept for a window of lines around the ones
+// looked at last, so that a file with millions of lines does not need
+// anything per line.
+// The screen line on which each buffer line starts is cached relative to
+// the buffer line shown at the top of the screen.
+type layout struct {
+	width   int
+	tabStop int
+	lines   int
+	// heights holds the heights of the lines from line first on, or 0 for
+	// the ones not computed yet.
+	first   int
+	heights []int
+	top     int
+	starts  []int
+}
+
+// sync makes sure the cache matches a buffer with the given number of lines
+// and tabstop displayed on a screen of the given width. Changing the width
+// or tabstop drops every cached height, while a line count that changed
+// behind the cache's back drops everything.
+func (l *layout) sync(width, tabStop, lines int) {
+	if width != l.width || tabStop != l.tabStop {
+		l.width, l.tabStop = width, tabStop
+		for i := range l.heights {
+			l.heights[i] = 0
+		}
+		l.starts = l.starts[:0]
+	}
+	if l.lines != lines {
+		l.lines = lines
+		l.first, l.heights = 0, l.heights[:0]
+		l.starts = l.starts[:0]
+	}
+}
+
+// lineHeight returns the number of screen lines line y of buf occupies.
+func (l *layout) lineHeight(buf *lineBuffer, y int) int {
+	i := l.slot(y)
+	if i >= 0 && l.heights[i] != 0 {
+		return l.heights[i]
+	}
+	h := 1
+	if l.width > 0 {
+		h = 1 + lineWidth(buf.line(y), l.tabStop)/l.width
+	}
+	if i >= 0 {
+		l.heights[i] = h
+	}
+	return h
+}
+
+// slot returns the index in heights of the height of line y, moving the
+// window of kept heights to y if it is not in it, or -1 if y is not a line.
+func (l *layout) slot(y int) int {
+	if y < 0 || y >= l.lines {
+		return -1
+	}
+	if i := y - l.first; i >= 0 && i < len(l.heights) {
+		return i
+	}
+	if y == l.first+len(l.heights) && len(l.heights) < layoutWindow {
+		l.heights = append(l.heights, 0)
+		return y - l.first
+	}
+	first := clampInt(y-layoutWindow/2, 0, maxInt(l.lines-layoutWindow, 0))
+	heights := make([]int, minInt(layoutWindow, l.lines-first))
+	if from, to := maxInt(first, l.first), minInt(first+len(heights), l.first+len(l.heights)); from < to {
+		copy(heights[from-first:to-first], l.heights[from-l.first:to-l.first])
+	}
+	l.first, l.heights = first, heights
+	return y - first
+}
+
+// cachedHeight returns the cached height of line y, or 0 if it is not
+// known.
+func (l *layout) cachedHeight(y int) int {
+	if i := y - l.first; i >= 0 && i < len(l.heights) {
+		return l.heights[i]
+	}
+	return 0
+}
+
+// start returns the screen line on which line y of buf starts when line top
+// is displayed on the first line of the screen. y may be equal to the
+// number of lines in buf, which gives the first screen line after the
+// buffer.
+func (l *layout) start(buf *lineBuffer, top, y int) int {
+	if top != l.top {
+		l.top = top
+		l.starts = l.starts[:0]
+	}
+	if len(l.starts) == 0 {
+		l.starts = append(l.starts, 0)
+	}
+	for i := len(l.starts); i <= y-top; i++ {
+		l.starts = append(l.starts, l.starts[i-1]+l.lineHeight(buf, top+i-1))
+	}
+	return l.starts[y-top]
+}
+
+// invalidateLine drops the cached layout of line y after it has been edited.
+// Only the lines below it have to be laid out again.
+func (l *layout) invalidateLine(y int) {
+	if i := y - l.first; i >= 0 && i < len(l.heights) {
+		l.heights[i] = 0
+	}
+	l.truncateStarts(y + 1)
+}
+
+// insertLines updates the cache after n lines have been inserted before
+// line y.
+func (l *layout) insertLines(y, n int) {
+	l.lines += n
+	switch i := y - l.first; {
+	case i < 0:
+		l.first += n
+	case i < len(l.heights):
+		// Only as many lines as fit into the window are added to it.
+		k := minInt(n, layoutWindow-i)
+		tail := l.heights[i:minInt(len(l.heights), layoutWindow-k)]
+		heights := make([]int, i+k, i+k+len(tail))
+		copy(heights, l.heights[:i])
+		l.heights = append(heights, tail...)
+	}
+	l.truncateStarts(y + 1)
+}
+
+// deleteLines updates the cache after the n lines starting at line y have
+// been removed.
+func (l *layout) deleteLines(y, n int) {
+	l.lines -= n
+	from, to := clampInt(y-l.first, 0, len(l.heights)), clampInt(y+n-l.first, 0, len(l.heights))
+	l.heights = append(l.heights[:from], l.heights[to:]...)
+	l.first -= clampInt(l.first-y, 0, n)
+	l.truncateStarts(y + 1)
+}
+
+// truncateStarts drops the cached start of every line from line y onwards.
+func (l *layout) truncateStarts(y int) {
+	if i := y - l.top; i < len(l.starts) {
+		l.starts = l.starts[:maxInt(i, 0)]
+	}
+}
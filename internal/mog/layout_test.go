@@ -0,0 +1,169 @@
+package mog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayout_start(t *testing.T) {
+	buf := newLineBuffer([]string{"a", "abcde", "", "abcdefg", "a"})
+	l := &layout{}
+	l.sync(3, defaultTabStop, buf.len())
+
+	assert.EqualValues(t, []int{0, 1, 3, 4, 7}, []int{
+		l.start(&buf, 0, 0),
+		l.start(&buf, 0, 1),
+		l.start(&buf, 0, 2),
+		l.start(&buf, 0, 3),
+		l.start(&buf, 0, 4),
+	})
+	assert.EqualValues(t, 8, l.start(&buf, 0, 5))
+	assert.EqualValues(t, 3, l.start(&buf, 1, 3))
+}
+
+func TestLayout_invalidateLine(t *testing.T) {
+	buf := newLineBuffer([]string{"a", "ab", "a"})
+	l := &layout{}
+	l.sync(3, defaultTabStop, buf.len())
+	assert.EqualValues(t, 2, l.start(&buf, 0, 2))
+
+	buf.setLine(1, "abcd")
+	l.invalidateLine(1)
+	assert.EqualValues(t, 1, l.start(&buf, 0, 1))
+	assert.EqualValues(t, 3, l.start(&buf, 0, 2))
+}
+
+func TestLayout_insertAndDeleteLines(t *testing.T) {
+	buf := newLineBuffer([]string{"a", "abcd", "a"})
+	l := &layout{}
+	l.sync(3, defaultTabStop, buf.len())
+	assert.EqualValues(t, 3, l.start(&buf, 0, 2))
+
+	buf = newLineBuffer([]string{"a", "abcdefg", "", "abcd", "a"})
+	l.insertLines(1, 2)
+	assert.EqualValues(t, 2, l.heights[3], "height of the moved line is kept")
+	assert.EqualValues(t, 7, l.start(&buf, 0, 4))
+
+	buf = newLineBuffer([]string{"a", "a"})
+	l.deleteLines(1, 3)
+	assert.EqualValues(t, 1, l.start(&buf, 0, 1))
+}
+
+func TestLayout_sync_ResizeDropsHeights(t *testing.T) {
+	buf := newLineBuffer([]string{"abcd", "a"})
+	l := &layout{}
+	l.sync(3, defaultTabStop, buf.len())
+	assert.EqualValues(t, 2, l.start(&buf, 0, 1))
+
+	l.sync(4, defaultTabStop, buf.len())
+	assert.EqualValues(t, 2, l.start(&buf, 0, 1))
+	l.sync(5, defaultTabStop, buf.len())
+	assert.EqualValues(t, 1, l.start(&buf, 0, 1))
+}
+
+func TestLayout_KeepsWindowOfHeights(t *testing.T) {
+	lines := make([]string, 3*layoutWindow)
+	lines[2*layoutWindow] = "abcd"
+	buf := newLineBuffer(lines)
+	l := &layout{}
+	l.sync(3, defaultTabStop, buf.len())
+	assert.EqualValues(t, 2, l.start(&buf, 0, 2))
+
+	top := 2*layoutWindow - 1
+	assert.EqualValues(t, 3, l.start(&buf, top, top+2))
+	assert.EqualValues(t, layoutWindow, len(l.heights))
+	assert.EqualValues(t, 2, l.cachedHeight(top+1))
+	assert.EqualValues(t, 0, l.cachedHeight(0), "heights far from the window are dropped")
+
+	l.insertLines(10, 5)
+	assert.EqualValues(t, 2, l.cachedHeight(top+6), "height of the moved line is kept")
+	l.deleteLines(top+1, 10)
+	assert.EqualValues(t, layoutWindow-10, len(l.heights))
+	assert.EqualValues(t, 3*layoutWindow-5, l.lines)
+}
+
+func TestSimpleFrame_InsertRune_UpdatesLayout(t *testing.T) {
+	ss := NewSimulationScreen()
+	ss.SetSize(3, 5)
+	f := &SimpleFrame{
+		screen: ss,
+		buffer: newLineBuffer([]string{"ab", "cd"}),
+		cursor: NewSimpleCursorAt(0, 0),
+	}
+	_, y := f.bufferPosToViewPos(0, 1)
+	assert.EqualValues(t, 1, y)
+
+	f.InsertRune('x')
+	_, y = f.bufferPosToViewPos(0, 1)
+	assert.EqualValues(t, 2, y)
+}
+
+func benchmarkWriteBufferToScreen(b *testing.B, lines, lineLength int) {
+	ss := NewSimulationScreen()
+	if err := ss.Init(); err != nil {
+		b.Fatal(err)
+	}
+	ss.SetSize(80, 25)
+	buf := make([]string, lines)
+	for i := range buf {
+		buf[i] = strings.Repeat("x", lineLength)
+	}
+	f := &SimpleFrame{
+		screen: ss,
+		buffer: newLineBuffer(buf),
+		cursor: NewSimpleCursorAt(0, lines/2),
+		mode:   ModeNormal,
+		offset: lines / 2,
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.writeBufferToScreen()
+		f.showCursor()
+	}
+}
+
+// The time it takes to redraw the screen should only depend on what fits on
+// it, not on the size of the buffer or the length of the lines that are not
+// visible.
+func BenchmarkSimpleFrame_writeBufferToScreen(b *testing.B) {
+	for _, lines := range []int{100, 10000, 1000000} {
+		for _, lineLength := range []int{10, 1000} {
+			b.Run(fmt.Sprintf("lines=%d/length=%d", lines, lineLength), func(b *testing.B) {
+				benchmarkWriteBufferToScreen(b, lines, lineLength)
+			})
+		}
+	}
+}
+
+func BenchmarkSimpleFrame_InsertRune(b *testing.B) {
+	for _, lines := range []int{100, 10000, 1000000} {
+		b.Run(fmt.Sprintf("lines=%d", lines), func(b *testing.B) {
+			ss := NewSimulationScreen()
+			if err := ss.Init(); err != nil {
+				b.Fatal(err)
+			}
+			ss.SetSize(80, 25)
+			buf := make([]string, lines)
+			for i := range buf {
+				buf[i] = strings.Repeat("x", 100)
+			}
+			f := &SimpleFrame{
+				screen: ss,
+				buffer: newLineBuffer(buf),
+				cursor: NewSimpleCursorAt(0, lines/2),
+				mode:   ModeInsert,
+				offset: lines/2 - 10,
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				f.buffer.setLine(lines/2, buf[lines/2][:100])
+				f.lineChanged(lines / 2)
+				f.handleEventRune('a')
+				f.writeBufferToScreen()
+			}
+		})
+	}
+}
@@ -0,0 +1,477 @@
+package mog
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// mapModes is a set of the modes mappings apply in.
+type mapModes int
+
+const (
+	mapNormal mapModes = 1 << iota
+	mapVisual
+	mapInsert
+	mapCommand
+)
+
+var mapModeNames = []struct {
+	mode mapModes
+	name string
+}{
+	{mapNormal, "n"},
+	{mapVisual, "v"},
+	{mapInsert, "i"},
+	{mapCommand, "c"},
+}
+
+// The values of timeoutlen and mapleader when nothing set them.
+const (
+	defaultTimeoutLen = 1000
+	defaultMapLeader  = "\\"
+)
+
+// maxMapDepth is how often mappings may lead to other mappings before
+// the keys are taken to map to each other forever.
+const maxMapDepth = 1000
+
+// keyCode identifies a key in a sequence of keys.
+type keyCode struct {
+	key Key
+	ch  rune
+}
+
+func codeOf(ev EventKey) keyCode {
+	if ev.Key() != KeyRune {
+		return keyCode{key: ev.Key()}
+	}
+	return keyCode{key: KeyRune, ch: ev.Rune()}
+}
+
+// mapping makes typing the keys of lhs type the keys of rhs instead. The
+// keys of rhs are mapped again unless noremap is set.
+type mapping struct {
+	// lhs and rhs are written as they were given.
+	lhs, rhs string
+	keys     []*EventKey
+	noremap  bool
+	// silent mappings do not show the command lines they run, buffer
+	// mappings apply to the current buffer only.
+	silent bool
+	buffer bool
+}
+
+// keyTrie holds the mappings of a mode by their keys. Every node stands for
+// the sequence of keys leading to it.
+type keyTrie struct {
+	next map[keyCode]*keyTrie
+	// global and buffer are the mappings of the sequence of the node, for
+	// any buffer and for the current one.
+	global, buffer *mapping
+}
+
+// mapping returns the mapping of the sequence of n, with the one for the
+// buffer taking precedence.
+func (n *keyTrie) mapping() *mapping {
+	if n.buffer != nil {
+		return n.buffer
+	}
+	return n.global
+}
+
+func (n *keyTrie) add(keys []keyCode, m *mapping) {
+	for _, k := range keys {
+		next := n.next[k]
+		if next == nil {
+			if n.next == nil {
+				n.next = make(map[keyCode]*keyTrie)
+			}
+			next = &keyTrie{}
+			n.next[k] = next
+		}
+		n = next
+	}
+	if m.buffer {
+		n.buffer = m
+	} else {
+		n.global = m
+	}
+}
+
+// remove removes the mapping of keys, the one for the buffer if buffer is
+// set, and reports whether there was one. Nodes left without mappings are
+// removed as well.
+func (n *keyTrie) remove(keys []keyCode, buffer bool) bool {
+	if len(keys) == 0 {
+		removed := n.global != nil
+		if buffer {
+			removed = n.buffer != nil
+			n.buffer = nil
+		} else {
+			n.global = nil
+		}
+		return removed
+	}
+	next := n.next[keys[0]]
+	if next == nil || !next.remove(keys[1:], buffer) {
+		return false
+	}
+	if next.empty() {
+		delete(n.next, keys[0])
+	}
+	return true
+}
+
+func (n *keyTrie) empty() bool {
+	return n.global == nil && n.buffer == nil && len(n.next) == 0
+}
+
+// clearBuffer removes every mapping for the buffer.
+func (n *keyTrie) clearBuffer() {
+	n.buffer = nil
+	for k, next := range n.next {
+		next.clearBuffer()
+		if next.empty() {
+			delete(n.next, k)
+		}
+	}
+}
+
+// each calls fn with every mapping in the trie.
+func (n *keyTrie) each(fn func(m *mapping)) {
+	if n.buffer != nil {
+		fn(n.buffer)
+	}
+	if n.global != nil {
+		fn(n.global)
+	}
+	for _, next := range n.next {
+		next.each(fn)
+	}
+}
+
+// typedKey is a key waiting to be handled. Keys that a mapping typed
+// remember it, and are not mapped again if noremap is set.
+type typedKey struct {
+	ev      EventKey
+	noremap bool
+	mapping *mapping
+}
+
+// eventKeyTimeout is posted when keys that could be the start of a mapping
+// were held for timeoutlen milliseconds.
+type eventKeyTimeout struct {
+	EventTime
+	wait chan struct{}
+}
+
+// mapMode returns the mode mappings currently apply in, or 0 if none do.
+func (f *SimpleFrame) mapMode() mapModes {
+	switch {
+	case f.prompt != nil:
+		return 0
+	case f.mode == ModeCommand:
+		return mapCommand
+	case f.hex != nil:
+		return 0
+	case f.mode == ModeNormal:
+		return mapNormal
+	case f.mode == ModeVisual:
+		return mapVisual
+	case f.mode == ModeInsert:
+		return mapInsert
+	}
+	return 0
+}
+
+// runTypeahead handles the keys waiting to be handled, replacing those
+// that were mapped by the keys they map to. Keys that could be the start of
+// a longer mapping are held until more keys are typed or, if timedOut is
+// set, the wait for them has ended.
+func (f *SimpleFrame) runTypeahead(timedOut bool) {
+	depth := 0
+	for len(f.typeahead) > 0 && !f.closed {
+		m, n, more := f.matchMapping()
+		if more && !timedOut {
+			f.startKeyTimeout()
+			return
+		}
+		if m == nil {
+			t := f.typeahead[0]
+			f.typeahead = f.typeahead[1:]
+			f.mapped = t.mapping
+			f.dispatchKey(t.ev)
+			continue
+		}
+		if depth++; depth > maxMapDepth {
+			f.typeahead = nil
+			f.showError(editorErrorf(223, "recursive mapping"))
+			return
+		}
+		// Like in vim, a mapping whose keys start with its own does not
+		// map those again.
+		recursive := len(m.keys) >= n && sameKeys(m.keys[:n], f.typeahead[:n])
+		keys := make([]typedKey, len(m.keys), len(m.keys)+len(f.typeahead)-n)
+		for i, ev := range m.keys {
+			keys[i] = typedKey{ev: *ev, noremap: m.noremap || recursive && i < n, mapping: m}
+		}
+		f.typeahead = append(keys, f.typeahead[n:]...)
+	}
+	f.mapped = nil
+}
+
+func sameKeys(keys []*EventKey, typed []typedKey) bool {
+	for i, ev := range keys {
+		if codeOf(*ev) != codeOf(typed[i].ev) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchMapping returns the longest mapping the waiting keys start with and
+// the number of its keys. more reports whether the keys are the start of a
+// longer mapping.
+func (f *SimpleFrame) matchMapping() (m *mapping, n int, more bool) {
+	node := f.mappings[f.mapMode()]
+	for i, t := range f.typeahead {
+		if node == nil || t.noremap {
+			return m, n, false
+		}
+		node = node.next[codeOf(t.ev)]
+		if node == nil {
+			break
+		}
+		if found := node.mapping(); found != nil {
+			m, n = found, i+1
+		}
+	}
+	return m, n, node != nil && len(node.next) > 0
+}
+
+// startKeyTimeout waits timeoutlen milliseconds for more keys. A headless
+// frame is given all keys at once and does not wait.
+func (f *SimpleFrame) startKeyTimeout() {
+	f.stopKeyTimeout()
+	if f.headless {
+		return
+	}
+	wait := make(chan struct{})
+	f.keyWait = wait
+	screen, d := f.screen, time.Duration(f.timeoutLen)*time.Millisecond
+	go func() {
+		select {
+		case <-wait:
+			return
+		case <-time.After(d):
+		}
+		postEvent(screen, func() Event {
+			ev := &eventKeyTimeout{wait: wait}
+			ev.SetEventNow()
+			return ev
+		}, true, wait)
+	}()
+}
+
+func (f *SimpleFrame) stopKeyTimeout() {
+	if f.keyWait != nil {
+		close(f.keyWait)
+		f.keyWait = nil
+	}
+}
+
+// handleKeyTimeout handles the keys that were held if ev ended the current
+// wait for more keys.
+func (f *SimpleFrame) handleKeyTimeout(ev *eventKeyTimeout) {
+	if ev.wait != f.keyWait {
+		return
+	}
+	f.keyWait = nil
+	f.runTypeahead(true)
+}
+
+// flushKeys handles the keys that are held without waiting any longer.
+func (f *SimpleFrame) flushKeys() {
+	f.stopKeyTimeout()
+	f.runTypeahead(true)
+}
+
+// exMap returns the implementation of a command that maps keys in modes,
+// like :nmap or :noremap. :map, :noremap and :unmap apply to normal and
+// visual mode, followed by a '!' to insert and command line mode instead.
+func exMap(modes mapModes, noremap bool) func(f *SimpleFrame, args string) error {
+	return func(f *SimpleFrame, args string) error {
+		return f.mapKeys(bangModes(modes, &args), noremap, args)
+	}
+}
+
+func exUnmap(modes mapModes) func(f *SimpleFrame, args string) error {
+	return func(f *SimpleFrame, args string) error {
+		return f.unmapKeys(bangModes(modes, &args), args)
+	}
+}
+
+func bangModes(modes mapModes, args *string) mapModes {
+	if modes == mapNormal|mapVisual && strings.HasPrefix(*args, "!") {
+		*args = strings.TrimSpace((*args)[1:])
+		return mapInsert | mapCommand
+	}
+	return modes
+}
+
+// mapKeys implements the commands that map keys.
+//
+//	:map [<silent>] [<buffer>] {lhs} {rhs}  map lhs to rhs
+//	:map {lhs}                              list the mappings starting with lhs
+//	:map                                    list every mapping
+//
+// Keys are written like in scripts, and <Leader> stands for mapleader.
+func (f *SimpleFrame) mapKeys(modes mapModes, noremap bool, args string) error {
+	m := &mapping{noremap: noremap}
+	args = parseMapAttrs(args, m)
+	lhs, rhs := args, ""
+	if i := strings.IndexAny(args, " \t"); i >= 0 {
+		lhs, rhs = args[:i], strings.TrimSpace(args[i:])
+	}
+	if rhs == "" {
+		f.listMappings(modes, lhs)
+		return nil
+	}
+	m.lhs, m.rhs = lhs, rhs
+	m.keys = parseKeys(f.expandLeader(rhs))
+	keys := f.mapKeyCodes(lhs)
+	if f.mappings == nil {
+		f.mappings = make(map[mapModes]*keyTrie)
+	}
+	for _, mode := range mapModeNames {
+		if modes&mode.mode == 0 {
+			continue
+		}
+		if f.mappings[mode.mode] == nil {
+			f.mappings[mode.mode] = &keyTrie{}
+		}
+		f.mappings[mode.mode].add(keys, m)
+	}
+	return nil
+}
+
+// unmapKeys implements the commands that remove mappings, :unmap
+// [<buffer>] {lhs}.
+func (f *SimpleFrame) unmapKeys(modes mapModes, args string) error {
+	m := &mapping{}
+	lhs := parseMapAttrs(args, m)
+	if lhs == "" {
+		return editorErrorf(474, "Invalid argument")
+	}
+	keys := f.mapKeyCodes(lhs)
+	removed := false
+	for _, mode := range mapModeNames {
+		if t := f.mappings[mode.mode]; modes&mode.mode != 0 && t != nil && t.remove(keys, m.buffer) {
+			removed = true
+		}
+	}
+	if !removed {
+		return editorErrorf(31, "No such mapping")
+	}
+	return nil
+}
+
+// parseMapAttrs sets the attributes of m given at the start of args, like
+// <silent>, and returns the rest of args.
+func parseMapAttrs(args string, m *mapping) string {
+	attrs := map[string]*bool{"<silent>": &m.silent, "<buffer>": &m.buffer}
+	for {
+		args = strings.TrimSpace(args)
+		end := strings.IndexByte(args, '>') + 1
+		attr, ok := attrs[strings.ToLower(args[:end])]
+		if !ok {
+			return args
+		}
+		*attr = true
+		args = args[end:]
+	}
+}
+
+var leaderPattern = regexp.MustCompile(`(?i)<leader>`)
+
+// expandLeader replaces every <Leader> in keys by mapleader.
+func (f *SimpleFrame) expandLeader(keys string) string {
+	return leaderPattern.ReplaceAllLiteralString(keys, f.mapLeader)
+}
+
+func (f *SimpleFrame) mapKeyCodes(lhs string) []keyCode {
+	var codes []keyCode
+	for _, ev := range parseKeys(f.expandLeader(lhs)) {
+		codes = append(codes, codeOf(*ev))
+	}
+	return codes
+}
+
+// listMappings shows the mappings of modes whose keys start with those of
+// lhs, or all of them if lhs is empty.
+func (f *SimpleFrame) listMappings(modes mapModes, lhs string) {
+	prefix := f.mapKeyCodes(lhs)
+	var lines []string
+	for _, mode := range mapModeNames {
+		t := f.mappings[mode.mode]
+		if modes&mode.mode == 0 || t == nil {
+			continue
+		}
+		var found []*mapping
+		t.each(func(m *mapping) {
+			if keys := f.mapKeyCodes(m.lhs); len(keys) >= len(prefix) && equalKeyCodes(keys[:len(prefix)], prefix) {
+				found = append(found, m)
+			}
+		})
+		sort.SliceStable(found, func(i, j int) bool { return found[i].lhs < found[j].lhs })
+		for _, m := range found {
+			lines = append(lines, formatMapping(mode.name, m))
+		}
+	}
+	if len(lines) == 0 {
+		f.showMessage("No mapping found")
+		return
+	}
+	f.listing = nil
+	for _, line := range lines {
+		f.listing = append(f.listing, message{text: line})
+	}
+}
+
+func equalKeyCodes(a, b []keyCode) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatMapping returns how a mapping is listed: its mode and keys, with a
+// '*' if its keys are not mapped again and a '@' if it is for the buffer
+// only.
+func formatMapping(mode string, m *mapping) string {
+	flags := ""
+	if m.noremap {
+		flags += "*"
+	} else {
+		flags += " "
+	}
+	if m.buffer {
+		flags += "@"
+	} else {
+		flags += " "
+	}
+	return fmt.Sprintf("%-3s%-12s %s%s", mode, m.lhs, flags, m.rhs)
+}
+
+// clearBufferMappings removes the mappings of the buffer, for editing
+// another file.
+func (f *SimpleFrame) clearBufferMappings() {
+	for _, t := range f.mappings {
+		t.clearBuffer()
+	}
+}
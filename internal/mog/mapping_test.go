@@ -0,0 +1,117 @@
+package mog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newMappingTestFrame(t *testing.T) *SimpleFrame {
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+	f.loadBuffer([]byte("abcdef\nabcdef\nabcdef"))
+	return f
+}
+
+func TestSimpleFrame_mapKeys(t *testing.T) {
+	f := newMappingTestFrame(t)
+
+	assert.Nil(t, f.Execute("nmap L lll"))
+	typeKeys(f, "L")
+	assert.EqualValues(t, bufferPos{3, 0}, f.cursorPos())
+
+	// The keys of a mapping are mapped again, unless it is a noremap
+	// one.
+	assert.Nil(t, f.Execute("nmap l h"))
+	assert.Nil(t, f.Execute("nnoremap ; l"))
+	typeKeys(f, ";;l")
+	assert.EqualValues(t, bufferPos{4, 0}, f.cursorPos())
+	typeKeys(f, "L")
+	assert.EqualValues(t, bufferPos{1, 0}, f.cursorPos())
+
+	// Mapped keys that start with those of the mapping are not mapped
+	// again.
+	assert.Nil(t, f.Execute("nmap j j;"))
+	typeKeys(f, "j")
+	assert.EqualValues(t, bufferPos{2, 1}, f.cursorPos())
+
+	assert.Nil(t, f.Execute("nmap a b"))
+	assert.Nil(t, f.Execute("nmap b a"))
+	typeKeys(f, "a")
+	assert.EqualValues(t, "E223: recursive mapping", f.bottomLine())
+}
+
+func TestSimpleFrame_mapKeys_Modes(t *testing.T) {
+	f := newMappingTestFrame(t)
+
+	assert.Nil(t, f.Execute("imap jk <Esc>"))
+	assert.Nil(t, f.Execute("cmap <C-a> set"))
+	assert.Nil(t, f.Execute("vmap x <Esc>l"))
+
+	// j could be the start of jk and waits for the next key.
+	typeKeys(f, "ij")
+	assert.EqualValues(t, "abcdef", f.buffer.line(0))
+	typeKeys(f, "ajk")
+	assert.EqualValues(t, "jaabcdef", f.buffer.line(0))
+	assert.EqualValues(t, ModeNormal, f.mode)
+
+	// Scripts end with the keys that are held.
+	typeKeys(f, "ij")
+	f.flushKeys()
+	assert.EqualValues(t, "jajabcdef", f.buffer.line(0))
+	typeKeys(f, "\x1b")
+
+	typeKeys(f, "vx")
+	assert.EqualValues(t, ModeNormal, f.mode)
+	assert.EqualValues(t, bufferPos{4, 0}, f.cursorPos())
+
+	typeKeys(f, ":")
+	f.handleEventKey(*NewEventKey(KeyCtrlA, 0, ModNone))
+	typeKeys(f, " so=2\r")
+	assert.EqualValues(t, 2, f.scrollOff)
+}
+
+func TestSimpleFrame_mapKeys_Attributes(t *testing.T) {
+	f := newMappingTestFrame(t)
+
+	assert.Nil(t, f.Execute("set mapleader=,"))
+	assert.Nil(t, f.Execute("nmap <Leader>s :set so=2<CR>"))
+	assert.Nil(t, f.Execute("nmap <silent> <Leader>S :set so=3<CR>"))
+	typeKeys(f, ",s")
+	assert.EqualValues(t, 2, f.scrollOff)
+	assert.EqualValues(t, ":set so=2", f.bottomLine())
+	typeKeys(f, ",S")
+	assert.EqualValues(t, 3, f.scrollOff)
+	assert.EqualValues(t, " -- Normal --", f.bottomLine())
+
+	assert.Nil(t, f.Execute("nmap <buffer> ,s l"))
+	typeKeys(f, ",s")
+	assert.EqualValues(t, bufferPos{1, 0}, f.cursorPos())
+
+	assert.Nil(t, f.Execute("map"))
+	assert.EqualValues(t, "n  ,s            @l\nn  <Leader>S      :set so=3<CR>\nn  <Leader>s      :set so=2<CR>", f.bottomLine())
+	assert.Nil(t, f.Execute("nmap ,S"))
+	assert.EqualValues(t, "n  <Leader>S      :set so=3<CR>", f.bottomLine())
+
+	f.clearBufferMappings()
+	typeKeys(f, ",s")
+	assert.EqualValues(t, ":set so=2", f.bottomLine())
+
+	assert.Nil(t, f.Execute("unmap <Leader>s"))
+	assert.EqualError(t, f.Execute("unmap <Leader>s"), "E31: No such mapping")
+	assert.Nil(t, f.Execute("nunmap ,S"))
+	assert.Nil(t, f.Execute("map"))
+	assert.EqualValues(t, "No mapping found", f.bottomLine())
+}
+
+func TestSimpleFrame_mapKeys_Timeout(t *testing.T) {
+	f, _ := newTestFrame(t, []string{""}, 80, 10)
+	f.buffer = newLineBuffer([]string{"a", "b"})
+	assert.Nil(t, f.Execute("set timeoutlen=10"))
+	assert.Nil(t, f.Execute("nnoremap jj l"))
+
+	typeKeys(f, "j")
+	assert.EqualValues(t, bufferPos{0, 0}, f.cursorPos())
+	handleEventsUntil(t, f, func() bool { return len(f.typeahead) == 0 })
+	assert.EqualValues(t, bufferPos{0, 1}, f.cursorPos())
+}
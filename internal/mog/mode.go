@@ -12,9 +12,29 @@ const (
 )
 
 var (
+	ModeNormal = Mode{
+		Name:      "Normal",
+		ShortName: "Nor",
+		Letter:    'N',
+	}
 	ModeInsert = Mode{
 		Name:      "Insert",
 		ShortName: "Ins",
 		Letter:    'I',
 	}
+	ModeReplace = Mode{
+		Name:      "Replace",
+		ShortName: "Rep",
+		Letter:    'R',
+	}
+	ModeVisual = Mode{
+		Name:      "Visual",
+		ShortName: "Vis",
+		Letter:    'V',
+	}
+	ModeCommand = Mode{
+		Name:      "Command",
+		ShortName: "Cmd",
+		Letter:    'C',
+	}
 )
@@ -0,0 +1,132 @@
+package mog
+
+import (
+	"strings"
+	"unicode"
+)
+
+// defaultModelines is the default of modelines, the number of lines at the
+// start and at the end of a file that are looked at for modelines.
+const defaultModelines = 5
+
+// modelineSafe holds the options modelines may set. Modelines come with the
+// files being edited, so they must not be able to do anything harmful.
+var modelineSafe = map[string]bool{
+	"expandtab":  true,
+	"filetype":   true,
+	"shiftwidth": true,
+	"tabstop":    true,
+	"textwidth":  true,
+}
+
+// modelinePrefixes start the options of a modeline. They must be at the
+// start of the line or follow white space.
+var modelinePrefixes = []string{"vim:", "vi:", "mog:"}
+
+// parseModeline returns the options set by the modeline in line, if it has
+// one. A modeline either lists options separated by spaces or colons,
+//
+//	// vim: ts=4 sw=4:et
+//
+// or, if they start with set, separated by spaces and ended by a colon,
+// after which anything else in the line is ignored:
+//
+//	/* vim: set ts=4 sw=4 et: */
+func parseModeline(line string) ([]string, bool) {
+	rest, ok := cutModelinePrefix(line)
+	if !ok {
+		return nil, false
+	}
+	rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+	for _, set := range []string{"set ", "se "} {
+		if strings.HasPrefix(rest, set) {
+			end := strings.IndexByte(rest, ':')
+			if end < 0 {
+				return nil, false
+			}
+			return strings.Fields(rest[len(set):end]), true
+		}
+	}
+	return strings.FieldsFunc(rest, func(r rune) bool { return r == ':' || unicode.IsSpace(r) }), true
+}
+
+// cutModelinePrefix returns what follows the first modeline prefix in line.
+func cutModelinePrefix(line string) (string, bool) {
+	for i := 0; i < len(line); i++ {
+		if i > 0 && line[i-1] != ' ' && line[i-1] != '\t' {
+			continue
+		}
+		for _, p := range modelinePrefixes {
+			if strings.HasPrefix(line[i:], p) {
+				return line[i+len(p):], true
+			}
+		}
+	}
+	return "", false
+}
+
+// modelineOptions returns the options set by the modelines in the first and
+// last n lines of buf, in the order they appear.
+func modelineOptions(buf *lineBuffer, n int) []string {
+	l := buf.len()
+	searched := buf.slice(0, minInt(l, 2*n))
+	if l > 2*n {
+		searched = append(searched[:n], buf.slice(l-n, l)...)
+	}
+	var opts []string
+	for _, line := range searched {
+		if o, ok := parseModeline(line); ok {
+			opts = append(opts, o...)
+		}
+	}
+	return opts
+}
+
+// modelineFileType returns the filetype the modelines of the buffer set,
+// or "" if they set none or modelines are switched off.
+func (f *SimpleFrame) modelineFileType() string {
+	fileType := ""
+	for _, o := range f.modelineOptions() {
+		for _, prefix := range []string{"filetype=", "ft="} {
+			if strings.HasPrefix(o, prefix) {
+				fileType = o[len(prefix):]
+			}
+		}
+	}
+	return fileType
+}
+
+// modelineOptions returns the options set by the modelines of the buffer,
+// or nothing if modeline is not set.
+func (f *SimpleFrame) modelineOptions() []string {
+	if !f.modeline || f.modelines <= 0 {
+		return nil
+	}
+	return modelineOptions(&f.buffer, f.modelines)
+}
+
+// applyModelines sets the options the modelines of the buffer set, except
+// for filetype, which was set when it was detected. Only the options in
+// modelineSafe can be set, and only with the value given, so that no
+// command is ever run. Errors are shown.
+func (f *SimpleFrame) applyModelines() {
+	for _, arg := range f.modelineOptions() {
+		name := arg
+		if i := strings.IndexByte(name, '='); i >= 0 {
+			name = name[:i]
+		}
+		o, err := findOption(name)
+		if err != nil && strings.HasPrefix(name, "no") {
+			o, err = findOption(name[2:])
+		}
+		switch {
+		case err != nil || !modelineSafe[o.name]:
+			f.showError(editorErrorf(520, "Not allowed in a modeline: %s", arg))
+		case o.name == "filetype":
+		default:
+			if err := f.setOption(arg, localValue); err != nil {
+				f.showError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseModeline(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+		ok   bool
+	}{
+		{"// vim: ts=4 sw=4:et", []string{"ts=4", "sw=4", "et"}, true},
+		{"/* vim: set ts=4 sw=4 et: */", []string{"ts=4", "sw=4", "et"}, true},
+		{"vi:noet", []string{"noet"}, true},
+		{"# mog: se ft=yaml:", []string{"ft=yaml"}, true},
+		{"# vim: set ts=4", nil, false},
+		{"see gvim: ts=4", nil, false},
+		{"no modeline", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseModeline(tt.line)
+		assert.EqualValues(t, tt.want, got, tt.line)
+		assert.EqualValues(t, tt.ok, ok, tt.line)
+	}
+}
+
+func Test_modelineOptions(t *testing.T) {
+	lines := make([]string, 20)
+	lines[2] = "# vim: ts=2"
+	lines[10] = "# vim: ts=3"
+	lines[19] = "# vim: ts=4"
+	buf := newLineBuffer(lines)
+	assert.EqualValues(t, []string{"ts=2", "ts=4"}, modelineOptions(&buf, 5))
+	buf = newLineBuffer(lines[:11])
+	assert.EqualValues(t, []string{"ts=2", "ts=3"}, modelineOptions(&buf, 5))
+}
+
+func TestSimpleFrame_loadFile_Modelines(t *testing.T) {
+	dir := t.TempDir()
+	setEnv(t, "XDG_CONFIG_HOME", dir)
+	path := filepath.Join(dir, "notes")
+	contents := "# vim: set ts=4 sw=2 et ft=markdown tw=60 :\ntext\n# mog: noet fenc=latin1\n"
+	assert.Nil(t, os.WriteFile(path, []byte(contents), 0o644))
+	f := NewHeadlessFrame()
+	t.Cleanup(func() { _ = f.Close() })
+
+	assert.Nil(t, f.loadFile(path))
+	assert.EqualValues(t, "markdown", f.fileType)
+	assert.EqualValues(t, 4, f.tabStop)
+	assert.EqualValues(t, 2, f.shiftWidth)
+	assert.EqualValues(t, 60, f.textWidth)
+	assert.False(t, f.expandTab)
+	assert.EqualValues(t, encodingUTF8, f.fileEncoding)
+	assert.EqualValues(t, []message{
+		{text: "E520: Not allowed in a modeline: fenc=latin1", isError: true},
+	}, f.messages)
+
+	// A filetype must not name a file elsewhere.
+	assert.Nil(t, os.WriteFile(path, []byte("# vim: ft=../../x\n"), 0o644))
+	assert.Nil(t, f.editFile(path, ""))
+	assert.EqualValues(t, "", f.fileType)
+	assert.EqualValues(t, "E474: Invalid argument: filetype=../../x", f.messages[1].text)
+
+	assert.Nil(t, f.Execute("set nomodeline"))
+	assert.Nil(t, os.WriteFile(path, []byte("# vim: ts=3\n"), 0o644))
+	assert.Nil(t, f.editFile(path, ""))
+	assert.EqualValues(t, defaultTabStop, f.tabStop)
+}
@@ -0,0 +1,121 @@
+package mog
+
+import "time"
+
+const (
+	// doubleClickTime is the longest time between two clicks on the same
+	// spot for them to count as a double click.
+	doubleClickTime = 500 * time.Millisecond
+
+	// wheelLines is the number of lines a step of the mouse wheel scrolls.
+	wheelLines = 3
+)
+
+// mouseState remembers what happened with the mouse before the current event.
+type mouseState struct {
+	buttons   ButtonMask
+	pressedAt bufferPos
+	dragging  bool
+	lastClick time.Time
+	lastX     int
+	lastY     int
+}
+
+// handleEventMouse handles the mouse when the mouse option is set: the
+// wheel scrolls, clicks move the cursor and dragging selects text. The
+// editor shows a single buffer without windows, so there are no status lines
+// of windows or borders between them that clicks could focus or drags
+// resize.
+func (f *SimpleFrame) handleEventMouse(ev *EventMouse) {
+	if !f.mouse || f.mode == ModeCommand || f.prompt != nil || f.hex != nil {
+		return
+	}
+	x, y := ev.Position()
+	buttons := ev.Buttons()
+	pressed := buttons &^ f.mouseState.buttons
+	f.mouseState.buttons = buttons & (Button1 | Button2 | Button3)
+
+	switch {
+	case buttons&WheelUp != 0:
+		f.ScrollLines(-wheelLines)
+	case buttons&WheelDown != 0:
+		f.ScrollLines(wheelLines)
+	case pressed&Button1 != 0:
+		f.mouseClick(x, y, ev.When())
+	case buttons&Button1 != 0:
+		f.mouseDrag(x, y)
+	}
+}
+
+// mouseClick moves the cursor to the clicked position, or selects the word
+// there on a double click. Clicks on the bottom line are ignored.
+func (f *SimpleFrame) mouseClick(x, y int, when time.Time) {
+	ms := &f.mouseState
+	double := when.Sub(ms.lastClick) < doubleClickTime && x == ms.lastX && y == ms.lastY
+	ms.lastClick, ms.lastX, ms.lastY = when, x, y
+	ms.dragging = false
+
+	if y >= f.textHeight() {
+		return
+	}
+	pos := f.viewPosToBufferPos(x, y)
+	if f.mode == ModeVisual {
+		f.StopVisual()
+	}
+	f.cursor.MoveTo(pos.x, pos.y)
+	ms.pressedAt = pos
+	if double {
+		f.SelectWord()
+		// A third click should not count as another double click.
+		ms.lastClick = time.Time{}
+	}
+	f.scrollToCursor()
+	f.showCursor()
+}
+
+// mouseDrag selects the text from where the mouse button was pressed to
+// where it is now.
+func (f *SimpleFrame) mouseDrag(x, y int) {
+	ms := &f.mouseState
+	if y >= f.textHeight() {
+		y = f.textHeight() - 1
+	}
+	pos := f.viewPosToBufferPos(x, y)
+	if !ms.dragging {
+		if pos == ms.pressedAt {
+			return
+		}
+		ms.dragging = true
+		if f.mode != ModeVisual {
+			f.cursor.MoveTo(ms.pressedAt.x, ms.pressedAt.y)
+			f.StartVisual()
+		}
+	}
+	f.cursor.MoveTo(pos.x, pos.y)
+	f.scrollToCursor()
+	f.showCursor()
+}
+
+// viewPosToBufferPos returns the position in the buffer shown at screen
+// position x, y. Positions beyond the end of a line map to its last
+// character, or the end of the line in insert mode, and positions below the
+// end of the buffer map to its last line.
+func (f *SimpleFrame) viewPosToBufferPos(x, y int) bufferPos {
+	w, _ := f.screen.Size()
+	l := f.syncLayout()
+	bufY := f.offset
+	for bufY < f.buffer.len()-1 && l.start(&f.buffer, f.offset, bufY+1) <= y {
+		bufY++
+	}
+	row := y - l.start(&f.buffer, f.offset, bufY)
+	if row >= l.lineHeight(&f.buffer, bufY) {
+		row = l.lineHeight(&f.buffer, bufY) - 1
+		x = w - 1
+	}
+	bufX := byteAtColumn(f.buffer.line(bufY), row*w+x, f.tabStop)
+	last := lastCharStart(f.buffer.line(bufY))
+	if f.mode == ModeInsert {
+		last = len(f.buffer.line(bufY))
+	}
+	return bufferPos{clampInt(bufX, 0, maxInt(last, 0)), bufY}
+}
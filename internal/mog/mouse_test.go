@@ -0,0 +1,168 @@
+package mog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mouseEvent(x, y int, buttons ButtonMask) *EventMouse {
+	return NewEventMouse(x, y, buttons, ModNone)
+}
+
+func TestSimpleFrame_viewPosToBufferPos(t *testing.T) {
+	tests := []struct {
+		name      string
+		x, y      int
+		offset    int
+		mode      Mode
+		expected  bufferPos
+		roundTrip bool
+	}{
+		{"first character", 0, 0, 0, ModeNormal, bufferPos{0, 0}, true},
+		{"wrapped part of a line", 1, 2, 0, ModeNormal, bufferPos{6, 1}, true},
+		{"beyond the end of a line", 4, 0, 0, ModeNormal, bufferPos{1, 0}, false},
+		{"beyond the end of a line in insert mode", 4, 0, 0, ModeInsert, bufferPos{2, 0}, false},
+		{"below the end of the buffer", 0, 4, 0, ModeNormal, bufferPos{0, 2}, false},
+		{"scrolled view", 2, 0, 1, ModeNormal, bufferPos{2, 1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := newTestFrame(t, []string{"ab", "abcdefgh", "a"}, 5, 6)
+			f.mouse = true
+			f.offset = tt.offset
+			f.mode = tt.mode
+			assert.EqualValues(t, tt.expected, f.viewPosToBufferPos(tt.x, tt.y))
+
+			// Mapping back gives the same screen position
+			if tt.roundTrip {
+				x, y := f.bufferPosToViewPos(tt.expected.x, tt.expected.y)
+				assert.EqualValues(t, []int{tt.x, tt.y}, []int{x, y})
+			}
+		})
+	}
+}
+
+func TestSimpleFrame_handleEventMouse_ClickMovesCursor(t *testing.T) {
+	f, _ := newTestFrame(t, []string{"ab", "abcdefgh", "a"}, 5, 6)
+	f.mouse = true
+
+	f.HandleEvent(mouseEvent(1, 2, Button1))
+	f.HandleEvent(mouseEvent(1, 2, ButtonNone))
+
+	assert.EqualValues(t, 6, f.cursor.XPos())
+	assert.EqualValues(t, 1, f.cursor.YPos())
+	assert.EqualValues(t, ModeNormal, f.mode)
+}
+
+func TestSimpleFrame_handleEventMouse_WheelScrolls(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(100), 10, 11)
+	f.mouse = true
+
+	f.HandleEvent(mouseEvent(0, 0, WheelDown))
+	assert.EqualValues(t, 3, f.offset)
+	f.HandleEvent(mouseEvent(0, 0, WheelUp))
+	assert.EqualValues(t, 0, f.offset)
+}
+
+func TestSimpleFrame_handleEventMouse_DragSelects(t *testing.T) {
+	f, _ := newTestFrame(t, []string{"abcd", "efgh"}, 10, 5)
+	f.mouse = true
+
+	f.HandleEvent(mouseEvent(1, 0, Button1))
+	f.HandleEvent(mouseEvent(1, 0, Button1))
+	assert.EqualValues(t, ModeNormal, f.mode, "no selection without moving")
+
+	f.HandleEvent(mouseEvent(2, 1, Button1))
+	f.HandleEvent(mouseEvent(2, 1, ButtonNone))
+
+	start, end, ok := f.selection()
+	assert.True(t, ok)
+	assert.EqualValues(t, bufferPos{1, 0}, start)
+	assert.EqualValues(t, bufferPos{2, 1}, end)
+	assert.True(t, f.inSelection(3, 0))
+	assert.False(t, f.inSelection(3, 1))
+
+	// Clicking again ends the selection
+	f.HandleEvent(mouseEvent(0, 0, Button1))
+	assert.EqualValues(t, ModeNormal, f.mode)
+}
+
+func TestSimpleFrame_handleEventMouse_DoubleClickSelectsWord(t *testing.T) {
+	f, _ := newTestFrame(t, []string{"foo bar_baz.qux"}, 20, 5)
+	f.mouse = true
+
+	f.mouseClick(6, 0, time.Now())
+	f.HandleEvent(mouseEvent(6, 0, ButtonNone))
+	f.mouseClick(6, 0, time.Now())
+
+	start, end, ok := f.selection()
+	assert.True(t, ok)
+	assert.EqualValues(t, bufferPos{4, 0}, start)
+	assert.EqualValues(t, bufferPos{10, 0}, end)
+}
+
+func TestSimpleFrame_SelectWord_MultiByte(t *testing.T) {
+	f, _ := newTestFrame(t, []string{"x ücafé."}, 20, 5)
+	f.cursor.MoveTo(4, 0)
+
+	f.SelectWord()
+
+	start, end, ok := f.selection()
+	assert.True(t, ok)
+	assert.EqualValues(t, bufferPos{2, 0}, start)
+	assert.EqualValues(t, bufferPos{7, 0}, end, "the start of é")
+}
+
+func TestSimpleFrame_handleEventMouse_Disabled(t *testing.T) {
+	f, _ := newTestFrame(t, []string{"ab", "cd"}, 5, 5)
+	f.mouse = true
+	assert.Nil(t, f.Execute("set nomouse"))
+
+	f.HandleEvent(mouseEvent(1, 1, Button1))
+	assert.EqualValues(t, 0, f.cursor.YPos())
+
+	assert.Nil(t, f.Execute("set mouse"))
+	f.HandleEvent(mouseEvent(1, 1, Button1))
+	assert.EqualValues(t, 1, f.cursor.YPos())
+}
+
+func TestSimpleFrame_exSet(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(100), 10, 11)
+
+	assert.Nil(t, f.Execute("set so=3 nomouse"))
+	assert.EqualValues(t, 3, f.scrollOff)
+	assert.False(t, f.mouse)
+
+	assert.EqualError(t, f.Execute("set scrolloff"), "E521: Number required after =: scrolloff")
+	assert.EqualError(t, f.Execute("set scrolloff=x"), "E474: Invalid argument: scrolloff=x")
+	assert.EqualError(t, f.Execute("set mouse=1"), "E474: Invalid argument: mouse=1")
+	assert.EqualError(t, f.Execute("set nosuchoption"), "E518: Unknown option: nosuchoption")
+}
+
+func TestSimpleFrame_Show_HighlightsSelection(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"abcd", "efgh"}, 5, 4)
+	f.cursor.MoveTo(1, 0)
+	f.StartVisual()
+	f.MoveCursor(dirRight)
+	f.Show()
+
+	cells, w, _ := ss.GetContents()
+	assert.EqualValues(t, f.style(GroupNormal), cells[0].Style)
+	assert.EqualValues(t, f.style(GroupVisual), cells[1].Style)
+	assert.EqualValues(t, f.style(GroupVisual), cells[2].Style)
+	assert.EqualValues(t, f.style(GroupNormal), cells[3].Style)
+
+	f.MoveCursor(dirDown)
+	f.Show()
+	cells, _, _ = ss.GetContents()
+	assert.EqualValues(t, f.style(GroupVisual), cells[3].Style)
+	assert.EqualValues(t, f.style(GroupVisual), cells[w+2].Style)
+
+	f.handleEventKey(*NewEventKey(KeyEscape, 0, ModNone))
+	f.Show()
+	cells, _, _ = ss.GetContents()
+	assert.EqualValues(t, f.style(GroupNormal), cells[3].Style)
+	assert.EqualValues(t, f.style(GroupNormal), cells[w+2].Style)
+}
@@ -0,0 +1,406 @@
+package mog
+
+import (
+	"strconv"
+	"strings"
+)
+
+// optionType is the type of the value of an option.
+type optionType int
+
+const (
+	optionBool optionType = iota
+	optionNumber
+	optionString
+)
+
+// optionScope is what the value of an option belongs to. Window and buffer
+// options have a local value, the one that is used, besides their global
+// value, which is what new windows and buffers start out with.
+type optionScope int
+
+const (
+	scopeGlobal optionScope = iota
+	scopeWindow
+	scopeBuffer
+)
+
+// optionValue is the value of an option, in the field of its type.
+type optionValue struct {
+	b bool
+	n int
+	s string
+}
+
+// option is a setting that can be changed with :set.
+type option struct {
+	name  string
+	short string
+	typ   optionType
+	scope optionScope
+	// def is the value the option has when nothing set it.
+	def optionValue
+	// check reports whether v is a valid value of the option and may
+	// normalize it. It is nil for options taking any value of their type.
+	check func(v *optionValue) bool
+	// get returns the value the frame uses, set changes it.
+	get func(f *SimpleFrame) optionValue
+	set func(f *SimpleFrame, v optionValue)
+}
+
+var options []option
+
+func init() {
+	options = []option{
+		{
+			name: "autoread", short: "ar", typ: optionBool, def: optionValue{b: true},
+			get: func(f *SimpleFrame) optionValue { return optionValue{b: f.autoread} },
+			set: func(f *SimpleFrame, v optionValue) { f.autoread = v.b },
+		},
+		{
+			name: "bomb", typ: optionBool, scope: scopeBuffer,
+			get: func(f *SimpleFrame) optionValue { return optionValue{b: f.bomb} },
+			set: (*SimpleFrame).setBomb,
+		},
+		{
+			name: "commentstring", short: "cms", typ: optionString, scope: scopeBuffer,
+			def: optionValue{s: defaultCommentString}, check: checkCommentString,
+			get: func(f *SimpleFrame) optionValue { return optionValue{s: f.commentString} },
+			set: func(f *SimpleFrame, v optionValue) { f.commentString = v.s },
+		},
+		{
+			name: "cursorline", short: "cul", typ: optionBool, scope: scopeWindow,
+			get: func(f *SimpleFrame) optionValue { return optionValue{b: f.cursorLine} },
+			set: (*SimpleFrame).setCursorLine,
+		},
+		{
+			name: "expandtab", short: "et", typ: optionBool, scope: scopeBuffer,
+			get: func(f *SimpleFrame) optionValue { return optionValue{b: f.expandTab} },
+			set: func(f *SimpleFrame, v optionValue) { f.expandTab = v.b },
+		},
+		{
+			name: "fileencoding", short: "fenc", typ: optionString, scope: scopeBuffer,
+			def: optionValue{s: encodingUTF8}, check: checkFileEncoding,
+			get: func(f *SimpleFrame) optionValue { return optionValue{s: f.fileEncoding} },
+			set: (*SimpleFrame).setFileEncoding,
+		},
+		{
+			name: "fileformat", short: "ff", typ: optionString, scope: scopeBuffer,
+			def: optionValue{s: fileFormatUnix}, check: checkFileFormat,
+			get: func(f *SimpleFrame) optionValue { return optionValue{s: f.fileFormat} },
+			set: (*SimpleFrame).setFileFormat,
+		},
+		{
+			name: "filetype", short: "ft", typ: optionString, scope: scopeBuffer, check: checkFileType,
+			get: func(f *SimpleFrame) optionValue { return optionValue{s: f.fileType} },
+			set: (*SimpleFrame).setFileType,
+		},
+		{
+			name: "fixendofline", short: "fixeol", typ: optionBool, scope: scopeBuffer,
+			get: func(f *SimpleFrame) optionValue { return optionValue{b: f.fixEndOfLine} },
+			set: func(f *SimpleFrame, v optionValue) { f.fixEndOfLine = v.b },
+		},
+		{
+			name: "formatprg", short: "fp", typ: optionString, scope: scopeBuffer,
+			get: func(f *SimpleFrame) optionValue { return optionValue{s: f.formatPrg} },
+			set: func(f *SimpleFrame, v optionValue) { f.formatPrg = v.s },
+		},
+		{
+			name: "hlsearch", short: "hls", typ: optionBool,
+			get: func(f *SimpleFrame) optionValue { return optionValue{b: f.hlSearch} },
+			set: (*SimpleFrame).setHlSearch,
+		},
+		{
+			name: "mapleader", typ: optionString, def: optionValue{s: defaultMapLeader},
+			get: func(f *SimpleFrame) optionValue { return optionValue{s: f.mapLeader} },
+			set: func(f *SimpleFrame, v optionValue) { f.mapLeader = v.s },
+		},
+		{
+			name: "modeline", short: "ml", typ: optionBool, scope: scopeBuffer, def: optionValue{b: true},
+			get: func(f *SimpleFrame) optionValue { return optionValue{b: f.modeline} },
+			set: func(f *SimpleFrame, v optionValue) { f.modeline = v.b },
+		},
+		{
+			name: "modelines", short: "mls", typ: optionNumber, def: optionValue{n: defaultModelines},
+			check: func(v *optionValue) bool { return v.n >= 0 },
+			get:   func(f *SimpleFrame) optionValue { return optionValue{n: f.modelines} },
+			set:   func(f *SimpleFrame, v optionValue) { f.modelines = v.n },
+		},
+		{
+			name: "mouse", typ: optionBool,
+			get: func(f *SimpleFrame) optionValue { return optionValue{b: f.mouse} },
+			set: (*SimpleFrame).setMouse,
+		},
+		{
+			name: "readonly", short: "ro", typ: optionBool, scope: scopeBuffer,
+			get: func(f *SimpleFrame) optionValue { return optionValue{b: f.readonly} },
+			set: func(f *SimpleFrame, v optionValue) { f.readonly = v.b },
+		},
+		{
+			name: "scrolloff", short: "so", typ: optionNumber, scope: scopeWindow,
+			check: func(v *optionValue) bool { return v.n >= 0 },
+			get:   func(f *SimpleFrame) optionValue { return optionValue{n: f.scrollOff} },
+			set:   (*SimpleFrame).setScrollOff,
+		},
+		{
+			name: "shiftwidth", short: "sw", typ: optionNumber, scope: scopeBuffer, def: optionValue{n: defaultTabStop},
+			check: func(v *optionValue) bool { return v.n >= 0 },
+			get:   func(f *SimpleFrame) optionValue { return optionValue{n: f.shiftWidth} },
+			set:   func(f *SimpleFrame, v optionValue) { f.shiftWidth = v.n },
+		},
+		{
+			name: "syntax", short: "syn", typ: optionString, scope: scopeBuffer, check: checkSyntax,
+			get: func(f *SimpleFrame) optionValue { return optionValue{s: f.syntax} },
+			set: (*SimpleFrame).setSyntax,
+		},
+		{
+			name: "tabstop", short: "ts", typ: optionNumber, scope: scopeBuffer, def: optionValue{n: defaultTabStop},
+			check: func(v *optionValue) bool { return v.n > 0 },
+			get:   func(f *SimpleFrame) optionValue { return optionValue{n: f.tabStop} },
+			set:   (*SimpleFrame).setTabStop,
+		},
+		{
+			name: "textwidth", short: "tw", typ: optionNumber, scope: scopeBuffer,
+			check: func(v *optionValue) bool { return v.n >= 0 },
+			get:   func(f *SimpleFrame) optionValue { return optionValue{n: f.textWidth} },
+			set:   func(f *SimpleFrame, v optionValue) { f.textWidth = v.n },
+		},
+		{
+			name: "timeoutlen", short: "tm", typ: optionNumber, def: optionValue{n: defaultTimeoutLen},
+			check: func(v *optionValue) bool { return v.n >= 0 },
+			get:   func(f *SimpleFrame) optionValue { return optionValue{n: f.timeoutLen} },
+			set:   func(f *SimpleFrame, v optionValue) { f.timeoutLen = v.n },
+		},
+		{
+			name: "trimtrailingwhitespace", short: "ttw", typ: optionBool, scope: scopeBuffer,
+			get: func(f *SimpleFrame) optionValue { return optionValue{b: f.trimTrailingWhitespace} },
+			set: func(f *SimpleFrame, v optionValue) { f.trimTrailingWhitespace = v.b },
+		},
+	}
+}
+
+func findOption(name string) (*option, error) {
+	for i, o := range options {
+		if name == o.name || name == o.short {
+			return &options[i], nil
+		}
+	}
+	return nil, editorErrorf(518, "Unknown option: %s", name)
+}
+
+// parse parses s as a value of o. Boolean options take no value.
+func (o *option) parse(s string) (optionValue, bool) {
+	var v optionValue
+	switch o.typ {
+	case optionBool:
+		return v, false
+	case optionNumber:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return v, false
+		}
+		v.n = n
+	case optionString:
+		v.s = s
+	}
+	return v, o.check == nil || o.check(&v)
+}
+
+// format returns how :set shows o with the value v, e.g. "scrolloff=5" or
+// "nomouse".
+func (o *option) format(v optionValue) string {
+	switch o.typ {
+	case optionBool:
+		if v.b {
+			return o.name
+		}
+		return "no" + o.name
+	case optionNumber:
+		return o.name + "=" + strconv.Itoa(v.n)
+	}
+	return o.name + "=" + v.s
+}
+
+// The values of options that :set, :setlocal and :setglobal change and
+// show.
+const (
+	localValue = 1 << iota
+	globalValue
+)
+
+// exSet implements :set, which sets each of the space separated options it
+// is given. Window and buffer options get both their local and their
+// global value set.
+//
+//	:set              show the options that are not set to their default
+//	:set {opt}        switch a boolean option on
+//	:set no{opt}      switch it off
+//	:set {opt}={val}  give any other option a value
+//	:set {opt}?       show the value of an option
+func (f *SimpleFrame) exSet(args string) error {
+	return f.setOptions(args, localValue|globalValue)
+}
+
+// exSetLocal implements :setlocal, which is :set for just the local values
+// of options.
+func (f *SimpleFrame) exSetLocal(args string) error {
+	return f.setOptions(args, localValue)
+}
+
+// exSetGlobal implements :setglobal, which is :set for just the global
+// values of options.
+func (f *SimpleFrame) exSetGlobal(args string) error {
+	return f.setOptions(args, globalValue)
+}
+
+func (f *SimpleFrame) setOptions(args string, which int) error {
+	fields := splitOptionArgs(args)
+	if len(fields) == 0 {
+		f.showMessage(strings.Join(f.changedOptions(which), "  "))
+		return nil
+	}
+	var shown []string
+	for _, arg := range fields {
+		if name := strings.TrimSuffix(arg, "?"); name != arg {
+			o, err := findOption(name)
+			if err != nil {
+				return err
+			}
+			shown = append(shown, o.format(f.optionValue(o, which)))
+			continue
+		}
+		if err := f.setOption(arg, which); err != nil {
+			return err
+		}
+	}
+	if len(shown) > 0 {
+		f.showMessage(strings.Join(shown, "  "))
+	}
+	return nil
+}
+
+// splitOptionArgs splits the arguments of :set at white space. A space
+// or a backslash that is to be part of a value is escaped with a backslash.
+func splitOptionArgs(args string) []string {
+	var fields []string
+	var field strings.Builder
+	inField := false
+	for i := 0; i < len(args); i++ {
+		c := args[i]
+		switch {
+		case c == '\\' && i+1 < len(args) && (args[i+1] == ' ' || args[i+1] == '\\'):
+			i++
+			field.WriteByte(args[i])
+			inField = true
+		case c == ' ' || c == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteByte(c)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields
+}
+
+func (f *SimpleFrame) setOption(arg string, which int) error {
+	if kv := strings.SplitN(arg, "=", 2); len(kv) == 2 {
+		o, err := findOption(kv[0])
+		if err != nil {
+			return err
+		}
+		v, ok := o.parse(kv[1])
+		if !ok {
+			return editorErrorf(474, "Invalid argument: %s=%s", o.name, kv[1])
+		}
+		f.setOptionValue(o, v, which)
+		return nil
+	}
+	v := optionValue{b: true}
+	o, err := findOption(arg)
+	if err != nil && strings.HasPrefix(arg, "no") {
+		v.b = false
+		o, err = findOption(arg[2:])
+	}
+	if err != nil {
+		return editorErrorf(518, "Unknown option: %s", arg)
+	}
+	if o.typ != optionBool {
+		return editorErrorf(521, "Number required after =: %s", arg)
+	}
+	f.setOptionValue(o, v, which)
+	return nil
+}
+
+// optionValue returns the local value of o, or its global value if which
+// is just globalValue. Global options have only the one value.
+func (f *SimpleFrame) optionValue(o *option, which int) optionValue {
+	if which == globalValue && o.scope != scopeGlobal {
+		if v, ok := f.globalOptions[o.name]; ok {
+			return v
+		}
+		return o.def
+	}
+	return o.get(f)
+}
+
+// setOptionValue sets the values of o that which selects to v.
+func (f *SimpleFrame) setOptionValue(o *option, v optionValue, which int) {
+	if which&globalValue != 0 && o.scope != scopeGlobal {
+		if f.globalOptions == nil {
+			f.globalOptions = make(map[string]optionValue)
+		}
+		f.globalOptions[o.name] = v
+	}
+	if which&localValue != 0 || o.scope == scopeGlobal {
+		o.set(f, v)
+	}
+}
+
+// changedOptions returns the options whose value differs from their
+// default, the way :set shows them.
+func (f *SimpleFrame) changedOptions(which int) []string {
+	var changed []string
+	for i := range options {
+		o := &options[i]
+		if v := f.optionValue(o, which); v != o.def {
+			changed = append(changed, o.format(v))
+		}
+	}
+	return changed
+}
+
+// resetBufferOptions gives the buffer options their global values, for
+// editing another file.
+func (f *SimpleFrame) resetBufferOptions() {
+	for i := range options {
+		if o := &options[i]; o.scope == scopeBuffer {
+			o.set(f, f.optionValue(o, globalValue))
+		}
+	}
+}
+
+func (f *SimpleFrame) setMouse(v optionValue) {
+	f.mouse = v.b
+	if f.mouse {
+		f.screen.EnableMouse()
+	} else {
+		f.screen.DisableMouse()
+	}
+}
+
+func (f *SimpleFrame) setCursorLine(v optionValue) {
+	f.cursorLine = v.b
+	f.damage.markLine(f.cursor.YPos(), f.lineHeightIfValid(f.cursor.YPos()))
+}
+
+func (f *SimpleFrame) setScrollOff(v optionValue) {
+	f.scrollOff = v.n
+	f.scrollToCursor()
+}
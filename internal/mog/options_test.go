@@ -0,0 +1,46 @@
+package mog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleFrame_exSetShowsOptions(t *testing.T) {
+	f := newFrame(NewSimulationScreen())
+
+	assert.Nil(t, f.Execute("set"))
+	assert.EqualValues(t, "", f.message.text)
+
+	assert.Nil(t, f.Execute("set so=2 mouse ff=dos"))
+	assert.Nil(t, f.Execute("set so? mouse? ff?"))
+	assert.EqualValues(t, "scrolloff=2  mouse  fileformat=dos", f.bottomLine())
+	assert.Nil(t, f.Execute("set"))
+	assert.EqualValues(t, "fileformat=dos  mouse  scrolloff=2", f.bottomLine())
+
+	assert.EqualError(t, f.Execute("set frob?"), "E518: Unknown option: frob")
+}
+
+func TestSimpleFrame_exSetLocal(t *testing.T) {
+	paths := writeTestFiles(t, "a", "b")
+	f := newFrame(NewSimulationScreen())
+	assert.Nil(t, f.loadFile(paths[0]))
+
+	assert.Nil(t, f.Execute("setlocal ro fenc=latin-1 so=3"))
+	assert.Nil(t, f.Execute("setlocal ro? fenc? so?"))
+	assert.EqualValues(t, "readonly  fileencoding=latin1  scrolloff=3", f.bottomLine())
+	assert.Nil(t, f.Execute("setglobal ro? fenc? so?"))
+	assert.EqualValues(t, "noreadonly  fileencoding=utf-8  scrolloff=0", f.bottomLine())
+
+	// Global options have just the one value.
+	assert.Nil(t, f.Execute("setlocal mouse"))
+	assert.Nil(t, f.Execute("setglobal mouse?"))
+	assert.EqualValues(t, "mouse", f.bottomLine())
+
+	assert.Nil(t, f.Execute("setglobal ro"))
+	assert.Nil(t, f.Execute("edit! "+paths[1]))
+	assert.Nil(t, f.Execute("set ro? fenc? so?"))
+	// The buffer options of another file start out with their global
+	// values, those of the window are kept.
+	assert.EqualValues(t, "readonly  fileencoding=utf-8  scrolloff=3", f.bottomLine())
+}
@@ -0,0 +1,79 @@
+package mog
+
+// startPager makes the frame a pager: the buffer is read-only and keys
+// scroll through it like in less.
+func (f *SimpleFrame) startPager(follow bool) {
+	f.pager = true
+	f.readonly = true
+	if follow {
+		f.startFollowing()
+	}
+}
+
+// handlePagerKey handles a key in normal mode while the frame is a pager.
+// Keys that are not pager commands move the cursor, search and open the
+// command line as in normal mode, all others are ignored. Any key stops
+// following the end of the text.
+func (f *SimpleFrame) handlePagerKey(ev EventKey) {
+	if f.following {
+		f.following = false
+		return
+	}
+	switch ev.Key() {
+	case KeyPgDn:
+		f.ScrollPage(1)
+	case KeyPgUp:
+		f.ScrollPage(-1)
+	case KeyEnter:
+		f.ScrollLines(1)
+	case KeyRune:
+		f.handlePagerRune(ev)
+	default:
+		f.handleNormalKey(ev)
+	}
+}
+
+func (f *SimpleFrame) handlePagerRune(ev EventKey) {
+	switch ev.Rune() {
+	case 'q':
+		if err := f.exQuit(""); err != nil {
+			f.showError(err)
+		}
+	case ' ', 'f':
+		f.ScrollPage(1)
+	case 'b':
+		f.ScrollPage(-1)
+	case 'd':
+		f.ScrollHalfPage(1)
+	case 'u':
+		f.ScrollHalfPage(-1)
+	case 'e':
+		f.ScrollLines(1)
+	case 'y':
+		f.ScrollLines(-1)
+	case 'g':
+		_ = f.goToLine("1")
+	case 'G':
+		_ = f.goToLine("$")
+	case 'F':
+		f.startFollowing()
+	case 'h', 'j', 'k', 'l', 'H', 'M', 'L', 'n', '/', ':', 'z':
+		f.handleNormalKey(ev)
+	}
+}
+
+// startFollowing keeps the end of the text in view as more of it is read
+// from stdin or appended to the file being edited, like less +F.
+func (f *SimpleFrame) startFollowing() {
+	f.following = true
+	f.showMessage("Waiting for data... (press any key to stop)")
+	f.followEnd()
+}
+
+// followEnd moves the cursor to the last line while following the end of
+// the text.
+func (f *SimpleFrame) followEnd() {
+	if f.following {
+		_ = f.goToLine("$")
+	}
+}
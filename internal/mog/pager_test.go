@@ -0,0 +1,44 @@
+package mog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleFrame_handlePagerKey(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(20), 80, 5)
+	f.startPager(false)
+	assert.True(t, f.readonly)
+
+	typeKeys(f, " ")
+	assert.EqualValues(t, 2, f.offset)
+	typeKeys(f, "G")
+	assert.EqualValues(t, 19, f.cursor.YPos())
+	typeKeys(f, "g")
+	assert.EqualValues(t, 0, f.cursor.YPos())
+	typeKeys(f, "ix")
+	assert.EqualValues(t, ModeNormal, f.mode)
+	assert.EqualValues(t, "0", f.buffer.line(0))
+
+	typeKeys(f, "q")
+	assert.True(t, f.closed)
+}
+
+func TestSimpleFrame_handlePagerKey_Follow(t *testing.T) {
+	f, _ := newTestFrame(t, []string{""}, 80, 5)
+	f.startPager(false)
+	f.appendText([]byte("a\nb"))
+
+	typeKeys(f, "F")
+	assert.True(t, f.following)
+	assert.EqualValues(t, 1, f.cursor.YPos())
+	f.appendText([]byte("\nc"))
+	f.followEnd()
+	assert.EqualValues(t, 2, f.cursor.YPos())
+
+	// The key that stops following does nothing else
+	f.handleEventKey(*NewEventKey(KeyRune, 'q', ModNone))
+	assert.False(t, f.following)
+	assert.False(t, f.closed)
+}
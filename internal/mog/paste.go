@@ -0,0 +1,81 @@
+package mog
+
+import "strings"
+
+// handleEventPaste collects the keys of a bracketed paste, which arrive
+// between the start and the end event, and inserts them once the paste has
+// ended.
+func (f *SimpleFrame) handleEventPaste(ev *EventPaste) {
+	if ev.Start() {
+		f.flushKeys()
+		f.paste = &strings.Builder{}
+		return
+	}
+	if f.paste == nil {
+		return
+	}
+	text := f.paste.String()
+	f.paste = nil
+	f.Paste(text)
+}
+
+// handlePastedKey adds a key that is part of a bracketed paste to the pasted
+// text. Pasted keys are never interpreted as commands.
+func (f *SimpleFrame) handlePastedKey(ev EventKey) {
+	switch ev.Key() {
+	case KeyRune:
+		f.paste.WriteRune(ev.Rune())
+	case KeyEnter, KeyLF:
+		f.paste.WriteByte('\n')
+	case KeyTab:
+		f.paste.WriteByte('\t')
+	}
+}
+
+// Paste inserts pasted text at the cursor position as a single change that
+// is undone as a whole. Terminals send line breaks as carriage returns, so
+// those are treated as newlines. On the command line only the first line of
+// the text is inserted. Nothing is pasted into the pager.
+func (f *SimpleFrame) Paste(text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	if text == "" || f.prompt != nil {
+		return
+	}
+	switch {
+	case f.mode == ModeCommand:
+		f.cmdline += strings.SplitN(text, "\n", 2)[0]
+		return
+	case f.pager:
+		// The text of the pager cannot be edited.
+		return
+	case f.hex != nil:
+		// In the hex view text is pasted over the bytes in replace mode
+		// only.
+		if f.mode == ModeReplace {
+			for _, r := range text {
+				f.hexTypeRune(r)
+			}
+		}
+		return
+	case f.mode == ModeVisual:
+		f.StopVisual()
+	}
+
+	insert := f.mode == ModeInsert
+	if !insert && f.currentLine() != "" {
+		// In normal mode the text goes before the character under the cursor.
+		f.cursor.MoveTo(f.cursorPos().x, f.cursor.YPos())
+	}
+	f.undo.begin(f.cursorPos())
+	f.InsertText(text)
+	f.undo.end()
+	if insert {
+		// Whatever is typed after the paste is a change of its own.
+		f.undo.begin(f.cursorPos())
+	} else if f.cursor.XPos() > 0 {
+		f.cursor.MoveLeft()
+	}
+	f.scrollToCursor()
+	f.showCursor()
+}
@@ -0,0 +1,101 @@
+package mog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sendPaste(f *SimpleFrame, text string) {
+	f.HandleEvent(NewEventPaste(true))
+	for _, r := range text {
+		switch r {
+		case '\r':
+			f.HandleEvent(NewEventKey(KeyEnter, 0, ModNone))
+		case '\t':
+			f.HandleEvent(NewEventKey(KeyTab, 0, ModNone))
+		default:
+			f.HandleEvent(NewEventKey(KeyRune, r, ModNone))
+		}
+	}
+	f.HandleEvent(NewEventPaste(false))
+}
+
+func TestSimpleFrame_Paste_InsertMode(t *testing.T) {
+	f, _ := newTestFrame(t, []string{"ab", "cd"}, 20, 10)
+	f.handleEventKey(*NewEventKey(KeyRune, 'l', ModNone))
+	f.handleEventKey(*NewEventKey(KeyRune, 'i', ModNone))
+
+	sendPaste(f, "x:\r\tiu\ry")
+
+	assert.EqualValues(t, []string{"ax:", "\tiu", "yb", "cd"}, f.buffer.lines())
+	assert.EqualValues(t, ModeInsert, f.mode, "pasted keys are not commands")
+	assert.EqualValues(t, 1, f.cursor.XPos())
+	assert.EqualValues(t, 2, f.cursor.YPos())
+}
+
+func TestSimpleFrame_Paste_IsUndoneAsOneChange(t *testing.T) {
+	f, _ := newTestFrame(t, []string{"ab"}, 20, 10)
+	f.handleEventKey(*NewEventKey(KeyRune, 'i', ModNone))
+	f.handleEventKey(*NewEventKey(KeyRune, 'x', ModNone))
+	sendPaste(f, "1\r2\r3")
+	f.handleEventKey(*NewEventKey(KeyRune, 'y', ModNone))
+	f.handleEventKey(*NewEventKey(KeyEscape, 0, ModNone))
+	assert.EqualValues(t, []string{"x1", "2", "3yab"}, f.buffer.lines())
+
+	f.handleEventKey(*NewEventKey(KeyRune, 'u', ModNone))
+	assert.EqualValues(t, []string{"x1", "2", "3ab"}, f.buffer.lines())
+	f.handleEventKey(*NewEventKey(KeyRune, 'u', ModNone))
+	assert.EqualValues(t, []string{"xab"}, f.buffer.lines())
+	f.handleEventKey(*NewEventKey(KeyRune, 'u', ModNone))
+	assert.EqualValues(t, []string{"ab"}, f.buffer.lines())
+
+	f.handleEventKey(*NewEventKey(KeyCtrlR, 0, ModNone))
+	f.handleEventKey(*NewEventKey(KeyCtrlR, 0, ModNone))
+	assert.EqualValues(t, []string{"x1", "2", "3ab"}, f.buffer.lines())
+}
+
+func TestSimpleFrame_Paste_NormalMode(t *testing.T) {
+	f, _ := newTestFrame(t, []string{"ab"}, 20, 10)
+	f.cursor.MoveTo(1, 0)
+
+	sendPaste(f, "xy\r\n")
+
+	assert.EqualValues(t, []string{"axy", "", "b"}, f.buffer.lines())
+	assert.EqualValues(t, ModeNormal, f.mode)
+}
+
+func TestSimpleFrame_Paste_CommandLine(t *testing.T) {
+	f, _ := newTestFrame(t, []string{"ab"}, 20, 10)
+	f.handleEventKey(*NewEventKey(KeyRune, ':', ModNone))
+
+	sendPaste(f, "set so=2\rignored")
+
+	assert.EqualValues(t, ":set so=2", f.bottomLine())
+	assert.EqualValues(t, []string{"ab"}, f.buffer.lines())
+}
+
+func TestSimpleFrame_Paste_Pager(t *testing.T) {
+	f, _ := newTestFrame(t, []string{"ab"}, 20, 10)
+	f.startPager(false)
+	tick := f.changedTick
+
+	sendPaste(f, "xy")
+
+	assert.EqualValues(t, []string{"ab"}, f.buffer.lines())
+	assert.EqualValues(t, tick, f.changedTick)
+	assert.False(t, f.modified)
+	f.handleEventKey(*NewEventKey(KeyRune, ':', ModNone))
+	sendPaste(f, "q")
+	assert.EqualValues(t, ":q", f.bottomLine())
+}
+
+func TestSimpleFrame_Paste_RedrawsLinesBelow(t *testing.T) {
+	f, ss := newTestFrame(t, []string{"ab", "cd"}, 5, 6)
+	f.mode = ModeInsert
+	f.Show()
+	f.Paste("1\n2\n")
+	f.Show()
+
+	assert.EqualValues(t, []string{"1    ", "2    ", "ab   ", "cd   ", "~    ", " -- I"}, screenContents(ss))
+}
@@ -1,22 +1,26 @@
 package mog
 
 import (
-	"log"
-	"os"
-
-	"github.com/gdamore/tcell/v2"
+	"fmt"
+	"runtime/debug"
+	"strings"
 )
 
 type Frame interface {
 	MoveCursor(dir)
 	Show()
-	PollEvent() tcell.Event
+	PollEvent() Event
 
 	// HandleEvent returns true if the Frame closed as a result of this event
 	// and otherwise false.
-	HandleEvent(tcell.Event) bool
+	HandleEvent(Event) bool
 	Close() error
 
+	// Rescue is called instead of Close after a panic. It restores the
+	// terminal and saves the buffer if it was modified, returning how to
+	// recover the changes or "" if there was nothing to save.
+	Rescue() (string, error)
+
 	// InsertRune inserts a rune at the current cursor position.
 	// Inserting a run 'a' into a line 'bb' at position 0 would
 	// yield 'abb'.
@@ -36,44 +40,96 @@ const (
 	dirLeft
 )
 
-// NewProgram creates a new program with an empty buffer
-func NewProgram() *Program {
-	return &Program{
-		frame: EmptyFrame(),
+// NewProgram creates a new program with an empty buffer shown on s.
+func NewProgram(s Screen) (*Program, error) {
+	f, err := EmptyFrame(s)
+	if err != nil {
+		return nil, err
 	}
+	return &Program{frame: f}, nil
 }
 
-func NewProgramFromFile(filename string) (*Program, error) {
-	return &Program{
-		frame: NewFrameFromFile(filename),
-	}, nil
+// NewProgramFromFile creates a new program editing the file at filename.
+func NewProgramFromFile(s Screen, filename string) (*Program, error) {
+	f, err := NewFrameFromFile(s, filename)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{frame: f}, nil
 }
 
-// Start sets up the program and starts the event loop
-func (p *Program) Start() {
-	p.run()
+// NewProgramRecoveringFile creates a new program editing the file at
+// filename with the changes saved in its swap file.
+func NewProgramRecoveringFile(s Screen, filename string) (*Program, error) {
+	f, err := NewFrameRecoveringFile(s, filename)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{frame: f}, nil
 }
 
-// Quit exits the program
-func (p *Program) Quit() {
-	err := p.frame.Close()
-	if err != nil {
-		log.Fatalf("%+v", err)
+// Start sets up the program and runs the event loop until the frame is
+// closed. The terminal has been restored when it returns.
+func (p *Program) Start() error {
+	if err := p.run(); err != nil {
+		return err
 	}
-	os.Exit(0)
+	return p.Quit()
+}
+
+// Quit closes the frame, which restores the terminal.
+func (p *Program) Quit() error {
+	return p.frame.Close()
 }
 
 func (p *Program) Show() {
 	p.frame.Show()
 }
 
-func (p *Program) run() {
+// run runs the event loop. A panic in it is turned into a *PanicError after
+// the frame has been rescued.
+func (p *Program) run() (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = p.rescue(v, debug.Stack())
+		}
+	}()
 	for {
 		p.Show()
 		ev := p.frame.PollEvent()
 
 		if closed := p.frame.HandleEvent(ev); closed {
-			break
+			return nil
 		}
 	}
 }
+
+func (p *Program) rescue(v interface{}, stack []byte) error {
+	rescued, err := p.frame.Rescue()
+	return &PanicError{Value: v, Stack: stack, Rescued: rescued, RescueErr: err}
+}
+
+// PanicError is returned by Start when the editor panicked.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+	// Rescued tells how to recover the changes that were not written, if
+	// there were any.
+	Rescued string
+	// RescueErr is set if the changes could not be saved.
+	RescueErr error
+}
+
+func (e *PanicError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "panic: %v\n\n%s\n", e.Value, e.Stack)
+	switch {
+	case e.RescueErr != nil:
+		fmt.Fprintf(&b, "Your changes could not be saved: %v", e.RescueErr)
+	case e.Rescued != "":
+		b.WriteString(e.Rescued)
+	default:
+		b.WriteString("There were no unsaved changes.")
+	}
+	return b.String()
+}
@@ -0,0 +1,29 @@
+package mog
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// prompt is a question shown at the bottom of the screen. Until it is
+// answered by typing one of the keys of answers every other key is ignored.
+type prompt struct {
+	lines   []message
+	answers map[rune]func(f *SimpleFrame)
+}
+
+func (p *prompt) addLine(isError bool, format string, a ...interface{}) {
+	p.lines = append(p.lines, message{text: fmt.Sprintf(format, a...), isError: isError})
+}
+
+func (f *SimpleFrame) handlePromptKey(ev EventKey) {
+	if ev.Key() != KeyRune {
+		return
+	}
+	answer, ok := f.prompt.answers[unicode.ToLower(ev.Rune())]
+	if !ok {
+		return
+	}
+	f.prompt = nil
+	answer(f)
+}
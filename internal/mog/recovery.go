@@ -0,0 +1,43 @@
+package mog
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Rescue restores the terminal after a panic and saves the buffer if it was
+// modified: to the swap file, which is kept so that the changes can be
+// recovered with mog -r, or for a buffer without a file to a file in the
+// temporary directory. It returns how to get the changes back.
+func (f *SimpleFrame) Rescue() (string, error) {
+	f.screen.Fini()
+	if !f.modified {
+		if f.swap != nil {
+			_ = os.Remove(f.swap.path)
+		}
+		return "", nil
+	}
+	if f.swap != nil && f.lazy == nil && f.hex == nil {
+		if err := f.swap.write(f.buffer.lines(), f.fileFormat, true); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Your changes were saved to the swap file %s.\nRun mog -r %s to recover them.", f.swap.path, f.filePath), nil
+	}
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("mog-%d.recover", os.Getpid()))
+	if f.lazy != nil || f.hex != nil {
+		// Large files and the bytes of the hex view are not kept in the
+		// swap file, so all of the file is saved.
+		if f.swap != nil {
+			_ = os.Remove(f.swap.path)
+		}
+		if _, err := f.writeBuffer(path); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Your changes were saved to %s.", path), nil
+	}
+	if err := os.WriteFile(path, []byte(joinLines(f.buffer.lines(), f.fileFormat)), 0o600); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Your changes were saved to %s.", path), nil
+}
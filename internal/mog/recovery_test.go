@@ -0,0 +1,69 @@
+package mog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// panickingFrame panics on the first event it handles.
+type panickingFrame struct {
+	*SimpleFrame
+}
+
+func (f panickingFrame) HandleEvent(Event) bool {
+	panic("boom")
+}
+
+func newPanickingProgram(t *testing.T, filePath string, modified bool) *Program {
+	ss := NewSimulationScreen()
+	assert.Nil(t, ss.Init())
+	f := &SimpleFrame{
+		screen:   ss,
+		buffer:   newLineBuffer([]string{"changed", ""}),
+		cursor:   NewSimpleCursor(),
+		mode:     ModeNormal,
+		modified: modified,
+	}
+	if filePath != "" {
+		f.filePath = filePath
+		assert.Nil(t, f.createSwapFile(swapFilePathOf(filePath), time.Now()))
+	}
+	ss.PostEvent(NewEventKey(KeyRune, 'x', ModNone))
+	return &Program{frame: panickingFrame{f}}
+}
+
+func TestProgram_Start_RescuesChangesAfterPanic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	p := newPanickingProgram(t, path, true)
+
+	err := p.Start()
+
+	var pe *PanicError
+	assert.True(t, errors.As(err, &pe))
+	assert.EqualValues(t, "boom", pe.Value)
+	assert.Contains(t, string(pe.Stack), "HandleEvent")
+	assert.Contains(t, err.Error(), "Run mog -r "+path+" to recover them.")
+
+	info, buffer, rerr := readSwapFile(swapFilePathOf(path))
+	assert.Nil(t, rerr)
+	assert.True(t, info.modified)
+	assert.EqualValues(t, []string{"changed", ""}, buffer)
+}
+
+func TestProgram_Start_PanicWithoutChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	p := newPanickingProgram(t, path, false)
+
+	err := p.Start()
+
+	var pe *PanicError
+	assert.True(t, errors.As(err, &pe))
+	assert.EqualValues(t, "", pe.Rescued)
+	_, serr := os.Stat(swapFilePathOf(path))
+	assert.True(t, os.IsNotExist(serr), "a swap file without changes is removed")
+}
@@ -0,0 +1,199 @@
+package mog
+
+import (
+	"errors"
+	"sync"
+)
+
+// Screen is a grid of cells a frame is drawn on and the source of the events
+// it handles. A terminal library, a remote client or a test can each provide
+// one, the editor does not depend on any of them.
+type Screen interface {
+	// Init prepares the screen for use, Fini restores whatever it took
+	// over when the editor is done with it.
+	Init() error
+	Fini()
+
+	// Size returns the number of columns and rows of cells.
+	Size() (w, h int)
+	// SetContent sets the rune, followed by combining runes, and the
+	// style of a cell. Nothing changes on the screen until Show is
+	// called.
+	SetContent(x, y int, mainc rune, combc []rune, style Style)
+	// SetStyle sets the style Clear fills the screen with.
+	SetStyle(style Style)
+	Clear()
+	// ShowCursor shows the cursor at x, y, or hides it when they are
+	// outside the screen.
+	ShowCursor(x, y int)
+	// Show shows the changes made to the cells since it was last called,
+	// Sync redraws all of them.
+	Show()
+	Sync()
+	// Colors returns the number of colors the screen can show, which is
+	// 1<<24 when any RGB value can be shown.
+	Colors() int
+
+	EnableMouse()
+	DisableMouse()
+	// EnablePaste asks for text that is pasted to be marked by
+	// EventPaste.
+	EnablePaste()
+
+	// PollEvent waits for the next event and returns it, or nil once the
+	// screen was finished.
+	PollEvent() Event
+	// PostEvent adds an event to those returned by PollEvent. It must be
+	// safe to call from any goroutine, and returns ErrEventQueueFull
+	// rather than waiting if there is no room for the event.
+	PostEvent(ev Event) error
+}
+
+// ErrEventQueueFull is returned by PostEvent if the event cannot be posted
+// because too many events are waiting.
+var ErrEventQueueFull = errors.New("event queue full")
+
+// eventQueueSize is how many events a SimulationScreen holds.
+const eventQueueSize = 10
+
+// Cell is a cell of a SimulationScreen.
+type Cell struct {
+	Runes []rune
+	Style Style
+}
+
+// SimulationScreen is a screen that keeps its cells in memory, for running
+// the editor without a terminal and for tests. It starts out 80x25 cells
+// large.
+type SimulationScreen struct {
+	mu         sync.Mutex
+	w, h       int
+	cells      []Cell
+	style      Style
+	cursorX    int
+	cursorY    int
+	events     chan Event
+	quit       chan struct{}
+	finishOnce sync.Once
+}
+
+// NewSimulationScreen returns a screen that is not shown anywhere.
+func NewSimulationScreen() *SimulationScreen {
+	s := &SimulationScreen{
+		events:  make(chan Event, eventQueueSize),
+		quit:    make(chan struct{}),
+		cursorX: -1,
+		cursorY: -1,
+	}
+	s.SetSize(80, 25)
+	return s
+}
+
+func (s *SimulationScreen) Init() error {
+	return nil
+}
+
+func (s *SimulationScreen) Fini() {
+	s.finishOnce.Do(func() { close(s.quit) })
+}
+
+func (s *SimulationScreen) Size() (w, h int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w, s.h
+}
+
+// SetSize changes the size of the screen, keeping the cells that still fit.
+func (s *SimulationScreen) SetSize(w, h int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cells := make([]Cell, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < s.w && y < s.h {
+				cells[y*w+x] = s.cells[y*s.w+x]
+			} else {
+				cells[y*w+x] = Cell{Runes: []rune{' '}, Style: s.style}
+			}
+		}
+	}
+	s.w, s.h, s.cells = w, h, cells
+}
+
+func (s *SimulationScreen) SetContent(x, y int, mainc rune, combc []rune, style Style) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if x < 0 || y < 0 || x >= s.w || y >= s.h {
+		return
+	}
+	s.cells[y*s.w+x] = Cell{Runes: append([]rune{mainc}, combc...), Style: style}
+}
+
+func (s *SimulationScreen) SetStyle(style Style) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.style = style
+}
+
+func (s *SimulationScreen) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.cells {
+		s.cells[i] = Cell{Runes: []rune{' '}, Style: s.style}
+	}
+}
+
+func (s *SimulationScreen) ShowCursor(x, y int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursorX, s.cursorY = x, y
+}
+
+func (s *SimulationScreen) Show() {}
+
+func (s *SimulationScreen) Sync() {}
+
+func (s *SimulationScreen) Colors() int {
+	return 256
+}
+
+func (s *SimulationScreen) EnableMouse() {}
+
+func (s *SimulationScreen) DisableMouse() {}
+
+func (s *SimulationScreen) EnablePaste() {}
+
+func (s *SimulationScreen) PollEvent() Event {
+	select {
+	case <-s.quit:
+		return nil
+	case ev := <-s.events:
+		return ev
+	}
+}
+
+func (s *SimulationScreen) PostEvent(ev Event) error {
+	select {
+	case s.events <- ev:
+		return nil
+	default:
+		return ErrEventQueueFull
+	}
+}
+
+// GetContents returns a copy of the cells of the screen, row by row, and
+// its size.
+func (s *SimulationScreen) GetContents() (cells []Cell, w, h int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cells = make([]Cell, len(s.cells))
+	copy(cells, s.cells)
+	return cells, s.w, s.h
+}
+
+// GetCursor returns where the cursor is shown and whether it is visible.
+func (s *SimulationScreen) GetCursor() (x, y int, visible bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursorX, s.cursorY, s.cursorX >= 0 && s.cursorY >= 0 && s.cursorX < s.w && s.cursorY < s.h
+}
@@ -0,0 +1,211 @@
+package mog
+
+// textHeight returns the number of screen lines available for buffer
+// content. The bottom line of the screen is reserved for the mode line.
+func (f *SimpleFrame) textHeight() int {
+	_, h := f.screen.Size()
+	if h < 2 {
+		return 1
+	}
+	return h - 1
+}
+
+// lineHeight returns the number of screen lines buffer line y occupies
+// when wrapped to the width of the screen.
+func (f *SimpleFrame) lineHeight(y int) int {
+	return f.syncLayout().lineHeight(&f.buffer, y)
+}
+
+// rowsBetween returns the number of screen lines occupied by the buffer
+// lines from (inclusive) and to (exclusive).
+func (f *SimpleFrame) rowsBetween(from, to int) int {
+	rows := 0
+	for y := from; y < to; y++ {
+		rows += f.lineHeight(y)
+	}
+	return rows
+}
+
+// effectiveScrollOff returns the scrolloff value limited to what fits on
+// the screen, so that a large value keeps the cursor line centered.
+func (f *SimpleFrame) effectiveScrollOff() int {
+	limit := (f.textHeight() - 1) / 2
+	if f.scrollOff > limit {
+		return limit
+	}
+	return f.scrollOff
+}
+
+// lastVisibleLine returns the last buffer line that is completely visible
+// on the screen. The line at the offset is always considered visible, even
+// when it is too long to fit.
+func (f *SimpleFrame) lastVisibleLine() int {
+	rows := 0
+	y := f.offset
+	for ; y < f.buffer.len(); y++ {
+		rows += f.lineHeight(y)
+		if rows > f.textHeight() {
+			break
+		}
+	}
+	if y-1 < f.offset {
+		return f.offset
+	}
+	return y - 1
+}
+
+// topForBottom returns the smallest offset for which buffer line y is still
+// completely visible at the bottom of the screen.
+func (f *SimpleFrame) topForBottom(y int) int {
+	rows := f.lineHeight(y)
+	top := y
+	for top > 0 && rows+f.lineHeight(top-1) <= f.textHeight() {
+		top--
+		rows += f.lineHeight(top)
+	}
+	return top
+}
+
+// scrollToCursor adjusts the offset so that the cursor line, together with
+// scrolloff lines of context above and below it, is visible on the screen.
+func (f *SimpleFrame) scrollToCursor() {
+	y := f.cursor.YPos()
+	so := f.effectiveScrollOff()
+	if top := y - so; top < f.offset {
+		f.offset = maxInt(top, 0)
+	}
+	bottom := minInt(y+so, f.buffer.len()-1)
+	if top := minInt(f.topForBottom(bottom), y); top > f.offset {
+		f.offset = top
+	}
+}
+
+// cursorBounds returns the first and last buffer line the cursor may be on
+// without scrolling, taking scrolloff into account. No context is kept above
+// the first or below the last line of the buffer.
+func (f *SimpleFrame) cursorBounds() (int, int) {
+	so := f.effectiveScrollOff()
+	top, bottom := f.offset, f.lastVisibleLine()
+	if top > 0 {
+		top += so
+	}
+	if bottom < f.buffer.len()-1 {
+		bottom -= so
+	}
+	if bottom < top {
+		bottom = top
+	}
+	return minInt(top, f.buffer.len()-1), minInt(bottom, f.buffer.len()-1)
+}
+
+// clampCursorToView moves the cursor to the closest line that is visible
+// after the view has been scrolled.
+func (f *SimpleFrame) clampCursorToView() {
+	top, bottom := f.cursorBounds()
+	y := f.cursor.YPos()
+	if y < top {
+		y = top
+	}
+	if y > bottom {
+		y = bottom
+	}
+	f.cursor.MoveTo(f.cursor.XPos(), y)
+}
+
+// ScrollLines scrolls the view n lines downwards, or upwards for negative
+// n, without moving the cursor unless it would leave the screen (Ctrl-E and
+// Ctrl-Y).
+func (f *SimpleFrame) ScrollLines(n int) {
+	f.offset = clampInt(f.offset+n, 0, f.buffer.len()-1)
+	f.clampCursorToView()
+	f.showCursor()
+}
+
+// ScrollHalfPage scrolls the view and the cursor half a screen downwards,
+// or upwards for negative dir (Ctrl-D and Ctrl-U).
+func (f *SimpleFrame) ScrollHalfPage(dir int) {
+	n := maxInt(f.textHeight()/2, 1) * dir
+	last := f.buffer.len() - 1
+	if dir > 0 && f.lastVisibleLine() < last || dir < 0 && f.offset > 0 {
+		f.offset = clampInt(f.offset+n, 0, last)
+	}
+	f.cursor.MoveTo(f.cursor.XPos(), clampInt(f.cursor.YPos()+n, 0, last))
+	f.scrollToCursor()
+	f.clampCursorToView()
+	f.showCursor()
+}
+
+// ScrollPage scrolls the view a screen downwards, or upwards for negative
+// dir, keeping two lines of the previous screen visible (Ctrl-F and
+// Ctrl-B).
+func (f *SimpleFrame) ScrollPage(dir int) {
+	if dir > 0 {
+		f.offset = clampInt(maxInt(f.lastVisibleLine()-1, f.offset+1), 0, f.buffer.len()-1)
+	} else {
+		f.offset = f.topForBottom(minInt(f.offset+1, f.buffer.len()-1))
+	}
+	f.clampCursorToView()
+	f.showCursor()
+}
+
+// ScrollCursorTo scrolls the view so that the cursor line is at the top
+// ('t'), middle ('z') or bottom ('b') of the screen.
+func (f *SimpleFrame) ScrollCursorTo(pos rune) {
+	y := f.cursor.YPos()
+	so := f.effectiveScrollOff()
+	switch pos {
+	case 't':
+		f.offset = maxInt(y-so, 0)
+	case 'b':
+		f.offset = f.topForBottom(minInt(y+so, f.buffer.len()-1))
+	case 'z':
+		above := (f.textHeight() - f.lineHeight(y)) / 2
+		top := y
+		for top > 0 && f.lineHeight(top-1) <= above {
+			top--
+			above -= f.lineHeight(top)
+		}
+		f.offset = top
+	}
+	f.clampCursorToView()
+	f.showCursor()
+}
+
+// MoveCursorToScreenLine moves the cursor to the top ('H'), middle ('M') or
+// bottom ('L') line of the screen.
+func (f *SimpleFrame) MoveCursorToScreenLine(pos rune) {
+	top, bottom := f.cursorBounds()
+	y := top
+	switch pos {
+	case 'M':
+		last := f.lastVisibleLine()
+		half := (f.rowsBetween(f.offset, last+1) - 1) / 2
+		y = f.offset
+		for rows := f.lineHeight(y); rows <= half && y < last; rows += f.lineHeight(y) {
+			y++
+		}
+	case 'L':
+		y = bottom
+	}
+	f.cursor.MoveTo(f.cursor.XPos(), y)
+	f.clampCursorToView()
+	f.showCursor()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clampInt(v, lo, hi int) int {
+	return maxInt(lo, minInt(v, hi))
+}
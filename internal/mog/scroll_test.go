@@ -0,0 +1,242 @@
+package mog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimpleFrame_MoveCursor_KeepsScrollOffLinesVisible(t *testing.T) {
+	// 10 lines of text fit on the screen
+	f, _ := newTestFrame(t, numberedLines(100), 10, 11)
+	f.scrollOff = 3
+
+	for i := 0; i < 7; i++ {
+		f.MoveCursor(dirDown)
+	}
+	assert.EqualValues(t, 7, f.cursor.YPos())
+	assert.EqualValues(t, 1, f.offset)
+
+	f.cursor.MoveTo(0, 50)
+	f.offset = 47
+	f.MoveCursor(dirUp)
+	assert.EqualValues(t, 46, f.offset)
+	f.MoveCursor(dirUp)
+	assert.EqualValues(t, 45, f.offset)
+}
+
+func TestSimpleFrame_MoveCursor_ScrollsPastWrappedLines(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(4), 3, 4)
+	f.buffer.setLine(0, "abcde")
+	f.MoveCursor(dirDown)
+	assert.EqualValues(t, 0, f.offset)
+
+	// Line 0 occupies two of the three text rows, so line 2 needs the view
+	// to be scrolled past it entirely.
+	f.MoveCursor(dirDown)
+	assert.EqualValues(t, 1, f.offset)
+}
+
+//nolint:funlen
+func TestSimpleFrame_Scrolling(t *testing.T) {
+	tests := []struct {
+		name           string
+		offset         int
+		cursorY        int
+		scrollOff      int
+		action         func(f *SimpleFrame)
+		expectedOffset int
+		expectedY      int
+	}{
+		{
+			name:           "Ctrl-E scrolls without moving the cursor",
+			cursorY:        5,
+			action:         func(f *SimpleFrame) { f.ScrollLines(1) },
+			expectedOffset: 1,
+			expectedY:      5,
+		},
+		{
+			name:           "Ctrl-E drags the cursor along with the top of the screen",
+			action:         func(f *SimpleFrame) { f.ScrollLines(1) },
+			expectedOffset: 1,
+			expectedY:      1,
+		},
+		{
+			name:           "Ctrl-E respects scrolloff",
+			scrollOff:      2,
+			cursorY:        2,
+			action:         func(f *SimpleFrame) { f.ScrollLines(1) },
+			expectedOffset: 1,
+			expectedY:      3,
+		},
+		{
+			name:           "Ctrl-Y cannot scroll above the first line",
+			cursorY:        3,
+			action:         func(f *SimpleFrame) { f.ScrollLines(-1) },
+			expectedOffset: 0,
+			expectedY:      3,
+		},
+		{
+			name:           "Ctrl-Y drags the cursor along with the bottom of the screen",
+			offset:         10,
+			cursorY:        19,
+			action:         func(f *SimpleFrame) { f.ScrollLines(-1) },
+			expectedOffset: 9,
+			expectedY:      18,
+		},
+		{
+			name:           "Ctrl-D scrolls view and cursor half a screen",
+			cursorY:        2,
+			action:         func(f *SimpleFrame) { f.ScrollHalfPage(1) },
+			expectedOffset: 5,
+			expectedY:      7,
+		},
+		{
+			name:           "Ctrl-U scrolls view and cursor half a screen",
+			offset:         20,
+			cursorY:        22,
+			action:         func(f *SimpleFrame) { f.ScrollHalfPage(-1) },
+			expectedOffset: 15,
+			expectedY:      17,
+		},
+		{
+			name:           "Ctrl-U only moves the cursor at the top",
+			cursorY:        7,
+			action:         func(f *SimpleFrame) { f.ScrollHalfPage(-1) },
+			expectedOffset: 0,
+			expectedY:      2,
+		},
+		{
+			name:           "Ctrl-F keeps two lines of the previous page",
+			action:         func(f *SimpleFrame) { f.ScrollPage(1) },
+			expectedOffset: 8,
+			expectedY:      8,
+		},
+		{
+			name:           "Ctrl-B keeps two lines of the previous page",
+			offset:         20,
+			cursorY:        25,
+			action:         func(f *SimpleFrame) { f.ScrollPage(-1) },
+			expectedOffset: 12,
+			expectedY:      21,
+		},
+		{
+			name:           "zt puts the cursor line at the top",
+			offset:         20,
+			cursorY:        25,
+			action:         func(f *SimpleFrame) { f.ScrollCursorTo('t') },
+			expectedOffset: 25,
+			expectedY:      25,
+		},
+		{
+			name:           "zt keeps scrolloff lines above the cursor",
+			scrollOff:      2,
+			offset:         20,
+			cursorY:        25,
+			action:         func(f *SimpleFrame) { f.ScrollCursorTo('t') },
+			expectedOffset: 23,
+			expectedY:      25,
+		},
+		{
+			name:           "zb puts the cursor line at the bottom",
+			offset:         20,
+			cursorY:        25,
+			action:         func(f *SimpleFrame) { f.ScrollCursorTo('b') },
+			expectedOffset: 16,
+			expectedY:      25,
+		},
+		{
+			name:           "zz puts the cursor line in the middle",
+			offset:         20,
+			cursorY:        25,
+			action:         func(f *SimpleFrame) { f.ScrollCursorTo('z') },
+			expectedOffset: 21,
+			expectedY:      25,
+		},
+		{
+			name:           "zz near the top of the buffer does not scroll past it",
+			offset:         1,
+			cursorY:        2,
+			action:         func(f *SimpleFrame) { f.ScrollCursorTo('z') },
+			expectedOffset: 0,
+			expectedY:      2,
+		},
+		{
+			name:           "H moves to the top of the screen",
+			offset:         20,
+			cursorY:        25,
+			action:         func(f *SimpleFrame) { f.MoveCursorToScreenLine('H') },
+			expectedOffset: 20,
+			expectedY:      20,
+		},
+		{
+			name:           "H respects scrolloff",
+			scrollOff:      3,
+			offset:         20,
+			cursorY:        25,
+			action:         func(f *SimpleFrame) { f.MoveCursorToScreenLine('H') },
+			expectedOffset: 20,
+			expectedY:      23,
+		},
+		{
+			name:           "M moves to the middle of the screen",
+			offset:         20,
+			cursorY:        20,
+			action:         func(f *SimpleFrame) { f.MoveCursorToScreenLine('M') },
+			expectedOffset: 20,
+			expectedY:      24,
+		},
+		{
+			name:           "L moves to the bottom of the screen",
+			offset:         20,
+			cursorY:        21,
+			action:         func(f *SimpleFrame) { f.MoveCursorToScreenLine('L') },
+			expectedOffset: 20,
+			expectedY:      29,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 10 lines of text fit on the screen
+			f, _ := newTestFrame(t, numberedLines(100), 10, 11)
+			f.offset = tt.offset
+			f.scrollOff = tt.scrollOff
+			f.cursor.MoveTo(0, tt.cursorY)
+
+			tt.action(f)
+
+			assert.EqualValues(t, tt.expectedOffset, f.offset, "offset")
+			assert.EqualValues(t, tt.expectedY, f.cursor.YPos(), "cursor line")
+		})
+	}
+}
+
+func TestSimpleFrame_lastVisibleLine_WithWrappedLines(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(10), 3, 5)
+	f.buffer.setLine(1, "abcde")
+	f.buffer.setLine(3, "abc")
+
+	// Lines 0 and 1 take up three of the four text rows, line 2 the last one.
+	assert.EqualValues(t, 2, f.lastVisibleLine())
+	f.offset = 2
+	assert.EqualValues(t, 4, f.lastVisibleLine())
+}
+
+func TestSimpleFrame_handleEventKey_ZCommands(t *testing.T) {
+	f, _ := newTestFrame(t, numberedLines(100), 10, 11)
+	f.cursor.MoveTo(0, 50)
+	f.offset = 45
+
+	f.handleEventKey(*NewEventKey(KeyRune, 'z', ModNone))
+	assert.EqualValues(t, 45, f.offset)
+	f.handleEventKey(*NewEventKey(KeyRune, 't', ModNone))
+	assert.EqualValues(t, 50, f.offset)
+	assert.EqualValues(t, "", f.pending)
+
+	// A key that does not complete the command cancels it
+	f.handleEventKey(*NewEventKey(KeyRune, 'z', ModNone))
+	f.handleEventKey(*NewEventKey(KeyRune, 'q', ModNone))
+	assert.EqualValues(t, 50, f.offset)
+	assert.EqualValues(t, "", f.pending)
+	assert.EqualValues(t, []string{"0", "1"}, f.buffer.slice(0, 2))
+}
@@ -0,0 +1,95 @@
+package mog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// search moves the cursor to the next match of pattern, or of the pattern
+// searched for last if it is empty.
+func (f *SimpleFrame) search(pattern string) error {
+	if f.hex != nil {
+		return f.hexSearch(pattern)
+	}
+	return f.searchText(pattern)
+}
+
+// searchText moves the cursor to the start of the next match of the regular
+// expression pattern after it, continuing at the top of the buffer.
+func (f *SimpleFrame) searchText(pattern string) error {
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return editorErrorf(383, "Invalid search string: %s", pattern)
+		}
+		f.lastSearch = re
+		if f.hlSearch {
+			f.damage.markAll()
+		}
+	}
+	if f.lastSearch == nil {
+		return editorErrorf(35, "No previous regular expression")
+	}
+	pos := f.cursorPos()
+	for i := 0; i <= f.buffer.len(); i++ {
+		y := (pos.y + i) % f.buffer.len()
+		line := f.lineText(y)
+		from := 0
+		if i == 0 {
+			from = minInt(nextCharStart(line, pos.x), len(line))
+		}
+		loc := f.lastSearch.FindStringIndex(line[from:])
+		if loc == nil || i == f.buffer.len() && from+loc[0] > pos.x {
+			continue
+		}
+		if y < pos.y || i == f.buffer.len() {
+			f.showMessage("search hit BOTTOM, continuing at TOP")
+		}
+		f.ensureLoaded(y, y+1)
+		f.cursor.MoveTo(from+loc[0], y)
+		f.scrollToCursor()
+		return nil
+	}
+	return editorErrorf(486, "Pattern not found: %s", f.lastSearch)
+}
+
+// searchMatches returns the matches of the pattern searched for last in
+// line, as spans of GroupSearch, if hlsearch is set.
+func (f *SimpleFrame) searchMatches(line string) []Span {
+	if !f.hlSearch || f.lastSearch == nil {
+		return nil
+	}
+	var spans []Span
+	for _, loc := range f.lastSearch.FindAllStringIndex(line, -1) {
+		if loc[0] < loc[1] {
+			spans = append(spans, Span{Start: loc[0], End: loc[1], Group: GroupSearch})
+		}
+	}
+	return spans
+}
+
+func (f *SimpleFrame) setHlSearch(v optionValue) {
+	f.hlSearch = v.b
+	f.damage.markAll()
+}
+
+// goToLine implements the ex command that is just a line number, or $ for
+// the last line, and moves the cursor to the first non-blank character of
+// that line.
+func (f *SimpleFrame) goToLine(line string) error {
+	y := f.buffer.len() - 1
+	if line != "$" {
+		// Ranges of lines are not supported.
+		n, err := strconv.Atoi(line)
+		if err != nil {
+			return errNotAnEditorCommand(line)
+		}
+		y = clampInt(n-1, 0, f.buffer.len()-1)
+	}
+	f.ensureLoaded(y, y+1)
+	x := strings.IndexFunc(f.buffer.line(y), func(r rune) bool { return !unicode.IsSpace(r) })
+	f.moveCursorTo(bufferPos{x: maxInt(x, 0), y: y})
+	return nil
+}
@@ -0,0 +1,78 @@
+package mog
+
+// AttrMask is the set of attributes text is drawn with besides its colors.
+type AttrMask int
+
+const (
+	AttrBold AttrMask = 1 << iota
+	AttrBlink
+	AttrReverse
+	AttrUnderline
+	AttrDim
+	AttrItalic
+	AttrStrikeThrough
+	AttrNone AttrMask = 0
+)
+
+// Style is how text is drawn: its colors and attributes. Styles are values,
+// each method returns a changed copy.
+type Style struct {
+	fg, bg Color
+	attrs  AttrMask
+}
+
+// StyleDefault draws text in the default colors of the screen, without any
+// attributes.
+var StyleDefault Style
+
+// Foreground returns s with the text color c.
+func (s Style) Foreground(c Color) Style {
+	s.fg = c
+	return s
+}
+
+// Background returns s with the background color c.
+func (s Style) Background(c Color) Style {
+	s.bg = c
+	return s
+}
+
+// Attributes returns s with exactly the attributes attrs.
+func (s Style) Attributes(attrs AttrMask) Style {
+	s.attrs = attrs
+	return s
+}
+
+func (s Style) setAttr(attr AttrMask, on bool) Style {
+	if on {
+		s.attrs |= attr
+	} else {
+		s.attrs &^= attr
+	}
+	return s
+}
+
+// Bold returns s with bold text, or without it if on is false.
+func (s Style) Bold(on bool) Style {
+	return s.setAttr(AttrBold, on)
+}
+
+// Italic returns s with italic text, or without it if on is false.
+func (s Style) Italic(on bool) Style {
+	return s.setAttr(AttrItalic, on)
+}
+
+// Reverse returns s with its colors swapped, or not if on is false.
+func (s Style) Reverse(on bool) Style {
+	return s.setAttr(AttrReverse, on)
+}
+
+// Underline returns s with underlined text, or without it if on is false.
+func (s Style) Underline(on bool) Style {
+	return s.setAttr(AttrUnderline, on)
+}
+
+// Decompose returns the colors and attributes of s.
+func (s Style) Decompose() (fg, bg Color, attrs AttrMask) {
+	return s.fg, s.bg, s.attrs
+}
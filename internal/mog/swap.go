@@ -0,0 +1,375 @@
+package mog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	swapFileSuffix = ".swp"
+	swapFileMagic  = "mog swap file"
+
+	// updateTime is how long after a change the buffer is written to the
+	// swap file.
+	updateTime = 4 * time.Second
+)
+
+// swapInfo is the header of a swap file. It tells who is editing the file
+// and, if there are changes that were not written, when they were saved to
+// the swap file.
+type swapInfo struct {
+	pid  int
+	host string
+	user string
+	file string
+	// mtime is the modification time of the file when it was loaded or
+	// last written.
+	mtime      time.Time
+	fileFormat string
+	modified   bool
+	saved      time.Time
+}
+
+func newSwapInfo(filePath string, mtime time.Time) swapInfo {
+	host, _ := os.Hostname()
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if abs, err := filepath.Abs(filePath); err == nil {
+		filePath = abs
+	}
+	return swapInfo{pid: os.Getpid(), host: host, user: name, file: filePath, mtime: mtime}
+}
+
+// stale reports whether the process that wrote the swap file is gone, which
+// can only be told for processes on this host.
+func (i swapInfo) stale() bool {
+	host, _ := os.Hostname()
+	return i.host == host && i.pid != os.Getpid() && !processAlive(i.pid)
+}
+
+// marshalSwapFile returns the contents of a swap file: a header of
+// "key: value" lines, an empty line and, if the buffer was modified, its
+// lines.
+func marshalSwapFile(info swapInfo, buffer []string) []byte {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, swapFileMagic)
+	fmt.Fprintf(&b, "pid: %d\n", info.pid)
+	fmt.Fprintf(&b, "host: %s\n", info.host)
+	fmt.Fprintf(&b, "user: %s\n", info.user)
+	fmt.Fprintf(&b, "file: %s\n", info.file)
+	fmt.Fprintf(&b, "mtime: %s\n", info.mtime.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "fileformat: %s\n", info.fileFormat)
+	fmt.Fprintf(&b, "modified: %t\n", info.modified)
+	fmt.Fprintf(&b, "saved: %s\n", info.saved.Format(time.RFC3339Nano))
+	fmt.Fprintln(&b)
+	if info.modified {
+		b.WriteString(joinLines(buffer, info.fileFormat))
+	}
+	return b.Bytes()
+}
+
+// parseSwapFile parses the contents of a swap file. The buffer is nil
+// unless the swap file holds changes.
+func parseSwapFile(bs []byte) (swapInfo, []string, error) {
+	var info swapInfo
+	r := bufio.NewReader(bytes.NewReader(bs))
+	readLine := func() (string, error) {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return strings.TrimSuffix(line, "\n"), err
+	}
+	magic, err := readLine()
+	if err != nil || magic != swapFileMagic {
+		return info, nil, fmt.Errorf("not a swap file")
+	}
+	for {
+		line, err := readLine()
+		if err != nil {
+			return info, nil, err
+		}
+		if line == "" {
+			break
+		}
+		kv := strings.SplitN(line, ": ", 2)
+		if len(kv) != 2 {
+			return info, nil, fmt.Errorf("invalid header line %q", line)
+		}
+		switch kv[0] {
+		case "pid":
+			info.pid, err = strconv.Atoi(kv[1])
+		case "host":
+			info.host = kv[1]
+		case "user":
+			info.user = kv[1]
+		case "file":
+			info.file = kv[1]
+		case "mtime":
+			info.mtime, err = time.Parse(time.RFC3339Nano, kv[1])
+		case "fileformat":
+			info.fileFormat = kv[1]
+		case "modified":
+			info.modified, err = strconv.ParseBool(kv[1])
+		case "saved":
+			info.saved, err = time.Parse(time.RFC3339Nano, kv[1])
+		}
+		if err != nil {
+			return info, nil, fmt.Errorf("invalid header line %q", line)
+		}
+	}
+	if !info.modified {
+		return info, nil, nil
+	}
+	rest, _ := io.ReadAll(r)
+	return info, splitLines(rest, info.fileFormat), nil
+}
+
+func readSwapFile(path string) (swapInfo, []string, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return swapInfo{}, nil, err
+	}
+	return parseSwapFile(bs)
+}
+
+// swapFile is the swap file of the file being edited.
+type swapFile struct {
+	path string
+	info swapInfo
+	// scheduled is set while a snapshot is about to be written.
+	scheduled bool
+}
+
+// write writes the swap file, including the buffer in the given file format
+// if it was modified.
+func (s *swapFile) write(buffer []string, format string, modified bool) error {
+	s.info.fileFormat = format
+	s.info.modified = modified
+	s.info.saved = time.Now()
+	return os.WriteFile(s.path, marshalSwapFile(s.info, buffer), 0o600)
+}
+
+// eventSnapshot is posted when it is time to save the buffer to the swap
+// file.
+type eventSnapshot struct {
+	EventTime
+}
+
+// createSwapFile starts using a new swap file at path for the loaded file.
+// If there already is a file at path, it is left alone and an error
+// satisfying os.IsExist is returned.
+func (f *SimpleFrame) createSwapFile(path string, mtime time.Time) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	s := &swapFile{path: path, info: newSwapInfo(f.filePath, mtime)}
+	if err := s.write(f.buffer.lines(), f.fileFormat, f.modified); err != nil {
+		_ = os.Remove(path)
+		return err
+	}
+	f.swap = s
+	return nil
+}
+
+// takeOverSwapFile replaces a swap file that was found by the one of the
+// loaded file.
+func (f *SimpleFrame) takeOverSwapFile(found *foundSwap, mtime time.Time) error {
+	if err := os.Remove(found.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return f.createSwapFile(found.path, mtime)
+}
+
+// createSwapFileBeside creates a swap file for the loaded file next to one
+// that is in the use of another session, named like it but ending in .swo,
+// or .swn and so on down to .swa if that is taken too.
+func (f *SimpleFrame) createSwapFileBeside(mtime time.Time) error {
+	path := swapFilePathOf(f.filePath)
+	for c := 'o'; c >= 'a'; c-- {
+		err := f.createSwapFile(path[:len(path)-1]+string(c), mtime)
+		if !os.IsExist(err) {
+			return err
+		}
+	}
+	return editorErrorf(326, "Too many swap files found")
+}
+
+// scheduleSnapshot makes sure the buffer is saved to the swap file soon
+// after it was changed. Large files are not saved, as most of their lines
+// were never read.
+func (f *SimpleFrame) scheduleSnapshot() {
+	if f.swap == nil || f.swap.scheduled || f.lazy != nil {
+		return
+	}
+	f.swap.scheduled = true
+	screen := f.screen
+	time.AfterFunc(updateTime, func() {
+		ev := &eventSnapshot{}
+		ev.SetEventNow()
+		_ = screen.PostEvent(ev)
+	})
+}
+
+// writeSnapshot saves the buffer to the swap file.
+func (f *SimpleFrame) writeSnapshot() {
+	if f.swap == nil {
+		return
+	}
+	f.swap.scheduled = false
+	if err := f.swap.write(f.buffer.lines(), f.fileFormat, f.modified); err != nil {
+		f.showError(editorErrorf(297, "Write error in swap file: %v", err))
+	}
+}
+
+// updateSwapFile records in the swap file that the buffer was written.
+func (f *SimpleFrame) updateSwapFile() {
+	if f.swap == nil {
+		return
+	}
+	if info, err := os.Stat(f.filePath); err == nil {
+		f.swap.info.mtime = info.ModTime()
+	}
+	f.writeSnapshot()
+}
+
+// foundSwap is a swap file that already existed when a file was loaded.
+type foundSwap struct {
+	path   string
+	info   swapInfo
+	buffer []string
+	// err is set if the swap file could not be read.
+	err error
+}
+
+// swapFilePrompt asks what to do about a swap file that was found when the
+// file at filePath, last modified at mtime, was loaded.
+func (f *SimpleFrame) swapFilePrompt(found *foundSwap, mtime time.Time) *prompt {
+	p := &prompt{answers: map[rune]func(*SimpleFrame){}}
+	p.addLine(true, "E325: ATTENTION")
+	p.addLine(false, "Found a swap file by the name %q", found.path)
+	if found.err != nil {
+		p.addLine(false, "          cannot be read: %v", found.err)
+	} else {
+		p.lines = append(p.lines, describeSwapFile(found.info)...)
+		if !found.info.mtime.Equal(mtime) {
+			p.addLine(false, "      The file has been changed since the swap file was created")
+		}
+	}
+	p.addLine(false, "While opening file %q", f.filePath)
+
+	choices := "[O]pen read-only, [E]dit anyway, "
+	p.answers['o'] = func(f *SimpleFrame) {
+		f.readonly = true
+		if err := f.createSwapFileBeside(mtime); err != nil {
+			f.showError(err)
+		}
+	}
+	p.answers['e'] = func(f *SimpleFrame) {
+		if err := f.createSwapFileBeside(mtime); err != nil {
+			f.showError(err)
+		}
+	}
+	p.answers['q'] = func(f *SimpleFrame) { f.closed = true }
+	if found.err == nil {
+		choices = "[R]ecover, " + choices
+		p.answers['r'] = func(f *SimpleFrame) {
+			if err := f.recoverSwapFile(found, mtime); err != nil {
+				f.showError(err)
+			}
+		}
+	}
+	if found.err != nil || found.info.stale() {
+		choices += "[D]elete it, "
+		p.answers['d'] = func(f *SimpleFrame) {
+			if err := f.takeOverSwapFile(found, mtime); err != nil {
+				f.showError(err)
+			}
+		}
+	}
+	p.addLine(false, "%s[Q]uit:", choices)
+	return p
+}
+
+func describeSwapFile(info swapInfo) []message {
+	running := "still running"
+	if info.stale() {
+		running = "no longer running"
+	}
+	modified := "no"
+	if info.modified {
+		modified = "YES"
+	}
+	lines := []string{
+		fmt.Sprintf("          owned by: %s   dated: %s", info.user, info.saved.Format(time.ANSIC)),
+		fmt.Sprintf("         file name: %s", info.file),
+		fmt.Sprintf("          modified: %s", modified),
+		fmt.Sprintf("         host name: %s", info.host),
+		fmt.Sprintf("        process ID: %d (%s)", info.pid, running),
+	}
+	msgs := make([]message, len(lines))
+	for i, l := range lines {
+		msgs[i] = message{text: l}
+	}
+	return msgs
+}
+
+// recoverSwapFile replaces the buffer with the changes saved in a swap file
+// and takes the swap file over. The recovery can be undone.
+func (f *SimpleFrame) recoverSwapFile(found *foundSwap, mtime time.Time) error {
+	if found.buffer != nil {
+		if found.info.fileFormat != "" {
+			f.fileFormat = found.info.fileFormat
+		}
+		f.replaceLines(0, f.buffer.len(), found.buffer)
+		f.undo.end()
+		f.moveCursorTo(bufferPos{})
+	}
+	if err := f.takeOverSwapFile(found, mtime); err != nil {
+		return err
+	}
+	if found.buffer == nil {
+		f.showMessage("The swap file holds no changes")
+		return nil
+	}
+	f.showMessage("Recovery completed. Check the changes, then write them with :w")
+	return nil
+}
+
+// ListSwapFiles writes a description of every swap file in dir to w.
+func ListSwapFiles(w io.Writer, dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, ".*.sw[a-p]"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Swap files found in %s:\n", dir)
+	if len(paths) == 0 {
+		fmt.Fprintln(w, "   -- none --")
+	}
+	for i, path := range paths {
+		fmt.Fprintf(w, "%d.    %s\n", i+1, filepath.Base(path))
+		info, _, err := readSwapFile(path)
+		if err != nil {
+			fmt.Fprintf(w, "          cannot be read: %v\n", err)
+			continue
+		}
+		for _, m := range describeSwapFile(info) {
+			fmt.Fprintln(w, m.text)
+		}
+	}
+	return nil
+}
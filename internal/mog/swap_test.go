@@ -0,0 +1,227 @@
+package mog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseSwapFile(t *testing.T) {
+	mtime := time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC)
+	info := swapInfo{pid: 42, host: "host", user: "user", file: "/a/b.txt", mtime: mtime, modified: true, saved: mtime}
+
+	got, buffer, err := parseSwapFile(marshalSwapFile(info, []string{"a", "", "b"}))
+	assert.Nil(t, err)
+	assert.True(t, got.mtime.Equal(mtime))
+	got.mtime, got.saved = mtime, mtime
+	assert.EqualValues(t, info, got)
+	assert.EqualValues(t, []string{"a", "", "b"}, buffer)
+
+	info.modified = false
+	_, buffer, err = parseSwapFile(marshalSwapFile(info, []string{"a"}))
+	assert.Nil(t, err)
+	assert.Nil(t, buffer)
+
+	_, _, err = parseSwapFile([]byte("something else\n"))
+	assert.Error(t, err)
+}
+
+func Test_swapInfo_stale(t *testing.T) {
+	cmd := exec.Command("go", "version")
+	assert.Nil(t, cmd.Run())
+	host, _ := os.Hostname()
+
+	assert.True(t, swapInfo{pid: cmd.Process.Pid, host: host}.stale())
+	assert.False(t, swapInfo{pid: cmd.Process.Pid, host: "elsewhere"}.stale(), "other hosts cannot be checked")
+	assert.False(t, swapInfo{pid: os.Getpid(), host: host}.stale())
+}
+
+// newSwapTestFrame loads a file with the given contents while its swap file
+// holds the changed buffer, written by a process that is gone if stale is
+// set.
+func newSwapTestFrame(t *testing.T, contents string, changed []string, stale bool) (*SimpleFrame, string) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	assert.Nil(t, os.WriteFile(path, []byte(contents), 0o644))
+	stat, err := os.Stat(path)
+	assert.Nil(t, err)
+	info := newSwapInfo(path, stat.ModTime())
+	info.modified = true
+	info.saved = time.Now()
+	if stale {
+		cmd := exec.Command("go", "version")
+		assert.Nil(t, cmd.Run())
+		info.pid = cmd.Process.Pid
+	} else {
+		info.host = "elsewhere"
+	}
+	assert.Nil(t, os.WriteFile(swapFilePathOf(path), marshalSwapFile(info, changed), 0o600))
+
+	f, _ := newTestFrame(t, numberedLines(1), 80, 20)
+	assert.Nil(t, f.loadFile(path))
+	assert.NotNil(t, f.prompt)
+	return f, path
+}
+
+func answer(f *SimpleFrame, r rune) bool {
+	return f.handleEventKey(*NewEventKey(KeyRune, r, ModNone))
+}
+
+func TestSimpleFrame_swapFilePrompt_Recover(t *testing.T) {
+	f, path := newSwapTestFrame(t, "old", []string{"new", "lines"}, false)
+	assert.Contains(t, f.bottomLine(), "E325: ATTENTION")
+	assert.Contains(t, f.bottomLine(), "[R]ecover, [O]pen read-only, [E]dit anyway, [Q]uit:")
+	assert.NotContains(t, f.bottomLine(), "The file has been changed")
+
+	answer(f, 'j')
+	assert.NotNil(t, f.prompt, "other keys are ignored")
+	answer(f, 'R')
+
+	assert.Nil(t, f.prompt)
+	assert.EqualValues(t, []string{"new", "lines"}, f.buffer.lines())
+	assert.True(t, f.modified)
+	info, _, err := readSwapFile(swapFilePathOf(path))
+	assert.Nil(t, err)
+	assert.EqualValues(t, os.Getpid(), info.pid, "the swap file is taken over")
+
+	// Recovering can be undone
+	answer(f, 'u')
+	assert.EqualValues(t, []string{"old"}, f.buffer.lines())
+}
+
+func TestSimpleFrame_swapFilePrompt_OpenReadOnly(t *testing.T) {
+	f, path := newSwapTestFrame(t, "old", []string{"new"}, false)
+	answer(f, 'o')
+
+	assert.True(t, f.readonly)
+	assert.EqualValues(t, filepath.Join(filepath.Dir(path), ".file.txt.swo"), f.swap.path)
+	assert.EqualError(t, f.Execute("w"), "E45: 'readonly' option is set (add ! to override)")
+	assert.Nil(t, f.Execute("w!"))
+}
+
+func TestSimpleFrame_swapFilePrompt_EditAnyway(t *testing.T) {
+	f, path := newSwapTestFrame(t, "old", []string{"new"}, false)
+	swo := filepath.Join(filepath.Dir(path), ".file.txt.swo")
+	assert.Nil(t, os.WriteFile(swo, nil, 0o600))
+	answer(f, 'e')
+
+	assert.Nil(t, f.prompt)
+	assert.False(t, f.readonly)
+	assert.EqualValues(t, filepath.Join(filepath.Dir(path), ".file.txt.swn"), f.swap.path)
+	info, buffer, err := readSwapFile(swapFilePathOf(path))
+	assert.Nil(t, err)
+	assert.EqualValues(t, "elsewhere", info.host, "the swap file found is left alone")
+	assert.EqualValues(t, []string{"new"}, buffer)
+
+	assert.Nil(t, f.Close())
+	_, err = os.Stat(filepath.Join(filepath.Dir(path), ".file.txt.swn"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(swo)
+	assert.Nil(t, err)
+}
+
+func TestSimpleFrame_createSwapFile_KeepsExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".file.txt.swp")
+	assert.Nil(t, os.WriteFile(path, []byte("theirs"), 0o600))
+	f, _ := newTestFrame(t, []string{"a"}, 80, 20)
+
+	err := f.createSwapFile(path, time.Now())
+	assert.True(t, os.IsExist(err))
+	assert.Nil(t, f.swap)
+	bs, _ := os.ReadFile(path)
+	assert.EqualValues(t, "theirs", string(bs))
+}
+
+func TestSimpleFrame_loadFile_WithoutSwapFile(t *testing.T) {
+	readOnly := filepath.Join(t.TempDir(), "ro")
+	assert.Nil(t, os.Mkdir(readOnly, 0o700))
+	paths := []string{
+		filepath.Join(readOnly, "file.txt"),
+		// The name of the swap file is longer than file names may be.
+		filepath.Join(t.TempDir(), strings.Repeat("x", 252)),
+	}
+	for _, path := range paths {
+		assert.Nil(t, os.WriteFile(path, []byte("a\n"), 0o644))
+	}
+	assert.Nil(t, os.Chmod(readOnly, 0o500))
+	t.Cleanup(func() { _ = os.Chmod(readOnly, 0o700) })
+	if os.Geteuid() == 0 {
+		// Root may write to the read-only directory.
+		paths = paths[1:]
+	}
+
+	for _, path := range paths {
+		f, _ := newTestFrame(t, []string{""}, 80, 20)
+		assert.Nil(t, f.loadFile(path))
+		assert.EqualValues(t, []string{"a", ""}, f.buffer.lines())
+		assert.Nil(t, f.swap)
+		assert.Nil(t, f.prompt)
+		assert.EqualValues(t, fmt.Sprintf("E303: Unable to open swap file for %q, recovery impossible", path), f.message.text)
+	}
+}
+
+func TestSimpleFrame_swapFilePrompt_DeleteStale(t *testing.T) {
+	f, path := newSwapTestFrame(t, "old", []string{"new"}, false)
+	assert.NotContains(t, f.bottomLine(), "[D]elete it")
+	answer(f, 'd')
+	assert.NotNil(t, f.prompt, "only swap files of processes that are gone can be deleted")
+
+	f, path = newSwapTestFrame(t, "old", []string{"new"}, true)
+	assert.Contains(t, f.bottomLine(), "(no longer running)")
+	answer(f, 'd')
+	assert.Nil(t, f.prompt)
+	assert.EqualValues(t, []string{"old"}, f.buffer.lines())
+	info, buffer, err := readSwapFile(swapFilePathOf(path))
+	assert.Nil(t, err)
+	assert.EqualValues(t, os.Getpid(), info.pid)
+	assert.Nil(t, buffer)
+}
+
+func TestSimpleFrame_swapFilePrompt_Quit(t *testing.T) {
+	f, path := newSwapTestFrame(t, "old", []string{"new"}, false)
+	assert.True(t, answer(f, 'q'))
+	assert.Nil(t, f.Close())
+	_, err := os.Stat(swapFilePathOf(path))
+	assert.Nil(t, err, "the swap file is kept")
+}
+
+func TestSimpleFrame_writeSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("a"), 0o644))
+	f, _ := newTestFrame(t, numberedLines(1), 80, 20)
+	assert.Nil(t, f.loadFile(path))
+
+	f.InsertText("b")
+	assert.True(t, f.swap.scheduled)
+	f.HandleEvent(&eventSnapshot{})
+	assert.False(t, f.swap.scheduled)
+	info, buffer, err := readSwapFile(f.swap.path)
+	assert.Nil(t, err)
+	assert.True(t, info.modified)
+	assert.EqualValues(t, []string{"ba"}, buffer)
+
+	assert.Nil(t, f.Execute("w"))
+	info, buffer, err = readSwapFile(f.swap.path)
+	assert.Nil(t, err)
+	assert.False(t, info.modified)
+	assert.Nil(t, buffer)
+}
+
+func TestListSwapFiles(t *testing.T) {
+	dir := t.TempDir()
+	info := swapInfo{pid: 42, host: "elsewhere", user: "someone", file: "/a/b.txt", modified: true}
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, ".b.txt.swp"), marshalSwapFile(info, []string{"x"}), 0o600))
+
+	var out bytes.Buffer
+	assert.Nil(t, ListSwapFiles(&out, dir))
+	assert.Contains(t, out.String(), "1.    .b.txt.swp\n")
+	assert.Contains(t, out.String(), "          owned by: someone")
+	assert.Contains(t, out.String(), "         file name: /a/b.txt\n")
+	assert.Contains(t, out.String(), "          modified: YES\n")
+}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package mog
+
+import "os"
+
+// processAlive reports whether a process with the given id is running.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
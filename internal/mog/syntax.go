@@ -0,0 +1,284 @@
+package mog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// syntaxRule highlights text matching a pattern and optionally switches the
+// highlighter to another state.
+type syntaxRule struct {
+	pattern *regexp.Regexp
+	// resume is pattern preceded by any character, for finding matches
+	// after the start of a line with the character before them in view.
+	resume *regexp.Regexp
+	group  HighlightGroup
+	next   int
+}
+
+func newSyntaxRule(pattern string, group HighlightGroup, next int) (syntaxRule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return syntaxRule{}, err
+	}
+	resume, err := regexp.Compile(`(?s:.)(` + pattern + `)`)
+	if err != nil {
+		return syntaxRule{}, err
+	}
+	return syntaxRule{pattern: re, resume: resume, group: group, next: next}, nil
+}
+
+// find returns the start and end of the leftmost match of the rule in line
+// that starts at or after pos, or nil if there is none. The text before pos
+// is taken into account, so that ^ only matches at the start of the line
+// and \b sees the character before pos.
+func (r *syntaxRule) find(line string, pos int) []int {
+	if pos == 0 {
+		return r.pattern.FindStringIndex(line)
+	}
+	prev := prevCharStart(line, pos)
+	m := r.resume.FindStringSubmatchIndex(line[prev:])
+	if m == nil {
+		return nil
+	}
+	return []int{prev + m[2], prev + m[3]}
+}
+
+// regexHighlighter is a state machine highlighter defined in a syntax
+// definition file.
+//
+// A syntax definition file consists of directives, one per line:
+//
+//	name <name>
+//	extensions <.ext> ...
+//	state <state> <group>
+//	rule <state> <group> <pattern> [-> <state>]
+//
+// Empty lines and lines starting with '#' are ignored. The highlighter
+// starts every buffer in the state named main. At every position of a line
+// the rules of the current state are tried, and the rule matching closest
+// to the position, or the first one of those matching at the same position,
+// highlights its match and switches to its next state, if any. Text not
+// matched by any rule is highlighted as the group of the state, which is
+// Normal unless a state directive says otherwise.
+type regexHighlighter struct {
+	name       string
+	extensions []string
+	states     map[string]int
+	groups     []HighlightGroup
+	rules      [][]syntaxRule
+}
+
+var syntaxRuleRegexp = regexp.MustCompile(`^rule\s+(\S+)\s+(\S+)\s+(.+?)(?:\s+->\s+(\S+))?$`)
+
+// parseSyntax reads a syntax definition file from r.
+func parseSyntax(r io.Reader) (*regexHighlighter, error) {
+	h := &regexHighlighter{states: make(map[string]int)}
+	h.state("main")
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := h.parseDirective(line); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	return h, scanner.Err()
+}
+
+func (h *regexHighlighter) parseDirective(line string) error {
+	fields := strings.Fields(line)
+	switch fields[0] {
+	case "name":
+		if len(fields) != 2 {
+			return fmt.Errorf("usage: name <name>")
+		}
+		h.name = fields[1]
+	case "extensions":
+		h.extensions = append(h.extensions, fields[1:]...)
+	case "state":
+		if len(fields) != 3 {
+			return fmt.Errorf("usage: state <state> <group>")
+		}
+		state := h.state(fields[1])
+		h.groups[state] = HighlightGroup(fields[2])
+	case "rule":
+		m := syntaxRuleRegexp.FindStringSubmatch(line)
+		if m == nil {
+			return fmt.Errorf("usage: rule <state> <group> <pattern> [-> <state>]")
+		}
+		state := h.state(m[1])
+		next := state
+		if m[4] != "" {
+			next = h.state(m[4])
+		}
+		rule, err := newSyntaxRule(m[3], HighlightGroup(m[2]), next)
+		if err != nil {
+			return err
+		}
+		h.rules[state] = append(h.rules[state], rule)
+	default:
+		return fmt.Errorf("unknown directive %q", fields[0])
+	}
+	return nil
+}
+
+// state returns the number of the named state, adding it if it is new.
+func (h *regexHighlighter) state(name string) int {
+	if s, ok := h.states[name]; ok {
+		return s
+	}
+	s := len(h.groups)
+	h.states[name] = s
+	h.groups = append(h.groups, GroupNormal)
+	h.rules = append(h.rules, nil)
+	return s
+}
+
+func (h *regexHighlighter) HighlightLine(line string, state int) ([]Span, int) {
+	var spans []Span
+	pos := 0
+	for pos < len(line) {
+		rule, start, end := h.match(line, pos, state)
+		if rule == nil {
+			spans = appendSpan(spans, Span{pos, len(line), h.groups[state]})
+			break
+		}
+		spans = appendSpan(spans, Span{pos, start, h.groups[state]})
+		spans = appendSpan(spans, Span{start, end, rule.group})
+		pos = end
+		state = rule.next
+	}
+	return spans, state
+}
+
+// match finds the rule of the given state that matches closest to pos in
+// line. Empty matches are ignored, as they would not make any progress.
+func (h *regexHighlighter) match(line string, pos, state int) (*syntaxRule, int, int) {
+	var best *syntaxRule
+	bestStart, bestEnd := 0, 0
+	for i := range h.rules[state] {
+		rule := &h.rules[state][i]
+		loc := rule.find(line, pos)
+		if loc == nil || loc[0] == loc[1] {
+			continue
+		}
+		if best == nil || loc[0] < bestStart {
+			best, bestStart, bestEnd = rule, loc[0], loc[1]
+		}
+	}
+	return best, bestStart, bestEnd
+}
+
+// appendSpan appends s to spans, skipping empty spans and spans highlighted
+// as Normal.
+func appendSpan(spans []Span, s Span) []Span {
+	if s.Start == s.End || s.Group == GroupNormal {
+		return spans
+	}
+	return append(spans, s)
+}
+
+// configDir returns the directory mog reads its configuration from.
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mog"), nil
+}
+
+// loadSyntaxDefinitions reads every *.syntax file in the syntax directory
+// of the configuration directory. Files that cannot be read are skipped, and
+// the first error encountered is returned along with the other definitions.
+func loadSyntaxDefinitions() ([]*regexHighlighter, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "syntax", "*.syntax"))
+	if err != nil {
+		return nil, err
+	}
+	var defs []*regexHighlighter
+	var firstErr error
+	for _, path := range paths {
+		h, err := loadSyntaxFile(path)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		defs = append(defs, h)
+	}
+	return defs, firstErr
+}
+
+func loadSyntaxFile(path string) (*regexHighlighter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	h, err := parseSyntax(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return h, nil
+}
+
+// highlighterFor returns the highlighter for the file at filePath, based on
+// its extension, or nil if there is none.
+func highlighterFor(filePath string) (Highlighter, error) {
+	ext := filepath.Ext(filePath)
+	if ext == ".go" {
+		return goHighlighter{}, nil
+	}
+	defs, err := loadSyntaxDefinitions()
+	for _, def := range defs {
+		for _, e := range def.extensions {
+			if e == ext {
+				return def, err
+			}
+		}
+	}
+	return nil, err
+}
+
+// highlighterNamed returns the highlighter of the given name, or nil if
+// there is none. The Go highlighter is called go, others have the name
+// given in their syntax definition file.
+func highlighterNamed(name string) (Highlighter, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if name == "go" {
+		return goHighlighter{}, nil
+	}
+	defs, err := loadSyntaxDefinitions()
+	for _, def := range defs {
+		if def.name == name {
+			return def, err
+		}
+	}
+	return nil, err
+}
+
+// highlighterName returns the name highlighterNamed knows h by.
+func highlighterName(h Highlighter) string {
+	switch h := h.(type) {
+	case goHighlighter:
+		return "go"
+	case *regexHighlighter:
+		return h.name
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package mog
+
+// change records that the lines old starting at line y were replaced by the
+// lines new.
+type change struct {
+	y        int
+	old, new []string
+}
+
+// undoStep is a group of changes that is undone as a whole, e.g. everything
+// typed in one go in insert mode. cursor is where the cursor was before the
+// first change.
+type undoStep struct {
+	changes []change
+	cursor  bufferPos
+}
+
+// undoHistory holds the changes that can be undone and redone.
+type undoHistory struct {
+	undo []undoStep
+	redo []undoStep
+	// open is set while changes are added to the last step.
+	open bool
+}
+
+// begin starts a new step that every change is added to until end is called.
+func (h *undoHistory) begin(cursor bufferPos) {
+	h.end()
+	h.undo = append(h.undo, undoStep{cursor: cursor})
+	h.open = true
+}
+
+// end closes the current step. Steps without changes are dropped.
+func (h *undoHistory) end() {
+	if h.open && len(h.undo[len(h.undo)-1].changes) == 0 {
+		h.undo = h.undo[:len(h.undo)-1]
+	}
+	h.open = false
+}
+
+// record adds a change to the current step, or to a step of its own if
+// there is none.
+func (h *undoHistory) record(c change, cursor bufferPos) {
+	if !h.open {
+		h.undo = append(h.undo, undoStep{cursor: cursor})
+	}
+	step := &h.undo[len(h.undo)-1]
+	step.changes = append(step.changes, c)
+	h.redo = nil
+}
+
+// replaceLines replaces the n lines starting at line y with lines and keeps
+// everything derived from the buffer up to date. It is the only way the
+// lines of the buffer are changed, so that every change can be undone.
+func (f *SimpleFrame) replaceLines(y, n int, lines []string) {
+	f.ensureLoaded(y, y+n)
+	old := f.buffer.slice(y, y+n)
+	f.undo.record(change{y: y, old: old, new: append([]string(nil), lines...)}, f.cursorPos())
+	f.applyChange(y, n, lines)
+}
+
+// applyChange replaces the n lines starting at line y with lines without
+// recording the change.
+func (f *SimpleFrame) applyChange(y, n int, lines []string) {
+	f.modified = true
+	f.changedTick++
+	f.scheduleSnapshot()
+	f.buffer.replace(y, n, lines)
+	common := minInt(n, len(lines))
+	if len(lines) > n {
+		f.linesInserted(y+n, len(lines)-n)
+	} else if len(lines) < n {
+		f.linesDeleted(y+common, n-len(lines))
+	}
+	for i := 0; i < common; i++ {
+		f.lineChanged(y + i)
+	}
+}
+
+// Undo reverts the last step of changes.
+func (f *SimpleFrame) Undo() bool {
+	f.undo.end()
+	if len(f.undo.undo) == 0 {
+		return false
+	}
+	step := f.undo.undo[len(f.undo.undo)-1]
+	f.undo.undo = f.undo.undo[:len(f.undo.undo)-1]
+	f.undo.redo = append(f.undo.redo, undoStep{changes: step.changes, cursor: f.cursorPos()})
+	for i := len(step.changes) - 1; i >= 0; i-- {
+		c := step.changes[i]
+		f.applyChange(c.y, len(c.new), c.old)
+	}
+	f.moveCursorTo(step.cursor)
+	return true
+}
+
+// Redo applies the last step of changes that was undone again.
+func (f *SimpleFrame) Redo() bool {
+	f.undo.end()
+	if len(f.undo.redo) == 0 {
+		return false
+	}
+	step := f.undo.redo[len(f.undo.redo)-1]
+	f.undo.redo = f.undo.redo[:len(f.undo.redo)-1]
+	f.undo.undo = append(f.undo.undo, undoStep{changes: step.changes, cursor: f.cursorPos()})
+	for _, c := range step.changes {
+		f.applyChange(c.y, len(c.old), c.new)
+	}
+	f.moveCursorTo(step.cursor)
+	return true
+}
+
+// moveCursorTo moves the cursor to pos, limited to the buffer.
+func (f *SimpleFrame) moveCursorTo(pos bufferPos) {
+	y := clampInt(pos.y, 0, f.buffer.len()-1)
+	f.cursor.MoveTo(maxInt(pos.x, 0), y)
+	f.scrollToCursor()
+}
@@ -0,0 +1,121 @@
+package mog
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// bufferPos is a position in the buffer, x being a byte offset into line y.
+type bufferPos struct {
+	x, y int
+}
+
+func (p bufferPos) before(o bufferPos) bool {
+	return p.y < o.y || p.y == o.y && p.x < o.x
+}
+
+// StartVisual starts selecting text from the cursor position.
+func (f *SimpleFrame) StartVisual() {
+	f.mode = ModeVisual
+	f.visualStart = f.cursorPos()
+}
+
+// StopVisual stops selecting text.
+func (f *SimpleFrame) StopVisual() {
+	f.mode = ModeNormal
+}
+
+// cursorPos returns the position of the cursor in the buffer, limited to the
+// length of the line it is on.
+func (f *SimpleFrame) cursorPos() bufferPos {
+	x := minInt(f.cursor.XPos(), maxInt(lastCharStart(f.currentLine()), 0))
+	return bufferPos{x, f.cursor.YPos()}
+}
+
+// selection returns the first and last position of the selected text, both
+// inclusive. ok is false when no text is selected.
+func (f *SimpleFrame) selection() (start, end bufferPos, ok bool) {
+	if f.mode != ModeVisual {
+		return bufferPos{}, bufferPos{}, false
+	}
+	start, end = f.visualStart, f.cursorPos()
+	if end.before(start) {
+		start, end = end, start
+	}
+	return start, end, true
+}
+
+// selectionLines returns the first and last line containing selected text,
+// or -1, -1 when nothing is selected.
+func (f *SimpleFrame) selectionLines() (int, int) {
+	start, end, ok := f.selection()
+	if !ok {
+		return -1, -1
+	}
+	return start.y, end.y
+}
+
+// inSelection reports whether the byte at offset x of line y is selected.
+func (f *SimpleFrame) inSelection(x, y int) bool {
+	start, end, ok := f.selection()
+	if !ok {
+		return false
+	}
+	p := bufferPos{x, y}
+	return !p.before(start) && !end.before(p)
+}
+
+// SelectWord selects the word under the cursor. Characters that are not part
+// of a word are selected together with the surrounding characters of the same
+// kind, e.g. a run of white space.
+func (f *SimpleFrame) SelectWord() {
+	line := f.currentLine()
+	pos := f.cursorPos()
+	if line == "" {
+		f.StartVisual()
+		return
+	}
+	r, _ := utf8.DecodeRuneInString(line[pos.x:])
+	class := charClass(r)
+	start, end := pos.x, pos.x
+	for start > 0 {
+		prev := prevCharStart(line, start)
+		if r, _ := utf8.DecodeRuneInString(line[prev:]); charClass(r) != class {
+			break
+		}
+		start = prev
+	}
+	for next := nextCharStart(line, end); next < len(line); next = nextCharStart(line, end) {
+		if r, _ := utf8.DecodeRuneInString(line[next:]); charClass(r) != class {
+			break
+		}
+		end = next
+	}
+	f.cursor.MoveTo(start, pos.y)
+	f.StartVisual()
+	f.cursor.MoveTo(end, pos.y)
+}
+
+// charClass divides characters into white space (0), punctuation (1) and
+// word characters (2).
+func charClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || r >= 0x80:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func (f *SimpleFrame) handleVisualKey(ev EventKey) {
+	if ev.Key() == KeyEscape || ev.Key() == KeyRune && ev.Rune() == 'v' {
+		f.StopVisual()
+		return
+	}
+	if ev.Key() == KeyRune && (ev.Rune() == 'i' || ev.Rune() == ':') {
+		return
+	}
+	f.handleNormalKey(ev)
+}
@@ -0,0 +1,299 @@
+package mog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// pollInterval is how often the file being edited is checked for changes
+// made by other programs.
+const pollInterval = time.Second
+
+// eventFileChanged is posted when the file being edited changed on disk.
+type eventFileChanged struct {
+	EventTime
+}
+
+// fileWatcher polls a file in the background and posts an eventFileChanged
+// to the screen whenever its modification time, size or identity changes.
+type fileWatcher struct {
+	stop chan struct{}
+}
+
+func watchFile(screen Screen, path string, interval time.Duration) *fileWatcher {
+	w := &fileWatcher{stop: make(chan struct{})}
+	last, _ := os.Stat(path)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+			}
+			info, _ := os.Stat(path)
+			if !sameFileState(last, info) {
+				ev := &eventFileChanged{}
+				ev.SetEventNow()
+				_ = screen.PostEvent(ev)
+			}
+			last = info
+		}
+	}()
+	return w
+}
+
+func (w *fileWatcher) close() {
+	close(w.stop)
+}
+
+// sameFileState reports whether a and b describe the same file with the
+// same contents, as far as can be told without reading it. Either is nil
+// if the file did not exist.
+func sameFileState(a, b os.FileInfo) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+// startWatching starts checking the file being edited for changes.
+func (f *SimpleFrame) startWatching() {
+	f.stopWatching()
+	if f.filePath != "" && !f.headless {
+		f.watcher = watchFile(f.screen, f.filePath, pollInterval)
+	}
+}
+
+func (f *SimpleFrame) stopWatching() {
+	if f.watcher != nil {
+		f.watcher.close()
+		f.watcher = nil
+	}
+}
+
+// checkFile compares the file being edited with what it was when it was
+// loaded or last written. A file that changed is reloaded if the buffer has
+// no changes and autoread is set, otherwise the user is asked what to do.
+func (f *SimpleFrame) checkFile() {
+	if f.filePath == "" || f.prompt != nil {
+		return
+	}
+	info, err := os.Stat(f.filePath)
+	if err != nil {
+		info = nil
+	}
+	if sameFileState(f.fileInfo, info) {
+		return
+	}
+	if f.following && info != nil && f.appendGrowth(info) {
+		return
+	}
+	if info == nil {
+		f.fileInfo = nil
+		f.showError(editorErrorf(211, "File %q no longer available", f.filePath))
+		return
+	}
+	if !f.modified && f.autoread {
+		if err := f.reloadFile(""); err != nil {
+			f.showError(err)
+		}
+		return
+	}
+
+	p := &prompt{answers: map[rune]func(*SimpleFrame){}}
+	if f.modified {
+		p.addLine(true, "W12: Warning: File %q has changed and the buffer was changed in mog as well", f.filePath)
+		p.addLine(false, "[L]oad file, [K]eep buffer:")
+		p.answers['k'] = func(f *SimpleFrame) { f.fileInfo = info }
+	} else {
+		p.addLine(true, "W11: Warning: File %q has changed since editing started", f.filePath)
+		p.addLine(false, "[O]K, [L]oad file:")
+		p.answers['o'] = func(f *SimpleFrame) { f.fileInfo = info }
+	}
+	p.answers['l'] = func(f *SimpleFrame) {
+		if err := f.reloadFile(""); err != nil {
+			f.showError(err)
+		}
+	}
+	f.prompt = p
+}
+
+// reloadFile replaces the buffer with the contents of the file being
+// edited, read in the given encoding or the one it appears to be in if that
+// is empty. Reloading can be undone like any other change.
+func (f *SimpleFrame) reloadFile(encoding string) error {
+	if f.lazy != nil {
+		if err := f.reloadLargeFile(encoding); err != nil {
+			return err
+		}
+		f.fireBufferAutocmds(BufReadPost)
+		return nil
+	}
+	info, err := os.Stat(f.filePath)
+	if err != nil {
+		return err
+	}
+	if f.hex != nil {
+		if err := f.reloadHexView(info); err != nil {
+			return err
+		}
+		f.fireBufferAutocmds(BufReadPost)
+		return nil
+	}
+	bs, err := os.ReadFile(f.filePath)
+	if err != nil {
+		return err
+	}
+	if f.mode == ModeVisual {
+		f.StopVisual()
+	}
+	lines := f.decodeFile(bs, encoding)
+	if !equalLines(lines, f.buffer.lines()) {
+		f.undo.begin(f.cursorPos())
+		f.replaceLines(0, f.buffer.len(), lines)
+		f.undo.end()
+	}
+	f.modified = false
+	f.fileInfo = info
+	f.updateSwapFile()
+	f.moveCursorTo(bufferPos{f.cursor.XPos(), f.cursor.YPos()})
+	f.fireBufferAutocmds(BufReadPost)
+	return nil
+}
+
+// reloadHexView shows the bytes of the file being edited again, which was
+// last modified as info tells, keeping the cursor where it was. It cannot be
+// undone.
+func (f *SimpleFrame) reloadHexView(info os.FileInfo) error {
+	v, err := openLargeBinaryFile(f.filePath, info)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		bs, err := os.ReadFile(f.filePath)
+		if err != nil {
+			return err
+		}
+		v = newHexView(bs)
+	}
+	v.ascii = f.hex.ascii
+	v.moveTo(f.hex.cursor)
+	f.closeHex()
+	f.hex = v
+	f.modified = false
+	f.fileInfo = info
+	f.updateSwapFile()
+	return nil
+}
+
+func equalLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// exEdit implements :edit, which reloads the file being edited or, given a
+// file name, edits that file instead. Both refuse to throw away changes
+// unless the command is followed by a '!'.
+func (f *SimpleFrame) exEdit(args string) error {
+	force := len(args) > 0 && args[0] == '!'
+	if force {
+		args = args[1:]
+	}
+	encoding, path, err := parseEditArgs(args)
+	if err != nil {
+		return err
+	}
+	if f.modified && !force {
+		return errNoWriteSinceLastChange
+	}
+	if path == "" || path == f.filePath {
+		if f.filePath == "" {
+			return errNoFileName
+		}
+		return f.reloadFile(encoding)
+	}
+	return f.editFile(path, encoding)
+}
+
+// editFile edits the file at path instead of the current one, decoding it
+// from encoding unless that is empty.
+func (f *SimpleFrame) editFile(path, encoding string) error {
+	c, err := f.readFile(path, encoding)
+	if err != nil {
+		return editorErrorf(484, "Can't open file %s", path)
+	}
+	if err := f.closeFile(); err != nil {
+		c.close()
+		return err
+	}
+	if err := f.loadContents(path, c); err != nil {
+		return err
+	}
+	f.startWatching()
+	f.showMessage(fmt.Sprintf("%q %dL", path, f.buffer.len()))
+	f.fireBufferAutocmds(BufEnter)
+	return nil
+}
+
+// parseEditArgs splits the arguments of :edit into the encoding given with
+// ++enc={encoding}, if any, and the file name.
+func parseEditArgs(args string) (encoding, path string, err error) {
+	args = strings.TrimSpace(args)
+	if !strings.HasPrefix(args, "++") {
+		return "", args, nil
+	}
+	opt := args
+	if i := strings.IndexAny(args, " \t"); i >= 0 {
+		opt, args = args[:i], strings.TrimSpace(args[i:])
+	} else {
+		args = ""
+	}
+	name := opt
+	for _, prefix := range []string{"++encoding=", "++enc="} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	if name == opt || normalizeEncoding(name) == "" {
+		return "", "", editorErrorf(474, "Invalid argument: %s", opt)
+	}
+	return normalizeEncoding(name), args, nil
+}
+
+// closeFile forgets the file being edited, so that another one can be
+// loaded.
+func (f *SimpleFrame) closeFile() error {
+	if f.swap != nil {
+		if err := os.Remove(f.swap.path); err != nil {
+			return err
+		}
+		f.swap = nil
+	}
+	f.stopWatching()
+	f.closeLargeFile()
+	f.closeHex()
+	if f.mode == ModeVisual {
+		f.StopVisual()
+	}
+	f.filePath = ""
+	f.fileInfo = nil
+	f.foundSwap = nil
+	f.resetBufferOptions()
+	f.clearBufferMappings()
+	f.modified = false
+	f.undo = undoHistory{}
+	f.layout = layout{}
+	f.offset = 0
+	f.cursor.MoveTo(0, 0)
+	f.damage.markAll()
+	return nil
+}
@@ -0,0 +1,142 @@
+package mog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newWatchTestFrame returns a frame editing a file with the given contents.
+func newWatchTestFrame(t *testing.T, contents string) (*SimpleFrame, string) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	assert.Nil(t, os.WriteFile(path, []byte(contents), 0o644))
+	f, _ := newTestFrame(t, numberedLines(1), 80, 10)
+	f.autoread = true
+	assert.Nil(t, f.loadFile(path))
+	return f, path
+}
+
+func TestWatchFile_PostsEventWhenFileChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("a"), 0o644))
+	ss := NewSimulationScreen()
+	assert.Nil(t, ss.Init())
+	defer ss.Fini()
+
+	w := watchFile(ss, path, 10*time.Millisecond)
+	defer w.close()
+	assert.Nil(t, os.WriteFile(path, []byte("changed"), 0o644))
+
+	events := make(chan Event, 1)
+	go func() { events <- ss.PollEvent() }()
+	select {
+	case ev := <-events:
+		assert.IsType(t, &eventFileChanged{}, ev)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event was posted")
+	}
+}
+
+func TestSimpleFrame_checkFile_AutoreadReloadsUnmodifiedBuffer(t *testing.T) {
+	f, path := newWatchTestFrame(t, "a\nb")
+	f.cursor.MoveTo(0, 1)
+	assert.Nil(t, os.WriteFile(path, []byte("new"), 0o644))
+
+	f.HandleEvent(&eventFileChanged{})
+
+	assert.Nil(t, f.prompt)
+	assert.EqualValues(t, []string{"new"}, f.buffer.lines())
+	assert.False(t, f.modified)
+	assert.EqualValues(t, 0, f.cursor.YPos())
+
+	// Nothing happens for a file that has not changed since
+	f.HandleEvent(&eventFileChanged{})
+	assert.Nil(t, f.prompt)
+}
+
+func TestSimpleFrame_checkFile_AsksWhenBufferWasChanged(t *testing.T) {
+	f, path := newWatchTestFrame(t, "a")
+	f.InsertText("x")
+	assert.Nil(t, os.WriteFile(path, []byte("new"), 0o644))
+
+	f.HandleEvent(&eventFileChanged{})
+	assert.Contains(t, f.bottomLine(), "W12: Warning: File \""+path+"\" has changed and the buffer was changed in mog as well")
+	answer(f, 'k')
+	assert.EqualValues(t, []string{"xa"}, f.buffer.lines())
+	f.HandleEvent(&eventFileChanged{})
+	assert.Nil(t, f.prompt, "the user is asked only once")
+
+	assert.Nil(t, os.WriteFile(path, []byte("newer"), 0o644))
+	f.HandleEvent(&eventFileChanged{})
+	answer(f, 'l')
+	assert.EqualValues(t, []string{"newer"}, f.buffer.lines())
+	assert.False(t, f.modified)
+
+	// Reloading can be undone
+	answer(f, 'u')
+	assert.EqualValues(t, []string{"xa"}, f.buffer.lines())
+}
+
+func TestSimpleFrame_checkFile_WithoutAutoread(t *testing.T) {
+	f, path := newWatchTestFrame(t, "a")
+	assert.Nil(t, f.Execute("set noautoread"))
+	assert.Nil(t, os.WriteFile(path, []byte("new"), 0o644))
+
+	f.HandleEvent(&eventFileChanged{})
+	assert.Contains(t, f.bottomLine(), "W11: Warning: File \""+path+"\" has changed since editing started")
+	answer(f, 'o')
+	assert.EqualValues(t, []string{"a"}, f.buffer.lines())
+}
+
+func TestSimpleFrame_checkFile_DeletedFile(t *testing.T) {
+	f, path := newWatchTestFrame(t, "a")
+	assert.Nil(t, os.Remove(path))
+
+	f.HandleEvent(&eventFileChanged{})
+	assert.EqualValues(t, "E211: File \""+path+"\" no longer available", f.bottomLine())
+	assert.EqualValues(t, []string{"a"}, f.buffer.lines())
+}
+
+func TestSimpleFrame_checkFile_IgnoresOwnWrites(t *testing.T) {
+	f, _ := newWatchTestFrame(t, "a")
+	f.InsertText("x")
+	assert.Nil(t, f.Execute("w"))
+
+	f.HandleEvent(&eventFileChanged{})
+	assert.Nil(t, f.prompt)
+}
+
+func TestSimpleFrame_exEdit(t *testing.T) {
+	f, path := newWatchTestFrame(t, "a")
+	f.InsertText("x")
+
+	assert.EqualError(t, f.Execute("e"), "E37: No write since last change (add ! to override)")
+	assert.Nil(t, f.Execute("e!"))
+	assert.EqualValues(t, []string{"a"}, f.buffer.lines())
+	assert.False(t, f.modified)
+
+	other := filepath.Join(filepath.Dir(path), "other.txt")
+	assert.Nil(t, os.WriteFile(other, []byte("b\nc"), 0o644))
+	assert.EqualError(t, f.Execute("e nosuchfile"), "E484: Can't open file nosuchfile")
+	dir := filepath.Dir(path)
+	assert.EqualError(t, f.Execute("e "+dir), "E484: Can't open file "+dir)
+	assert.EqualValues(t, []string{"a"}, f.buffer.lines(), "a file that cannot be read leaves the buffer alone")
+	assert.EqualValues(t, path, f.filePath)
+	assert.NotNil(t, f.swap)
+	assert.Nil(t, f.Execute("e ++enc=latin1 "+other))
+	assert.EqualValues(t, encodingLatin1, f.fileEncoding)
+	assert.False(t, f.Undo(), "decoding the other file cannot be undone")
+	assert.Nil(t, f.Execute("e "+other))
+	assert.EqualValues(t, []string{"b", "c"}, f.buffer.lines())
+	assert.EqualValues(t, other, f.filePath)
+	assert.False(t, f.Undo(), "the history of the other file is gone")
+
+	_, err := os.Stat(swapFilePathOf(path))
+	assert.True(t, os.IsNotExist(err), "the swap file of the first file is removed")
+	_, err = os.Stat(swapFilePathOf(other))
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+}
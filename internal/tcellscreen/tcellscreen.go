@@ -0,0 +1,226 @@
+// Package tcellscreen shows the editor on a terminal with tcell.
+package tcellscreen
+
+import (
+	"github.com/gdamore/tcell/v2"
+
+	"elyria.io/mog/internal/mog"
+)
+
+// screen is a mog.Screen drawn with a tcell.Screen.
+type screen struct {
+	s tcell.Screen
+}
+
+// New returns a screen for the terminal mog is run in.
+func New() (mog.Screen, error) {
+	s, err := tcell.NewScreen()
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(s), nil
+}
+
+// Wrap returns a screen drawn with s.
+func Wrap(s tcell.Screen) mog.Screen {
+	return &screen{s: s}
+}
+
+// eventPosted carries an event posted by the editor through the event
+// queue of tcell.
+type eventPosted struct {
+	tcell.EventTime
+	ev mog.Event
+}
+
+func (s *screen) Init() error {
+	return s.s.Init()
+}
+
+func (s *screen) Fini() {
+	s.s.Fini()
+}
+
+func (s *screen) Size() (w, h int) {
+	return s.s.Size()
+}
+
+func (s *screen) SetContent(x, y int, mainc rune, combc []rune, style mog.Style) {
+	s.s.SetContent(x, y, mainc, combc, toStyle(style))
+}
+
+func (s *screen) SetStyle(style mog.Style) {
+	s.s.SetStyle(toStyle(style))
+}
+
+func (s *screen) Clear() {
+	s.s.Clear()
+}
+
+func (s *screen) ShowCursor(x, y int) {
+	s.s.ShowCursor(x, y)
+}
+
+func (s *screen) Show() {
+	s.s.Show()
+}
+
+func (s *screen) Sync() {
+	s.s.Sync()
+}
+
+func (s *screen) Colors() int {
+	return s.s.Colors()
+}
+
+func (s *screen) EnableMouse() {
+	s.s.EnableMouse(tcell.MouseButtonEvents | tcell.MouseDragEvents)
+}
+
+func (s *screen) DisableMouse() {
+	s.s.DisableMouse()
+}
+
+func (s *screen) EnablePaste() {
+	s.s.EnablePaste()
+}
+
+// PollEvent returns the next event the editor handles, skipping those of
+// tcell that it has no use for.
+func (s *screen) PollEvent() mog.Event {
+	for {
+		ev := s.s.PollEvent()
+		if ev == nil {
+			return nil
+		}
+		if ev := fromEvent(ev); ev != nil {
+			return ev
+		}
+	}
+}
+
+func (s *screen) PostEvent(ev mog.Event) error {
+	p := &eventPosted{ev: ev}
+	p.SetEventNow()
+	if err := s.s.PostEvent(p); err != nil {
+		return mog.ErrEventQueueFull
+	}
+	return nil
+}
+
+// fromEvent returns the editor's event for ev, or nil if it has none.
+func fromEvent(ev tcell.Event) mog.Event {
+	switch ev := ev.(type) {
+	case *eventPosted:
+		return ev.ev
+	case *tcell.EventKey:
+		k, ok := fromKey(ev.Key())
+		if !ok {
+			return nil
+		}
+		return mog.NewEventKey(k, ev.Rune(), fromModMask(ev.Modifiers()))
+	case *tcell.EventMouse:
+		x, y := ev.Position()
+		return mog.NewEventMouse(x, y, fromButtonMask(ev.Buttons()), fromModMask(ev.Modifiers()))
+	case *tcell.EventPaste:
+		return mog.NewEventPaste(ev.Start())
+	case *tcell.EventResize:
+		w, h := ev.Size()
+		return mog.NewEventResize(w, h)
+	}
+	return nil
+}
+
+var keys = map[tcell.Key]mog.Key{
+	tcell.KeyRune:   mog.KeyRune,
+	tcell.KeyUp:     mog.KeyUp,
+	tcell.KeyDown:   mog.KeyDown,
+	tcell.KeyRight:  mog.KeyRight,
+	tcell.KeyLeft:   mog.KeyLeft,
+	tcell.KeyHome:   mog.KeyHome,
+	tcell.KeyEnd:    mog.KeyEnd,
+	tcell.KeyPgUp:   mog.KeyPgUp,
+	tcell.KeyPgDn:   mog.KeyPgDn,
+	tcell.KeyInsert: mog.KeyInsert,
+	tcell.KeyDelete: mog.KeyDelete,
+}
+
+// fromKey returns the editor's key for k. The control keys of both are the
+// ASCII control characters.
+func fromKey(k tcell.Key) (mog.Key, bool) {
+	if k <= tcell.KeyDEL {
+		return mog.Key(k), true
+	}
+	mk, ok := keys[k]
+	return mk, ok
+}
+
+func fromModMask(m tcell.ModMask) mog.ModMask {
+	var mm mog.ModMask
+	if m&tcell.ModShift != 0 {
+		mm |= mog.ModShift
+	}
+	if m&tcell.ModCtrl != 0 {
+		mm |= mog.ModCtrl
+	}
+	if m&tcell.ModAlt != 0 {
+		mm |= mog.ModAlt
+	}
+	return mm
+}
+
+var buttons = []struct {
+	from tcell.ButtonMask
+	to   mog.ButtonMask
+}{
+	{tcell.Button1, mog.Button1},
+	{tcell.Button2, mog.Button2},
+	{tcell.Button3, mog.Button3},
+	{tcell.WheelUp, mog.WheelUp},
+	{tcell.WheelDown, mog.WheelDown},
+}
+
+func fromButtonMask(b tcell.ButtonMask) mog.ButtonMask {
+	var mb mog.ButtonMask
+	for _, button := range buttons {
+		if b&button.from != 0 {
+			mb |= button.to
+		}
+	}
+	return mb
+}
+
+var attrs = []struct {
+	from mog.AttrMask
+	to   tcell.AttrMask
+}{
+	{mog.AttrBold, tcell.AttrBold},
+	{mog.AttrBlink, tcell.AttrBlink},
+	{mog.AttrReverse, tcell.AttrReverse},
+	{mog.AttrUnderline, tcell.AttrUnderline},
+	{mog.AttrDim, tcell.AttrDim},
+	{mog.AttrItalic, tcell.AttrItalic},
+	{mog.AttrStrikeThrough, tcell.AttrStrikeThrough},
+}
+
+// toStyle returns the tcell style for style.
+func toStyle(style mog.Style) tcell.Style {
+	fg, bg, a := style.Decompose()
+	var ta tcell.AttrMask
+	for _, attr := range attrs {
+		if a&attr.from != 0 {
+			ta |= attr.to
+		}
+	}
+	return tcell.StyleDefault.Foreground(toColor(fg)).Background(toColor(bg)).Attributes(ta)
+}
+
+func toColor(c mog.Color) tcell.Color {
+	switch {
+	case !c.Valid():
+		return tcell.ColorDefault
+	case c.IsRGB():
+		return tcell.NewHexColor(c.Hex())
+	}
+	return tcell.PaletteColor(int(c - mog.ColorValid))
+}
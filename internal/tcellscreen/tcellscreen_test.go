@@ -0,0 +1,70 @@
+package tcellscreen
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/stretchr/testify/assert"
+
+	"elyria.io/mog/internal/mog"
+)
+
+func newTestScreen(t *testing.T) (mog.Screen, tcell.SimulationScreen) {
+	ss := tcell.NewSimulationScreen("UTF-8")
+	s := Wrap(ss)
+	assert.Nil(t, s.Init())
+	t.Cleanup(s.Fini)
+	return s, ss
+}
+
+func TestPollEventConvertsEvents(t *testing.T) {
+	s, ss := newTestScreen(t)
+
+	ss.InjectKey(tcell.KeyRune, 'x', tcell.ModAlt)
+	ss.InjectKey(tcell.KeyCtrlR, 0, tcell.ModCtrl)
+	ss.InjectKey(tcell.KeyF1, 0, tcell.ModNone)
+	ss.InjectKey(tcell.KeyPgDn, 0, tcell.ModNone)
+	ss.InjectMouse(3, 4, tcell.Button1|tcell.WheelDown, tcell.ModNone)
+
+	ev := s.PollEvent().(*mog.EventKey)
+	assert.EqualValues(t, "Rune[x]", ev.Name())
+	assert.EqualValues(t, mog.ModAlt, ev.Modifiers())
+	ev = s.PollEvent().(*mog.EventKey)
+	assert.EqualValues(t, mog.KeyCtrlR, ev.Key())
+	assert.EqualValues(t, mog.ModCtrl, ev.Modifiers())
+	// F1 means nothing to the editor and is skipped.
+	ev = s.PollEvent().(*mog.EventKey)
+	assert.EqualValues(t, mog.KeyPgDn, ev.Key())
+	mouse := s.PollEvent().(*mog.EventMouse)
+	x, y := mouse.Position()
+	assert.EqualValues(t, []int{3, 4}, []int{x, y})
+	assert.EqualValues(t, mog.Button1|mog.WheelDown, mouse.Buttons())
+}
+
+func TestPostEvent(t *testing.T) {
+	s, _ := newTestScreen(t)
+
+	posted := mog.NewEventPaste(true)
+	assert.Nil(t, s.PostEvent(posted))
+
+	assert.Same(t, posted, s.PollEvent())
+}
+
+func TestSetContentConvertsStyle(t *testing.T) {
+	s, ss := newTestScreen(t)
+
+	style := mog.StyleDefault.
+		Foreground(mog.PaletteColor(9)).
+		Background(mog.NewHexColor(0x123456)).
+		Attributes(mog.AttrBold | mog.AttrUnderline)
+	s.SetContent(1, 0, 'a', nil, style)
+	s.Show()
+
+	cells, _, _ := ss.GetContents()
+	assert.EqualValues(t, []rune{'a'}, cells[1].Runes)
+	want := tcell.StyleDefault.
+		Foreground(tcell.PaletteColor(9)).
+		Background(tcell.NewHexColor(0x123456)).
+		Attributes(tcell.AttrBold | tcell.AttrUnderline)
+	assert.EqualValues(t, want, cells[1].Style)
+}